@@ -2,10 +2,14 @@ package main
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/dag-andersen/argocd-diff-preview/pkg/appproject"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/argocd"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/diff"
@@ -13,6 +17,7 @@ import (
 	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/immutable_fields"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/k8s"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/reposerverextract"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
@@ -70,6 +75,13 @@ func run(cfg *Config) error {
 	baseBranch := git.NewBranch(cfg.BaseBranch, git.Base)
 	targetBranch := git.NewBranch(cfg.TargetBranch, git.Target)
 
+	if cfg.BranchArgocdConfigPath != "" {
+		if err := useBranchArgoCDConfig(cfg, baseBranch, targetBranch); err != nil {
+			log.Error().Msgf("❌ Failed to compare the Argo CD config of the branches")
+			return err
+		}
+	}
+
 	if cfg.AutoDetectFilesChanged && len(filesChanged) == 0 {
 		log.Info().Msg("🔍 Auto-detecting changed files")
 		cf, duration, err := fileparsing.ListChangedFiles(baseBranch.FolderName(), targetBranch.FolderName())
@@ -81,17 +93,28 @@ func run(cfg *Config) error {
 		filesChanged = cf
 	}
 
-	// Check if users limited the Application Selection
-	searchIsLimited := len(selectors) > 0 || len(filesChanged) > 0 || fileRegex != nil
-
 	appSelectionOptions := argoapplication.ApplicationSelectionOptions{
 		Selector:                   selectors,
 		FileRegex:                  fileRegex,
+		IncludeFiles:               cfg.IncludeFiles,
+		BootstrapFolders:           cfg.BootstrapFolders,
 		FilesChanged:               filesChanged,
 		IgnoreInvalidWatchPattern:  cfg.IgnoreInvalidWatchPattern,
 		WatchIfNoWatchPatternFound: cfg.WatchIfNoWatchPatternFound,
+		InferDependencies:          cfg.InferDependencies,
+		RepoSelector:               cfg.RepoSelector,
+		DestinationNames:           cfg.DestinationNames,
+		DestinationServers:         cfg.DestinationServers,
+		DestinationNamespaces:      cfg.DestinationNamespaces,
+		Projects:                   cfg.Projects,
+		SourceRepoURLs:             cfg.SourceRepoURLs,
+		SourceCharts:               cfg.SourceCharts,
+		SourcePaths:                cfg.SourcePaths,
 	}
 
+	// Check if users limited the Application Selection
+	searchIsLimited := len(selectors) > 0 || len(filesChanged) > 0 || fileRegex != nil || len(cfg.IncludeFiles) > 0 || appSelectionOptions.HasSpecCriteria()
+
 	// Get applications for both branches
 	baseApps, targetApps, err := argoapplication.GetApplicationsForBranches(
 		cfg.ArgocdNamespace,
@@ -106,7 +129,31 @@ func run(cfg *Config) error {
 		return err
 	}
 
-	baseApps, targetApps = duplicates.RemoveIdenticalCopiesBetweenBranches(baseApps, targetApps)
+	// Collect the manifests that could not be parsed or are not valid before the
+	// selections are replaced by the ApplicationSet conversion
+	manifestProblems := diff.NewManifestProblems(baseApps, targetApps)
+	targetAppProjects := targetApps.AppProjects
+
+	// Applications that are the same in both branches can render differently with another Argo CD,
+	// so they are only removed when both branches are rendered with the same Argo CD
+	if !cfg.RendersTargetWithOtherArgoCD() {
+		baseApps, targetApps = duplicates.RemoveIdenticalCopiesBetweenBranches(baseApps, targetApps)
+	}
+
+	// Diff the Application[Set] manifests themselves before ApplicationSets are converted to Applications
+	var specChanges diff.SpecChanges
+	if cfg.ShowAppSpecChanges {
+		specChanges, err = diff.BuildSpecChanges(baseApps.SelectedApps, targetApps.SelectedApps, cfg.LineCount)
+		if err != nil {
+			log.Error().Msgf("❌ Failed to diff Application specs")
+			return err
+		}
+	}
+
+	// Keep track of why each application was selected or skipped
+	selectionReport := diff.NewSelectionReport()
+	selectionReport.Add(git.Base, baseApps)
+	selectionReport.Add(git.Target, targetApps)
 
 	// If dry-run is enabled, show which applications would be processed and exit
 	if cfg.DryRun {
@@ -129,8 +176,18 @@ func run(cfg *Config) error {
 			log.Info().Msgf("🤷 No applications selected for the target branch ('%s').", targetBranch.Name)
 		}
 
+		// The output folder is not cleared in a dry run, only the selection report is written
+		if err := utils.CreateFolder(cfg.OutputFolder, false); err != nil {
+			log.Error().Msgf("❌ Failed to create output folder: %s", cfg.OutputFolder)
+			return err
+		}
+		if err := selectionReport.WriteToFolder(cfg.OutputFolder); err != nil {
+			log.Error().Msgf("❌ Failed to write selection report")
+			return err
+		}
+
 		log.Info().Msg("✅ Dry run complete. No cluster was created and no diff was generated.")
-		return nil
+		return checkManifestProblems(manifestProblems, cfg.FailOnManifestProblems)
 	}
 
 	// Return if no applications are found
@@ -150,7 +207,12 @@ func run(cfg *Config) error {
 			return err
 		}
 
-		return nil
+		if err := selectionReport.WriteToFolder(cfg.OutputFolder); err != nil {
+			log.Error().Msgf("❌ Failed to write selection report")
+			return err
+		}
+
+		return checkManifestProblems(manifestProblems, cfg.FailOnManifestProblems)
 	}
 
 	var clusterCreationDuration time.Duration
@@ -196,19 +258,7 @@ func run(cfg *Config) error {
 		return err
 	}
 
-	argocd := argocd.New(
-		k8sClient,
-		cfg.ArgocdNamespace,
-		cfg.ArgocdChartVersion,
-		cfg.ArgocdChartName,
-		cfg.ArgocdChartURL,
-		cfg.ArgocdChartRepoUsername,
-		cfg.ArgocdChartRepoPassword,
-		cfg.ArgocdLoginOptions,
-		cfg.RenderMethod,
-		cfg.ArgocdAuthToken,
-		cfg.ArgocdConfigPath,
-	)
+	argocd := newArgoCDInstallation(cfg, k8sClient, cfg.ArgocdChartVersion, cfg.ArgocdConfigPath)
 
 	// Ensure cleanup is performed when we exit (e.g., stopping port forwards)
 	defer argocd.Cleanup()
@@ -240,7 +290,7 @@ func run(cfg *Config) error {
 	defer close(stopCrashWatcher)
 
 	// Generate applications from ApplicationSets
-	baseApps, targetApps, convertAppSetsToAppsDuration, err := argoapplication.ConvertAppSetsToAppsInBothBranches(
+	baseApps, targetApps, generatedAppChanges, convertAppSetsToAppsDuration, err := argoapplication.ConvertAppSetsToAppsInBothBranches(
 		argocd,
 		baseApps,
 		targetApps,
@@ -258,8 +308,21 @@ func run(cfg *Config) error {
 		return err
 	}
 
+	var appSetChanges diff.AppSetChanges
+	if cfg.ShowAppSetChanges {
+		appSetChanges, err = diff.BuildAppSetChanges(generatedAppChanges, cfg.LineCount)
+		if err != nil {
+			log.Error().Msgf("❌ Failed to diff Applications generated by ApplicationSets")
+			return err
+		}
+	}
+
 	// Check for duplicates again
-	baseApps, targetApps = duplicates.RemoveIdenticalCopiesBetweenBranches(baseApps, targetApps)
+	if !cfg.RendersTargetWithOtherArgoCD() {
+		baseApps, targetApps = duplicates.RemoveIdenticalCopiesBetweenBranches(baseApps, targetApps)
+	}
+	selectionReport.Add(git.Base, baseApps)
+	selectionReport.Add(git.Target, targetApps)
 
 	// Return if no applications are found
 	foundBaseApps = len(baseApps.SelectedApps) > 0
@@ -278,7 +341,12 @@ func run(cfg *Config) error {
 			return err
 		}
 
-		return nil
+		if err := selectionReport.WriteToFolder(cfg.OutputFolder); err != nil {
+			log.Error().Msgf("❌ Failed to write selection report")
+			return err
+		}
+
+		return checkManifestProblems(manifestProblems, cfg.FailOnManifestProblems)
 	}
 
 	// enure unique ids
@@ -290,6 +358,18 @@ func run(cfg *Config) error {
 		return err
 	}
 
+	if err := selectionReport.WriteToFolder(cfg.OutputFolder); err != nil {
+		log.Error().Msgf("❌ Failed to write selection report")
+		return err
+	}
+
+	if cfg.ShowAppSetChanges {
+		if err := appSetChanges.WriteToFolder(cfg.OutputFolder); err != nil {
+			log.Error().Msgf("❌ Failed to write ApplicationSet changes")
+			return err
+		}
+	}
+
 	// Advice the user to limit the Application Selection
 	if !searchIsLimited && (len(baseApps.SelectedApps) > 50 || len(targetApps.SelectedApps) > 50) {
 		log.Warn().Msgf("💡 You are rendering %d Applications. You might want to limit the Application rendered on each run.", len(baseApps.SelectedApps)+len(targetApps.SelectedApps))
@@ -317,54 +397,83 @@ func run(cfg *Config) error {
 	var baseManifests, targetManifests []extract.ExtractedApp
 	var extractDuration time.Duration
 
-	if cfg.RenderMethod == RenderMethodRepoServerAPI {
-
-		// Extract resources by streaming source files directly to the Argo CD repo server via gRPC.
-		// This bypasses the cluster reconciliation loop used by extract.RenderApplicationsFromBothBranches.
-		if cfg.TraverseAppOfApps {
-			baseManifests, targetManifests, extractDuration, err = reposerverextract.RenderApplicationsFromBothBranchesWithAppOfApps(
-				argocd,
-				baseBranch,
-				targetBranch,
-				cfg.Timeout,
-				cfg.Concurrency,
-				baseApps.SelectedApps,
-				targetApps.SelectedApps,
-				cfg.RepoSelector,
-				appSelectionOptions,
-				tempFolder,
-				redirectRevisions,
-			)
-		} else {
-			baseManifests, targetManifests, extractDuration, err = reposerverextract.RenderApplicationsFromBothBranches(
-				argocd,
-				baseBranch,
-				targetBranch,
-				cfg.Timeout,
-				cfg.Concurrency,
-				baseApps.SelectedApps,
-				targetApps.SelectedApps,
-				cfg.RepoSelector,
-			)
-		}
+	if cfg.RendersTargetWithOtherArgoCD() {
+		// Render the base branch with the current Argo CD and the target branch with the other one
+		var reinstallDuration time.Duration
+		baseManifests, targetManifests, extractDuration, reinstallDuration, err = renderWithTargetArgoCD(cfg, argocd, k8sClient, baseBranch, targetBranch, baseApps.SelectedApps, targetApps.SelectedApps, appSelectionOptions, tempFolder, uniqueID)
+		argocdInstallationDuration += reinstallDuration
 	} else {
-		// Extract resources from the cluster based on each branch, passing the manifests directly
-		deleteAfterProcessing := !cfg.CreateCluster
-		baseManifests, targetManifests, extractDuration, err = extract.RenderApplicationsFromBothBranches(
-			argocd,
-			cfg.Timeout,
-			cfg.Concurrency,
-			baseApps.SelectedApps,
-			targetApps.SelectedApps,
-			uniqueID,
-			deleteAfterProcessing,
-		)
+		baseManifests, targetManifests, extractDuration, err = renderApplications(cfg, argocd, baseBranch, targetBranch, baseApps.SelectedApps, targetApps.SelectedApps, appSelectionOptions, tempFolder, uniqueID)
 	}
 	if err != nil {
 		log.Error().Msg("❌ Failed to extract resources")
 		return err
 	}
 
+	// Render the same Applications with a second render method and report where they differ
+	var renderCrossCheck diff.RenderMethodCrossCheck
+	if cfg.CrossCheckRenderMethod != "" {
+		renderCrossCheck, err = crossCheckRenderMethod(cfg, argocd, baseBranch, targetBranch, baseApps.SelectedApps, targetApps.SelectedApps, baseManifests, targetManifests, uniqueID)
+		if err != nil {
+			log.Error().Msgf("❌ Failed to cross-check the render method with %s", cfg.CrossCheckRenderMethod)
+			return err
+		}
+	}
+
+	// Helm hooks are left out of the diff unless requested
+	if cfg.IncludeHelmHooks {
+		extract.IncludeHelmHooks(baseManifests)
+		extract.IncludeHelmHooks(targetManifests)
+	}
+
+	// Mask the fields of the global ignore rules before anything is compared
+	maskedFields := extract.ApplyGlobalIgnoreDifferences(baseManifests, targetManifests, cfg.IgnoreDifferences)
+
+	// Hide fields that change on every render, like generated passwords and certificates
+	var nonDeterministicFields []extract.NonDeterministicField
+	if cfg.CheckDeterminism {
+		checkStart := time.Now()
+		nonDeterministicFields, err = checkDeterminism(cfg, argocd, baseBranch, targetBranch, baseApps.SelectedApps, baseManifests)
+		if err != nil {
+			log.Error().Msg("❌ Failed to check if the rendered manifests are deterministic")
+			return err
+		}
+		extract.MaskNonDeterministicFields(baseManifests, nonDeterministicFields)
+		extract.MaskNonDeterministicFields(targetManifests, nonDeterministicFields)
+		extractDuration += time.Since(checkStart)
+		if len(nonDeterministicFields) > 0 {
+			log.Warn().Msgf("⚠️ Found %d fields that differ between two renders of the base branch. They are hidden in the diff", len(nonDeterministicFields))
+		} else {
+			log.Info().Msg("🎲 Rendered manifests are deterministic")
+		}
+	}
+
+	discoveredScopes, err := k8sClient.GetResourceScopes()
+	if err != nil {
+		log.Warn().Err(err).Msg("⚠️ Failed to discover the resource types of the cluster. Guessing which kinds are cluster-scoped")
+	}
+	resourceScopes := diff.NewResourceScopes(discoveredScopes, baseManifests, targetManifests)
+
+	var projectViolations diff.ProjectViolations
+	if cfg.ValidateAppProjects {
+		projectViolations, err = validateAppProjects(targetAppProjects, cfg.SecretsFolder, cfg.ArgocdNamespace, targetApps.SelectedApps, targetManifests, resourceScopes)
+		if err != nil {
+			log.Error().Msg("❌ Failed to validate AppProjects")
+			return err
+		}
+	}
+
+	sharedResources := diff.FindSharedResources(baseApps.SelectedApps, targetApps.SelectedApps, baseManifests, targetManifests, cfg.IgnoreResourceRules, resourceScopes)
+	if len(sharedResources) > 0 {
+		log.Warn().Msgf("⚠️ Found %d resources rendered by multiple Applications (%d new)", len(sharedResources), sharedResources.NewCount())
+	}
+
+	guardedKinds := append(slices.Clone(diff.DefaultGuardedKinds), cfg.FailOnDeletedKinds...)
+	deletedResources := diff.FindDeletedResources(baseApps.SelectedApps, targetApps.SelectedApps, baseManifests, targetManifests, cfg.IgnoreResourceRules, guardedKinds, resourceScopes)
+	if len(deletedResources) > 0 {
+		log.Warn().Msgf("⚠️ Found %d deleted stateful resources (%d will be deleted by Argo CD or by a manual sync with pruning)", len(deletedResources), deletedResources.DeletedCount(guardedKinds))
+	}
+
 	// Create info box for storing run time information
 	statsInfo := diff.StatsInfo{
 		FullDuration:               time.Since(startTime),
@@ -387,22 +496,35 @@ func run(cfg *Config) error {
 	}
 
 	// Generate diff
-	previewDuration, err := diff.GeneratePreview(
-		cfg.Title,
-		cfg.OutputFolder,
-		baseBranch,
-		targetBranch,
-		baseManifests,
-		targetManifests,
-		&cfg.DiffIgnore,
-		cfg.LineCount,
-		cfg.MaxDiffLength,
-		cfg.HideDeletedAppDiff,
-		statsInfo,
-		selectionInfo,
-		cfg.ArgocdUIURL,
-		cfg.IgnoreResourceRules,
-	)
+	previewDuration, err := diff.GeneratePreview(diff.PreviewInput{
+		Title:                   cfg.Title,
+		OutputFolder:            cfg.OutputFolder,
+		BaseBranch:              baseBranch,
+		TargetBranch:            targetBranch,
+		BaseManifests:           baseManifests,
+		TargetManifests:         targetManifests,
+		DiffIgnoreRegex:         &cfg.DiffIgnore,
+		LineCount:               cfg.LineCount,
+		MaxCharCount:            cfg.MaxDiffLength,
+		HideDeletedAppDiff:      cfg.HideDeletedAppDiff,
+		IgnoreResourceRules:     cfg.IgnoreResourceRules,
+		ImmutableFields:         append(slices.Clone(immutable_fields.BuiltIn), cfg.ImmutableFields...),
+		ExcludeIgnoreExtraneous: cfg.ExcludeIgnoreExtraneous,
+		ShowPlan:                cfg.ShowPlan,
+		ShowSyncPlan:            cfg.ShowSyncPlan,
+		ArgocdUIURL:             cfg.ArgocdUIURL,
+		StatsInfo:               statsInfo,
+		SelectionInfo:           selectionInfo,
+		ManifestProblems:        manifestProblems,
+		ProjectViolations:       projectViolations,
+		SharedResources:         sharedResources,
+		DeletedResources:        deletedResources,
+		SpecChanges:             specChanges,
+		AppSetChanges:           appSetChanges,
+		IgnoredFields:           diff.IgnoredFields(maskedFields),
+		NonDeterministicFields:  diff.NonDeterministicFields(nonDeterministicFields),
+		RenderCrossCheck:        renderCrossCheck,
+	})
 	if err != nil {
 		log.Error().Msg("❌ Failed to generate diff")
 		return err
@@ -417,9 +539,333 @@ func run(cfg *Config) error {
 
 	log.Info().Msgf("⏰ Run time stats: %s", statsInfo.Stats())
 
+	if err := checkManifestProblems(manifestProblems, cfg.FailOnManifestProblems); err != nil {
+		return err
+	}
+	if err := checkSharedResources(sharedResources, cfg.FailOnSharedResources); err != nil {
+		return err
+	}
+	return checkDeletedResources(deletedResources, cfg.FailOnDeletedKinds)
+}
+
+// renderApplications renders the Applications of both branches with the configured render method
+func renderApplications(
+	cfg *Config,
+	argocdInstallation *argocd.ArgoCDInstallation,
+	baseBranch *git.Branch,
+	targetBranch *git.Branch,
+	baseApps []argoapplication.ArgoResource,
+	targetApps []argoapplication.ArgoResource,
+	appSelectionOptions argoapplication.ApplicationSelectionOptions,
+	tempFolder string,
+	uniqueID string,
+) ([]extract.ExtractedApp, []extract.ExtractedApp, time.Duration, error) {
+	if cfg.RenderMethod == RenderMethodRepoServerAPI {
+		// Extract resources by streaming source files directly to the Argo CD repo server via gRPC.
+		// This bypasses the cluster reconciliation loop used by extract.RenderApplicationsFromBothBranches.
+		if cfg.TraverseAppOfApps {
+			return reposerverextract.RenderApplicationsFromBothBranchesWithAppOfApps(
+				argocdInstallation,
+				baseBranch,
+				targetBranch,
+				cfg.Timeout,
+				cfg.Concurrency,
+				baseApps,
+				targetApps,
+				cfg.RepoSelector,
+				appSelectionOptions,
+				tempFolder,
+				cfg.RedirectRevisions,
+			)
+		}
+		return reposerverextract.RenderApplicationsFromBothBranches(
+			argocdInstallation,
+			baseBranch,
+			targetBranch,
+			cfg.Timeout,
+			cfg.Concurrency,
+			baseApps,
+			targetApps,
+			cfg.RepoSelector,
+			false,
+		)
+	}
+
+	// Extract resources from the cluster based on each branch, passing the manifests directly
+	deleteAfterProcessing := !cfg.CreateCluster
+	return extract.RenderApplicationsFromBothBranches(
+		argocdInstallation,
+		cfg.Timeout,
+		cfg.Concurrency,
+		baseApps,
+		targetApps,
+		uniqueID,
+		deleteAfterProcessing,
+	)
+}
+
+// renderWithTargetArgoCD renders the base branch with the installed Argo CD, then reinstalls
+// Argo CD with --target-argocd-chart-version and --target-argocd-config-dir and renders the
+// target branch with it. Both branches usually point at the same commit in this mode.
+func renderWithTargetArgoCD(
+	cfg *Config,
+	argocdInstallation *argocd.ArgoCDInstallation,
+	k8sClient *k8s.Client,
+	baseBranch *git.Branch,
+	targetBranch *git.Branch,
+	baseApps []argoapplication.ArgoResource,
+	targetApps []argoapplication.ArgoResource,
+	appSelectionOptions argoapplication.ApplicationSelectionOptions,
+	tempFolder string,
+	uniqueID string,
+) ([]extract.ExtractedApp, []extract.ExtractedApp, time.Duration, time.Duration, error) {
+	baseManifests, _, baseDuration, err := renderApplications(cfg, argocdInstallation, baseBranch, targetBranch, baseApps, nil, appSelectionOptions, tempFolder, uniqueID)
+	if err != nil {
+		return nil, nil, baseDuration, 0, err
+	}
+
+	if err := argocdInstallation.Uninstall(); err != nil {
+		return nil, nil, baseDuration, 0, err
+	}
+
+	chartVersion, configPath := cfg.targetArgoCDChartVersionAndConfig()
+	log.Info().Msgf("🦑 Installing Argo CD for the %s-branch (chart version: '%s', config: '%s')", git.Target, chartVersion, configPath)
+	targetArgocd := newArgoCDInstallation(cfg, k8sClient, chartVersion, configPath)
+	defer targetArgocd.Cleanup()
+
+	installDuration, err := targetArgocd.Install(cfg.Debug, cfg.SecretsFolder)
+	if err != nil {
+		log.Error().Msgf("❌ Failed to install Argo CD for the %s-branch", git.Target)
+		return nil, nil, baseDuration, installDuration, err
+	}
+
+	_, targetManifests, targetDuration, err := renderApplications(cfg, targetArgocd, baseBranch, targetBranch, nil, targetApps, appSelectionOptions, tempFolder, uniqueID)
+	if err != nil {
+		return nil, nil, baseDuration + targetDuration, installDuration, err
+	}
+
+	return baseManifests, targetManifests, baseDuration + targetDuration, installDuration, nil
+}
+
+// newArgoCDInstallation creates the Argo CD installation of the configured chart, with the
+// given chart version and config folder
+func newArgoCDInstallation(cfg *Config, k8sClient *k8s.Client, chartVersion string, configPath string) *argocd.ArgoCDInstallation {
+	return argocd.New(
+		k8sClient,
+		cfg.ArgocdNamespace,
+		chartVersion,
+		cfg.ArgocdChartName,
+		cfg.ArgocdChartURL,
+		cfg.ArgocdChartRepoUsername,
+		cfg.ArgocdChartRepoPassword,
+		cfg.ArgocdLoginOptions,
+		cfg.RenderMethod,
+		cfg.ArgocdAuthToken,
+		configPath,
+	)
+}
+
+// useBranchArgoCDConfig installs Argo CD from the config folder of the base branch, and from
+// the config folder of the target branch for the target branch if the two differ
+func useBranchArgoCDConfig(cfg *Config, baseBranch *git.Branch, targetBranch *git.Branch) error {
+	baseConfigPath := filepath.Join(baseBranch.FolderName(), cfg.BranchArgocdConfigPath)
+	targetConfigPath := filepath.Join(targetBranch.FolderName(), cfg.BranchArgocdConfigPath)
+	cfg.ArgocdConfigPath = baseConfigPath
+
+	differs, err := argocd.ConfigDiffers(baseConfigPath, targetConfigPath)
+	if err != nil {
+		return err
+	}
+	if !differs {
+		log.Info().Msgf("🔧 Argo CD config '%s' is the same in both branches", cfg.BranchArgocdConfigPath)
+		return nil
+	}
+
+	log.Info().Msgf("🔧 Argo CD config '%s' changed in the %s-branch. Argo CD is reinstalled with it before the %s-branch is rendered", cfg.BranchArgocdConfigPath, git.Target, git.Target)
+	cfg.TargetArgocdConfigPath = targetConfigPath
 	return nil
 }
 
+// crossCheckRenderMethod renders the Applications of both branches with the cross-check render
+// method and diffs them against the manifests rendered with --render-method. A failing render
+// is reported in the output instead of failing the run, since it is a divergence as well.
+func crossCheckRenderMethod(
+	cfg *Config,
+	argocdInstallation *argocd.ArgoCDInstallation,
+	baseBranch *git.Branch,
+	targetBranch *git.Branch,
+	baseApps []argoapplication.ArgoResource,
+	targetApps []argoapplication.ArgoResource,
+	baseManifests []extract.ExtractedApp,
+	targetManifests []extract.ExtractedApp,
+	uniqueID string,
+) (diff.RenderMethodCrossCheck, error) {
+	result := diff.RenderMethodCrossCheck{
+		Method:           string(cfg.RenderMethod),
+		CrossCheckMethod: string(cfg.CrossCheckRenderMethod),
+	}
+
+	log.Info().Msgf("🔁 Rendering Applications again with %s to cross-check %s", cfg.CrossCheckRenderMethod, cfg.RenderMethod)
+	var crossCheckBase, crossCheckTarget []extract.ExtractedApp
+	var duration time.Duration
+	var err error
+	if cfg.CrossCheckRenderMethod == RenderMethodRepoServerAPI {
+		crossCheckBase, crossCheckTarget, duration, err = reposerverextract.RenderApplicationsFromBothBranches(
+			argocdInstallation,
+			baseBranch,
+			targetBranch,
+			cfg.Timeout,
+			cfg.Concurrency,
+			baseApps,
+			targetApps,
+			cfg.RepoSelector,
+			false,
+		)
+	} else {
+		crossCheckBase, crossCheckTarget, duration, err = extract.RenderApplicationsFromBothBranches(
+			argocdInstallation,
+			cfg.Timeout,
+			cfg.Concurrency,
+			baseApps,
+			targetApps,
+			uniqueID,
+			!cfg.CreateCluster,
+		)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msgf("⚠️ Failed to render Applications with %s", cfg.CrossCheckRenderMethod)
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	for _, branch := range []struct {
+		branchType git.BranchType
+		manifests  []extract.ExtractedApp
+		crossCheck []extract.ExtractedApp
+	}{
+		{git.Base, baseManifests, crossCheckBase},
+		{git.Target, targetManifests, crossCheckTarget},
+	} {
+		divergences, err := diff.BuildRenderDivergences(branch.branchType, branch.manifests, branch.crossCheck, cfg.LineCount, cfg.IgnoreResourceRules)
+		if err != nil {
+			return result, err
+		}
+		result.Divergences = append(result.Divergences, divergences...)
+	}
+
+	if len(result.Divergences) > 0 {
+		log.Warn().Msgf("⚠️ %d Applications render differently with %s and %s", len(result.Divergences), cfg.RenderMethod, cfg.CrossCheckRenderMethod)
+	} else {
+		log.Info().Msgf("✅ All Applications render the same with %s and %s (cross-check took %s)", cfg.RenderMethod, cfg.CrossCheckRenderMethod, duration.Round(time.Second))
+	}
+	return result, nil
+}
+
+// checkDeterminism renders the Applications of the base branch again, bypassing the repo
+// server cache, and returns the fields that differ from the first render. The second render
+// gets the same post-processing as the first, so only the rendering itself is compared.
+func checkDeterminism(
+	cfg *Config,
+	argocdInstallation *argocd.ArgoCDInstallation,
+	baseBranch *git.Branch,
+	targetBranch *git.Branch,
+	baseApps []argoapplication.ArgoResource,
+	baseManifests []extract.ExtractedApp,
+) ([]extract.NonDeterministicField, error) {
+	log.Info().Msgf("🎲 Rendering %d Applications from the %s-branch again to check if they are deterministic", len(baseApps), git.Base)
+	secondRender, _, _, err := reposerverextract.RenderApplicationsFromBothBranches(
+		argocdInstallation,
+		baseBranch,
+		targetBranch,
+		cfg.Timeout,
+		cfg.Concurrency,
+		baseApps,
+		nil,
+		cfg.RepoSelector,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.IncludeHelmHooks {
+		extract.IncludeHelmHooks(secondRender)
+	}
+	extract.ApplyGlobalIgnoreDifferences(secondRender, nil, cfg.IgnoreDifferences)
+
+	return extract.FindNonDeterministicFields(baseManifests, secondRender), nil
+}
+
+// validateAppProjects validates the rendered Applications of the target branch against the
+// AppProjects of the target branch and the secrets folder. The target branch takes
+// precedence when both define the same AppProject.
+func validateAppProjects(
+	targetAppProjects map[string]appproject.Project,
+	secretsFolder string,
+	argocdNamespace string,
+	apps []argoapplication.ArgoResource,
+	manifests []extract.ExtractedApp,
+	resourceScopes diff.ResourceScopes,
+) (diff.ProjectViolations, error) {
+	projects, err := appproject.LoadFolder(secretsFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AppProjects from '%s': %w", secretsFolder, err)
+	}
+	maps.Copy(projects, targetAppProjects)
+	log.Info().Msgf("🔒 Validating Applications against %d AppProjects", len(projects))
+
+	manifestsByID := make(map[string]extract.ExtractedApp, len(manifests))
+	for _, m := range manifests {
+		manifestsByID[m.Id] = m
+	}
+
+	var appsToValidate []appproject.App
+	for _, app := range apps {
+		if app.Kind != argoapplication.Application {
+			continue
+		}
+		rendered, found := manifestsByID[app.Id]
+		if !found {
+			continue
+		}
+		appsToValidate = append(appsToValidate, appproject.NewApp(app.Unpatched(), app.Name, app.FileName, rendered.Manifests))
+	}
+
+	return diff.NewProjectViolations(appproject.Validate(projects, appsToValidate, argocdNamespace, resourceScopes.IsClusterScoped)), nil
+}
+
+// checkManifestProblems returns an error if manifest problems other than warnings were found and the run
+// should fail because of them
+func checkManifestProblems(problems diff.ManifestProblems, failOnManifestProblems bool) error {
+	errorCount := problems.ErrorCount()
+	if errorCount == 0 || !failOnManifestProblems {
+		return nil
+	}
+	log.Error().Msgf("❌ Found %d problems in Application[Set] manifests. Failing because --fail-on-manifest-problems is enabled", errorCount)
+	return fmt.Errorf("found %d problems in Application[Set] manifests", errorCount)
+}
+
+// checkSharedResources returns an error if the target branch introduces resources rendered
+// by multiple Applications and the run should fail because of them
+func checkSharedResources(sharedResources diff.SharedResources, failOnSharedResources bool) error {
+	newCount := sharedResources.NewCount()
+	if newCount == 0 || !failOnSharedResources {
+		return nil
+	}
+	log.Error().Msgf("❌ Found %d new resources rendered by multiple Applications. Failing because --fail-on-shared-resources is enabled", newCount)
+	return fmt.Errorf("found %d new resources rendered by multiple Applications", newCount)
+}
+
+// checkDeletedResources returns an error if Argo CD deletes resources of the kinds the run
+// should fail on
+func checkDeletedResources(deletedResources diff.DeletedResources, failOnDeletedKinds []string) error {
+	deletedCount := deletedResources.DeletedCount(failOnDeletedKinds)
+	if deletedCount == 0 {
+		return nil
+	}
+	log.Error().Msgf("❌ Found %d resources that will be deleted by Argo CD or by a manual sync with pruning. Failing because their kinds are in --fail-on-deleted-kinds (%s)", deletedCount, strings.Join(failOnDeletedKinds, ", "))
+	return fmt.Errorf("found %d resources of kinds in --fail-on-deleted-kinds that will be deleted", deletedCount)
+}
+
 // writeManifests flattens apps once and writes manifest files based on the enabled options.
 // If perApp is true, each app is written to its own file under <outputFolder>/<branchType>/.
 // If perBranch is true, all apps are concatenated into <outputFolder>/<branchType>-branch.yaml.
@@ -20,7 +20,9 @@ import (
 
 	"github.com/dag-andersen/argocd-diff-preview/pkg/app_selector"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/cluster"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/immutable_fields"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/k3d"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/kind"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/minikube"
@@ -74,19 +76,38 @@ var (
 	DefaultAutoDetectFilesChanged               = true
 	DefaultWatchIfNoWatchPatternFound           = true
 	DefaultIgnoreInvalidWatchPattern            = false
+	DefaultInferDependencies                    = false
 	DefaultHideDeletedAppDiff                   = false
 	DefaultIgnoreResourceRules                  = ""
+	DefaultOnlyResourceRules                    = ""
+	DefaultImmutableFields                      = ""
+	DefaultIgnoreDifferences                    = ""
+	DefaultFailOnDeletedKinds                   = ""
 	DefaultArgocdLoginOptions                   = ""
 	DefaultDisableClientThrottling              = true
 	DefaultArgocdAuthToken                      = ""
 	DefaultArgocdUIURL                          = ""
 	DefaultConcurrency                          = uint(40)
 	DefaultRenderMethod                         = "server-api"
+	DefaultCrossCheckRenderMethod               = ""
 	DefaultArgocdConfigPath                     = "./argocd-config"
+	DefaultTargetArgocdChartVersion             = ""
+	DefaultTargetArgocdConfigPath               = ""
+	DefaultBranchArgocdConfigPath               = ""
 	DefaultOutputAppManifests                   = false
 	DefaultOutputBranchManifests                = false
 	DefaultTraverseAppOfApps                    = false
 	DefaultFailOnDuplicateGeneratedApplications = false
+	DefaultFailOnManifestProblems               = false
+	DefaultShowAppSpecChanges                   = false
+	DefaultShowAppSetChanges                    = false
+	DefaultValidateAppProjects                  = false
+	DefaultFailOnSharedResources                = false
+	DefaultShowPlan                             = false
+	DefaultExcludeIgnoreExtraneous              = false
+	DefaultShowSyncPlan                         = false
+	DefaultIncludeHelmHooks                     = false
+	DefaultCheckDeterminism                     = false
 )
 
 // RawOptions holds the raw CLI/env inputs - used only for parsing
@@ -95,6 +116,8 @@ type RawOptions struct {
 	DryRun                               bool   `mapstructure:"dry-run"`
 	Timeout                              uint64 `mapstructure:"timeout"`
 	FileRegex                            string `mapstructure:"file-regex"`
+	IncludeFiles                         string `mapstructure:"include-files"`
+	BootstrapFolders                     string `mapstructure:"bootstrap-folders"`
 	DiffIgnore                           string `mapstructure:"diff-ignore"`
 	LineCount                            uint   `mapstructure:"line-count"`
 	BaseBranch                           string `mapstructure:"base-branch"`
@@ -111,9 +134,17 @@ type RawOptions struct {
 	K3dOptions                           string `mapstructure:"k3d-options"`
 	MaxDiffLength                        uint   `mapstructure:"max-diff-length"`
 	Selector                             string `mapstructure:"selector"`
+	DestinationName                      string `mapstructure:"destination-name"`
+	DestinationServer                    string `mapstructure:"destination-server"`
+	DestinationNamespace                 string `mapstructure:"destination-namespace"`
+	Project                              string `mapstructure:"project"`
+	SourceRepoURL                        string `mapstructure:"source-repo-url"`
+	SourceChart                          string `mapstructure:"source-chart"`
+	SourcePath                           string `mapstructure:"source-path"`
 	FilesChanged                         string `mapstructure:"files-changed"`
 	IgnoreInvalidWatchPattern            bool   `mapstructure:"ignore-invalid-watch-pattern"`
 	WatchIfNoWatchPatternFound           bool   `mapstructure:"watch-if-no-watch-pattern-found"`
+	InferDependencies                    bool   `mapstructure:"infer-dependencies"`
 	AutoDetectFilesChanged               bool   `mapstructure:"auto-detect-files-changed"`
 	KeepClusterAlive                     bool   `mapstructure:"keep-cluster-alive"`
 	ArgocdNamespace                      string `mapstructure:"argocd-namespace"`
@@ -125,12 +156,20 @@ type RawOptions struct {
 	ArgocdLoginOptions                   string `mapstructure:"argocd-login-options"`
 	ArgocdAuthToken                      string `mapstructure:"argocd-auth-token"`
 	ArgocdConfigPath                     string `mapstructure:"argocd-config-dir"`
+	TargetArgocdChartVersion             string `mapstructure:"target-argocd-chart-version"`
+	TargetArgocdConfigPath               string `mapstructure:"target-argocd-config-dir"`
+	BranchArgocdConfigPath               string `mapstructure:"branch-argocd-config-dir"`
 	RenderMethod                         string `mapstructure:"render-method"`
+	CrossCheckRenderMethod               string `mapstructure:"cross-check-render-method"`
 	RedirectTargetRevisions              string `mapstructure:"redirect-target-revisions"`
 	LogFormat                            string `mapstructure:"log-format"`
 	Title                                string `mapstructure:"title"`
 	HideDeletedAppDiff                   bool   `mapstructure:"hide-deleted-app-diff"`
 	IgnoreResourceRules                  string `mapstructure:"ignore-resources"`
+	OnlyResourceRules                    string `mapstructure:"only-resources"`
+	ImmutableFields                      string `mapstructure:"immutable-fields"`
+	IgnoreDifferences                    string `mapstructure:"ignore-differences"`
+	FailOnDeletedKinds                   string `mapstructure:"fail-on-deleted-kinds"`
 	DisableClientThrottling              bool   `mapstructure:"disable-client-throttling"`
 	ArgocdUIURL                          string `mapstructure:"argocd-ui-url"`
 	Concurrency                          uint   `mapstructure:"concurrency"`
@@ -138,6 +177,16 @@ type RawOptions struct {
 	OutputBranchManifests                bool   `mapstructure:"output-branch-manifests"`
 	TraverseAppOfApps                    bool   `mapstructure:"traverse-app-of-apps"`
 	FailOnDuplicateGeneratedApplications bool   `mapstructure:"fail-on-duplicate-generated-applications"`
+	FailOnManifestProblems               bool   `mapstructure:"fail-on-manifest-problems"`
+	ShowAppSpecChanges                   bool   `mapstructure:"show-app-spec-changes"`
+	ShowAppSetChanges                    bool   `mapstructure:"show-appset-changes"`
+	ValidateAppProjects                  bool   `mapstructure:"validate-app-projects"`
+	FailOnSharedResources                bool   `mapstructure:"fail-on-shared-resources"`
+	ShowPlan                             bool   `mapstructure:"show-plan"`
+	ExcludeIgnoreExtraneous              bool   `mapstructure:"exclude-ignore-extraneous"`
+	ShowSyncPlan                         bool   `mapstructure:"show-sync-plan"`
+	IncludeHelmHooks                     bool   `mapstructure:"include-helm-hooks"`
+	CheckDeterminism                     bool   `mapstructure:"check-determinism"`
 }
 
 // Config is the final, validated, ready-to-use configuration
@@ -161,6 +210,7 @@ type Config struct {
 	MaxDiffLength                        uint
 	IgnoreInvalidWatchPattern            bool
 	WatchIfNoWatchPatternFound           bool
+	InferDependencies                    bool
 	AutoDetectFilesChanged               bool
 	KeepClusterAlive                     bool
 	ArgocdNamespace                      string
@@ -172,25 +222,51 @@ type Config struct {
 	ArgocdLoginOptions                   string
 	ArgocdAuthToken                      string
 	ArgocdConfigPath                     string
+	TargetArgocdChartVersion             string
+	TargetArgocdConfigPath               string
+	BranchArgocdConfigPath               string
 	LogFormat                            string
 	Title                                string
 	HideDeletedAppDiff                   bool
 	DisableClientThrottling              bool
 	RenderMethod                         RenderMethod
+	CrossCheckRenderMethod               RenderMethod
 	ArgocdUIURL                          string
 	Concurrency                          uint
 	OutputAppManifests                   bool
 	OutputBranchManifests                bool
 	TraverseAppOfApps                    bool
 	FailOnDuplicateGeneratedApplications bool
+	FailOnManifestProblems               bool
+	ShowAppSpecChanges                   bool
+	ShowAppSetChanges                    bool
+	ValidateAppProjects                  bool
+	FailOnSharedResources                bool
+	ShowPlan                             bool
+	ExcludeIgnoreExtraneous              bool
+	ShowSyncPlan                         bool
+	IncludeHelmHooks                     bool
+	CheckDeterminism                     bool
 
 	// Parsed/processed fields - no "parsed" prefix needed
-	FileRegex           *regexp.Regexp
-	Selectors           []app_selector.Selector
-	FilesChanged        []string
-	RedirectRevisions   []string
-	IgnoreResourceRules []resource_filter.IgnoreResourceRule
-	ClusterProvider     cluster.Provider
+	FileRegex             *regexp.Regexp
+	IncludeFiles          []string
+	BootstrapFolders      []string
+	Selectors             []app_selector.Selector
+	FilesChanged          []string
+	DestinationNames      []string
+	DestinationServers    []string
+	DestinationNamespaces []string
+	Projects              []string
+	SourceRepoURLs        []string
+	SourceCharts          []string
+	SourcePaths           []string
+	RedirectRevisions     []string
+	IgnoreResourceRules   []resource_filter.IgnoreResourceRule
+	ImmutableFields       []immutable_fields.ImmutableField
+	IgnoreDifferences     []extract.IgnoreDifferenceRule
+	FailOnDeletedKinds    []string
+	ClusterProvider       cluster.Provider
 }
 
 // Parse parses command line flags and environment variables, returning a validated Config
@@ -255,6 +331,7 @@ func Parse() *Config {
 	viper.SetDefault("create-cluster", DefaultCreateCluster)
 	viper.SetDefault("watch-if-no-watch-pattern-found", DefaultWatchIfNoWatchPatternFound)
 	viper.SetDefault("ignore-invalid-watch-pattern", DefaultIgnoreInvalidWatchPattern)
+	viper.SetDefault("infer-dependencies", DefaultInferDependencies)
 	viper.SetDefault("keep-cluster-alive", DefaultKeepClusterAlive)
 	viper.SetDefault("cluster", DefaultCluster)
 	viper.SetDefault("cluster-name", DefaultClusterName)
@@ -268,18 +345,36 @@ func Parse() *Config {
 	viper.SetDefault("argocd-login-options", DefaultArgocdLoginOptions)
 	viper.SetDefault("argocd-auth-token", DefaultArgocdAuthToken)
 	viper.SetDefault("render-method", DefaultRenderMethod)
+	viper.SetDefault("cross-check-render-method", DefaultCrossCheckRenderMethod)
 	viper.SetDefault("log-format", DefaultLogFormat)
 	viper.SetDefault("title", DefaultTitle)
 	viper.SetDefault("dry-run", DefaultDryRun)
 	viper.SetDefault("hide-deleted-app-diff", DefaultHideDeletedAppDiff)
 	viper.SetDefault("ignore-resources", DefaultIgnoreResourceRules)
+	viper.SetDefault("only-resources", DefaultOnlyResourceRules)
+	viper.SetDefault("immutable-fields", DefaultImmutableFields)
+	viper.SetDefault("ignore-differences", DefaultIgnoreDifferences)
+	viper.SetDefault("fail-on-deleted-kinds", DefaultFailOnDeletedKinds)
 	viper.SetDefault("disable-client-throttling", DefaultDisableClientThrottling)
 	viper.SetDefault("concurrency", DefaultConcurrency)
 	viper.SetDefault("argocd-config-dir", DefaultArgocdConfigPath)
+	viper.SetDefault("target-argocd-chart-version", DefaultTargetArgocdChartVersion)
+	viper.SetDefault("target-argocd-config-dir", DefaultTargetArgocdConfigPath)
+	viper.SetDefault("branch-argocd-config-dir", DefaultBranchArgocdConfigPath)
 	viper.SetDefault("output-app-manifests", DefaultOutputAppManifests)
 	viper.SetDefault("output-branch-manifests", DefaultOutputBranchManifests)
 	viper.SetDefault("traverse-app-of-apps", DefaultTraverseAppOfApps)
 	viper.SetDefault("fail-on-duplicate-generated-applications", DefaultFailOnDuplicateGeneratedApplications)
+	viper.SetDefault("fail-on-manifest-problems", DefaultFailOnManifestProblems)
+	viper.SetDefault("show-app-spec-changes", DefaultShowAppSpecChanges)
+	viper.SetDefault("show-appset-changes", DefaultShowAppSetChanges)
+	viper.SetDefault("validate-app-projects", DefaultValidateAppProjects)
+	viper.SetDefault("fail-on-shared-resources", DefaultFailOnSharedResources)
+	viper.SetDefault("show-plan", DefaultShowPlan)
+	viper.SetDefault("exclude-ignore-extraneous", DefaultExcludeIgnoreExtraneous)
+	viper.SetDefault("show-sync-plan", DefaultShowSyncPlan)
+	viper.SetDefault("include-helm-hooks", DefaultIncludeHelmHooks)
+	viper.SetDefault("check-determinism", DefaultCheckDeterminism)
 
 	// Basic flags
 	rootCmd.Flags().BoolP("debug", "d", false, "Activate debug mode")
@@ -289,9 +384,15 @@ func Parse() *Config {
 
 	// File and diff related
 	rootCmd.Flags().StringP("file-regex", "r", "", "Regex to select/filter files. Example: /apps_.*\\.yaml")
+	rootCmd.Flags().String("bootstrap-folders", "", "Helm chart or Kustomize folders rendered locally to find the applications they generate (comma-separated). Example: bootstrap,clusters/prod")
+	rootCmd.Flags().String("include-files", "", "Only search files matching one of these glob patterns (gitignore syntax) for applications (comma-separated). Example: apps/**,clusters/*/apps.yaml")
 	rootCmd.Flags().StringP("diff-ignore", "i", "", "Ignore lines in diff. Example: v[1,9]+.[1,9]+.[1,9]+ for ignoring version changes")
 	rootCmd.Flags().StringP("line-count", "c", fmt.Sprintf("%d", DefaultLineCount), "Generate diffs with <n> lines of context")
-	rootCmd.Flags().String("ignore-resources", DefaultIgnoreResourceRules, "Ignore resources in diff. Example: 'group:kind:name',group:kind:name")
+	rootCmd.Flags().String("ignore-resources", DefaultIgnoreResourceRules, "Ignore resources in diff. Format: group:kind:[namespace/]name[@selector]. Example: 'apps:Deployment:my-app,:ConfigMap:monitoring/*-dashboard,*:*:*@app.kubernetes.io/component=test'")
+	rootCmd.Flags().String("only-resources", DefaultOnlyResourceRules, "Only include matching resources in diff. Same format as --ignore-resources. Example: 'apps:*:*,:Service:*'")
+	rootCmd.Flags().String("immutable-fields", DefaultImmutableFields, "Additional fields that can not be updated in place and are flagged when changed. Example: 'group:kind:spec.path,group:kind:spec.path'")
+	rootCmd.Flags().String("ignore-differences", DefaultIgnoreDifferences, "Fields to ignore in all resources of both branches, in the format of spec.ignoreDifferences of an Application. Example: '[{kind: Deployment, jsonPointers: [/spec/replicas]}]'")
+	rootCmd.Flags().String("fail-on-deleted-kinds", DefaultFailOnDeletedKinds, "Fail when Argo CD will delete resources of these kinds (comma-separated). Example: Namespace,PersistentVolumeClaim")
 
 	// Argo CD related
 	rootCmd.Flags().String("argocd-chart-version", "", "Argo CD Helm Chart version")
@@ -303,6 +404,9 @@ func Parse() *Config {
 	rootCmd.Flags().String("argocd-auth-token", DefaultArgocdAuthToken, "Argo CD Auth Token for API access")
 	rootCmd.Flags().String("argocd-login-options", DefaultArgocdLoginOptions, "Additional options to pass to 'argocd login' command")
 	rootCmd.Flags().String("argocd-config-dir", DefaultArgocdConfigPath, "Path to the Argo CD config folder (contains values.yaml for Helm chart customization)")
+	rootCmd.Flags().String("target-argocd-chart-version", DefaultTargetArgocdChartVersion, "Render the target branch with this Argo CD Helm Chart version instead, to see the impact of an Argo CD upgrade. Requires --create-cluster")
+	rootCmd.Flags().String("target-argocd-config-dir", DefaultTargetArgocdConfigPath, "Render the target branch with the Argo CD config folder at this path instead, to see the impact of an Argo CD config change. Requires --create-cluster")
+	rootCmd.Flags().String("branch-argocd-config-dir", DefaultBranchArgocdConfigPath, "Path to an Argo CD config folder inside the repository. Each branch is rendered with Argo CD installed from its own copy of the folder. Takes precedence over --argocd-config-dir. Requires --create-cluster")
 	// Git related
 	rootCmd.Flags().StringP("base-branch", "b", DefaultBaseBranch, "Base branch name")
 	rootCmd.Flags().StringP("target-branch", "t", "", "Target branch name (required)")
@@ -316,6 +420,7 @@ func Parse() *Config {
 	// Cluster related
 	rootCmd.Flags().Bool("create-cluster", DefaultCreateCluster, "Create a new cluster if it doesn't exist")
 	rootCmd.Flags().String("render-method", DefaultRenderMethod, "Render mode for Argo CD manifests. Options: cli, server-api, repo-server-api. Takes precedence over --use-argocd-api")
+	rootCmd.Flags().String("cross-check-render-method", DefaultCrossCheckRenderMethod, "Also render the applications with this render method and report differences to --render-method. Options: server-api, repo-server-api")
 	rootCmd.Flags().String("cluster", DefaultCluster, "Local cluster tool. Options: kind, minikube, k3d, auto")
 	rootCmd.Flags().String("cluster-name", DefaultClusterName, "Cluster name (only for kind & k3d)")
 	rootCmd.Flags().String("kind-options", DefaultKindOptions, "kind options (only for kind)")
@@ -327,11 +432,19 @@ func Parse() *Config {
 
 	// Other options
 	rootCmd.Flags().String("max-diff-length", fmt.Sprintf("%d", DefaultMaxDiffLength), "Max diff message character count")
-	rootCmd.Flags().StringP("selector", "l", "", "Label selector to filter on (e.g. key1=value1,key2=value2)")
+	rootCmd.Flags().StringP("selector", "l", "", "Label selector to filter on. Supports the Kubernetes selector syntax (e.g. key1=value1,key2 notin (a,b),key3,!key4)")
+	rootCmd.Flags().String("destination-name", "", "Only select applications deploying to one of these cluster names (comma-separated)")
+	rootCmd.Flags().String("destination-server", "", "Only select applications deploying to one of these cluster URLs (comma-separated)")
+	rootCmd.Flags().String("destination-namespace", "", "Only select applications deploying to one of these namespaces (comma-separated)")
+	rootCmd.Flags().String("project", "", "Only select applications in one of these AppProjects (comma-separated)")
+	rootCmd.Flags().String("source-repo-url", "", "Only select applications with a source from one of these repositories. Accepts full URLs or OWNER/REPO (comma-separated)")
+	rootCmd.Flags().String("source-chart", "", "Only select applications with a source using one of these Helm charts (comma-separated)")
+	rootCmd.Flags().String("source-path", "", "Only select applications with a source using one of these paths (comma-separated)")
 	rootCmd.Flags().String("files-changed", "", "List of files changed between branches (comma, space or newline separated)")
 	rootCmd.Flags().Bool("auto-detect-files-changed", DefaultAutoDetectFilesChanged, "Auto detect files changed between branches")
 	rootCmd.Flags().Bool("ignore-invalid-watch-pattern", DefaultIgnoreInvalidWatchPattern, "Ignore invalid watch pattern Regex on Applications")
 	rootCmd.Flags().Bool("watch-if-no-watch-pattern-found", DefaultWatchIfNoWatchPatternFound, "Render applications without watch pattern")
+	rootCmd.Flags().Bool("infer-dependencies", DefaultInferDependencies, "Infer the files applications without watch pattern depend on (source paths, Helm value files, Kustomize resources and local chart dependencies)")
 	rootCmd.Flags().String("redirect-target-revisions", "", "Comma-separated source targetRevision values to redirect to the target branch. Example: main,HEAD. By default, every targetRevision in matching repositories is redirected")
 	rootCmd.Flags().String("title", DefaultTitle, "Custom title for the markdown output")
 	rootCmd.Flags().Bool("hide-deleted-app-diff", DefaultHideDeletedAppDiff, "Hide diff content for fully deleted applications (only show deletion header)")
@@ -340,6 +453,16 @@ func Parse() *Config {
 	rootCmd.Flags().Bool("output-branch-manifests", DefaultOutputBranchManifests, "Write all application manifests per branch to a single file (output/base-branch.yaml and output/target-branch.yaml)")
 	rootCmd.Flags().Bool("traverse-app-of-apps", DefaultTraverseAppOfApps, "Recursively render child Applications discovered in rendered manifests (app-of-apps pattern). Only supported with --render-method=repo-server-api")
 	rootCmd.Flags().Bool("fail-on-duplicate-generated-applications", DefaultFailOnDuplicateGeneratedApplications, "Fail when a single ApplicationSet generates multiple Applications with the same name")
+	rootCmd.Flags().Bool("fail-on-manifest-problems", DefaultFailOnManifestProblems, "Fail when an Application or ApplicationSet manifest in either branch cannot be parsed or is not valid. Unknown fields are only warnings")
+	rootCmd.Flags().Bool("show-app-spec-changes", DefaultShowAppSpecChanges, "Show the diff of the Application and ApplicationSet manifests themselves and highlight risky changes like enabling prune or switching destination")
+	rootCmd.Flags().Bool("show-appset-changes", DefaultShowAppSetChanges, "Show which Applications each ApplicationSet generates differently in the target branch (added, removed and modified)")
+	rootCmd.Flags().Bool("validate-app-projects", DefaultValidateAppProjects, "Validate the Applications in the target branch against the AppProjects found in the target branch and the secrets folder")
+	rootCmd.Flags().Bool("fail-on-shared-resources", DefaultFailOnSharedResources, "Fail when the target branch introduces resources rendered by more than one Application")
+	rootCmd.Flags().Bool("show-plan", DefaultShowPlan, "Show how many resources are added, changed, destroyed and replaced, per Application and kind")
+	rootCmd.Flags().Bool("exclude-ignore-extraneous", DefaultExcludeIgnoreExtraneous, "Skip resources with the 'argocd.argoproj.io/compare-options: IgnoreExtraneous' annotation in the diff")
+	rootCmd.Flags().Bool("show-sync-plan", DefaultShowSyncPlan, "Show the sync phases and waves of changed Applications with hooks or sync waves")
+	rootCmd.Flags().Bool("include-helm-hooks", DefaultIncludeHelmHooks, "Include Helm hooks in the diff instead of leaving them out")
+	rootCmd.Flags().Bool("check-determinism", DefaultCheckDeterminism, "Render the base branch twice and hide fields that differ between the renders, like random passwords. Only supported with --render-method=repo-server-api")
 
 	// Check if version flag was specified directly
 	for _, arg := range os.Args[1:] {
@@ -405,6 +528,7 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 		MaxDiffLength:                        o.MaxDiffLength,
 		IgnoreInvalidWatchPattern:            o.IgnoreInvalidWatchPattern,
 		WatchIfNoWatchPatternFound:           o.WatchIfNoWatchPatternFound,
+		InferDependencies:                    o.InferDependencies,
 		AutoDetectFilesChanged:               o.AutoDetectFilesChanged,
 		KeepClusterAlive:                     o.KeepClusterAlive,
 		ArgocdNamespace:                      o.ArgocdNamespace,
@@ -416,6 +540,9 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 		ArgocdLoginOptions:                   o.ArgocdLoginOptions,
 		ArgocdAuthToken:                      o.ArgocdAuthToken,
 		ArgocdConfigPath:                     o.ArgocdConfigPath,
+		TargetArgocdChartVersion:             o.TargetArgocdChartVersion,
+		TargetArgocdConfigPath:               o.TargetArgocdConfigPath,
+		BranchArgocdConfigPath:               o.BranchArgocdConfigPath,
 		LogFormat:                            o.LogFormat,
 		Title:                                o.Title,
 		HideDeletedAppDiff:                   o.HideDeletedAppDiff,
@@ -426,6 +553,16 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 		OutputBranchManifests:                o.OutputBranchManifests,
 		TraverseAppOfApps:                    o.TraverseAppOfApps,
 		FailOnDuplicateGeneratedApplications: o.FailOnDuplicateGeneratedApplications,
+		FailOnManifestProblems:               o.FailOnManifestProblems,
+		ShowAppSpecChanges:                   o.ShowAppSpecChanges,
+		ShowAppSetChanges:                    o.ShowAppSetChanges,
+		ValidateAppProjects:                  o.ValidateAppProjects,
+		FailOnSharedResources:                o.FailOnSharedResources,
+		ShowPlan:                             o.ShowPlan,
+		ExcludeIgnoreExtraneous:              o.ExcludeIgnoreExtraneous,
+		ShowSyncPlan:                         o.ShowSyncPlan,
+		IncludeHelmHooks:                     o.IncludeHelmHooks,
+		CheckDeterminism:                     o.CheckDeterminism,
 	}
 
 	var err error
@@ -445,11 +582,19 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid render-method: %w", err)
 	}
 
+	// Parse render method to cross-check the render method with
+	cfg.CrossCheckRenderMethod, err = o.parseCrossCheckRenderMethod(cfg.RenderMethod)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cross-check-render-method: %w", err)
+	}
+
 	// Parse file regex
 	cfg.FileRegex, err = o.parseFileRegex()
 	if err != nil {
 		return nil, fmt.Errorf("invalid file-regex: %w", err)
 	}
+	cfg.IncludeFiles = parseList(o.IncludeFiles)
+	cfg.BootstrapFolders = parseList(o.BootstrapFolders)
 
 	// Resolve the repository selector, auto-detecting from the checkout
 	// folders when neither --repo nor --repo-regex is provided.
@@ -467,12 +612,42 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 	// Parse files changed
 	cfg.FilesChanged = o.parseFilesChanged()
 
+	// Parse spec based selection
+	cfg.DestinationNames = parseList(o.DestinationName)
+	cfg.DestinationServers = parseList(o.DestinationServer)
+	cfg.DestinationNamespaces = parseList(o.DestinationNamespace)
+	cfg.Projects = parseList(o.Project)
+	cfg.SourceRepoURLs = parseList(o.SourceRepoURL)
+	cfg.SourceCharts = parseList(o.SourceChart)
+	cfg.SourcePaths = parseList(o.SourcePath)
+	cfg.FailOnDeletedKinds = parseList(o.FailOnDeletedKinds)
+
 	// Parse skip resource rules
 	cfg.IgnoreResourceRules, err = resource_filter.FromString(o.IgnoreResourceRules)
 	if err != nil {
 		return nil, fmt.Errorf("invalid ignore-resources: %w", err)
 	}
 
+	// Parse only resource rules. They are kept with the ignore rules, so every place that
+	// skips ignored resources also skips resources that are not included
+	onlyResourceRules, err := resource_filter.IncludeFromString(o.OnlyResourceRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid only-resources: %w", err)
+	}
+	cfg.IgnoreResourceRules = append(cfg.IgnoreResourceRules, onlyResourceRules...)
+
+	// Parse additional immutable fields
+	cfg.ImmutableFields, err = immutable_fields.FromString(o.ImmutableFields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid immutable-fields: %w", err)
+	}
+
+	// Parse global ignore differences
+	cfg.IgnoreDifferences, err = extract.ParseIgnoreDifferences(o.IgnoreDifferences)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ignore-differences: %w", err)
+	}
+
 	// Parse redirect revisions
 	cfg.RedirectRevisions = o.parseRedirectRevisions()
 
@@ -489,6 +664,15 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 		return nil, fmt.Errorf("--traverse-app-of-apps requires --render-method=repo-server-api (current: %s)", cfg.RenderMethod)
 	}
 
+	// --check-determinism renders the base branch again, which is only cheap with the repo-server-api render method
+	if cfg.CheckDeterminism && cfg.RenderMethod != RenderMethodRepoServerAPI {
+		return nil, fmt.Errorf("--check-determinism requires --render-method=repo-server-api (current: %s)", cfg.RenderMethod)
+	}
+
+	if err := cfg.validateArgoCDReinstall(); err != nil {
+		return nil, err
+	}
+
 	// Check if argocd CLI is installed when not using API mode
 	if cfg.RenderMethod == RenderMethodCLI && !cfg.DryRun {
 		if _, err := exec.LookPath("argocd"); err != nil {
@@ -499,19 +683,63 @@ func (o *RawOptions) ToConfig() (*Config, error) {
 	return cfg, nil
 }
 
-// parseSelectors parses the selector string into a slice of Selectors
-func (o *RawOptions) parseSelectors() ([]app_selector.Selector, error) {
-	var selectors []app_selector.Selector
-	if o.Selector != "" {
-		for s := range strings.SplitSeq(o.Selector, ",") {
-			selector, err := app_selector.FromString(strings.TrimSpace(s))
-			if err != nil {
-				return nil, err
-			}
-			selectors = append(selectors, *selector)
+// validateArgoCDReinstall validates the options that reinstall Argo CD between the branches
+func (o *Config) validateArgoCDReinstall() error {
+	// Rendering the target branch with another Argo CD reinstalls Argo CD between the branches
+	if o.RendersTargetWithOtherArgoCD() {
+		if !o.CreateCluster {
+			return fmt.Errorf("--target-argocd-chart-version and --target-argocd-config-dir require --create-cluster=true, since Argo CD is reinstalled")
+		}
+		if o.CheckDeterminism {
+			return fmt.Errorf("--check-determinism can not be combined with --target-argocd-chart-version or --target-argocd-config-dir")
+		}
+		if o.CrossCheckRenderMethod != "" {
+			return fmt.Errorf("--cross-check-render-method can not be combined with --target-argocd-chart-version or --target-argocd-config-dir")
 		}
 	}
-	return selectors, nil
+
+	// The Argo CD config of each branch is only known after the branches are checked out, so
+	// Argo CD may have to be reinstalled for the target branch
+	if o.BranchArgocdConfigPath != "" {
+		if !o.CreateCluster {
+			return fmt.Errorf("--branch-argocd-config-dir requires --create-cluster=true, since Argo CD is reinstalled when the config differs between the branches")
+		}
+		if o.TargetArgocdConfigPath != "" {
+			return fmt.Errorf("--branch-argocd-config-dir and --target-argocd-config-dir are mutually exclusive")
+		}
+		if o.CheckDeterminism {
+			return fmt.Errorf("--check-determinism can not be combined with --branch-argocd-config-dir")
+		}
+		if o.CrossCheckRenderMethod != "" {
+			return fmt.Errorf("--cross-check-render-method can not be combined with --branch-argocd-config-dir")
+		}
+	}
+	return nil
+}
+
+// RendersTargetWithOtherArgoCD returns true if the target branch is rendered with another
+// Argo CD chart version or config folder than the base branch
+func (o *Config) RendersTargetWithOtherArgoCD() bool {
+	return o.TargetArgocdChartVersion != "" || o.TargetArgocdConfigPath != ""
+}
+
+// targetArgoCDChartVersionAndConfig returns the Argo CD chart version and config folder the
+// target branch is rendered with. Each falls back to the one of the base branch.
+func (o *Config) targetArgoCDChartVersionAndConfig() (string, string) {
+	chartVersion := o.ArgocdChartVersion
+	if o.TargetArgocdChartVersion != "" {
+		chartVersion = o.TargetArgocdChartVersion
+	}
+	configPath := o.ArgocdConfigPath
+	if o.TargetArgocdConfigPath != "" {
+		configPath = o.TargetArgocdConfigPath
+	}
+	return chartVersion, configPath
+}
+
+// parseSelectors parses the selector string into a slice of Selectors
+func (o *RawOptions) parseSelectors() ([]app_selector.Selector, error) {
+	return app_selector.ParseSelectors(o.Selector)
 }
 
 // parseFilesChanged parses the files-changed string into a slice of strings
@@ -524,6 +752,17 @@ func (o *RawOptions) parseFilesChanged() []string {
 	})
 }
 
+// parseList parses a comma-separated string into a slice of trimmed, non-empty strings
+func parseList(s string) []string {
+	var values []string
+	for value := range strings.SplitSeq(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 // parseFileRegex returns a compiled regex if set
 func (o *RawOptions) parseFileRegex() (*regexp.Regexp, error) {
 	if o.FileRegex == "" {
@@ -576,6 +815,25 @@ func (o *RawOptions) parseRedirectRevisions() []string {
 	return strings.Split(o.RedirectTargetRevisions, ",")
 }
 
+// parseCrossCheckRenderMethod resolves the render method to compare --render-method with.
+// Both methods must use the Argo CD API, so they can share the same login.
+func (o *RawOptions) parseCrossCheckRenderMethod(renderMethod RenderMethod) (RenderMethod, error) {
+	if o.CrossCheckRenderMethod == "" {
+		return "", nil
+	}
+	crossCheckRenderMethod := RenderMethod(strings.ToLower(o.CrossCheckRenderMethod))
+	if crossCheckRenderMethod != RenderMethodServerAPI && crossCheckRenderMethod != RenderMethodRepoServerAPI {
+		return "", fmt.Errorf("unsupported cross-check-render-method %q: must be one of server-api, repo-server-api", o.CrossCheckRenderMethod)
+	}
+	if renderMethod != RenderMethodServerAPI && renderMethod != RenderMethodRepoServerAPI {
+		return "", fmt.Errorf("requires --render-method=server-api or --render-method=repo-server-api (current: %s)", renderMethod)
+	}
+	if crossCheckRenderMethod == renderMethod {
+		return "", fmt.Errorf("must differ from --render-method (%s)", renderMethod)
+	}
+	return crossCheckRenderMethod, nil
+}
+
 // parseRenderMethod resolves the effective RenderMethod.
 // --render-method takes precedence over --use-argocd-api.
 func (o *RawOptions) parseRenderMethod() (RenderMethod, error) {
@@ -697,6 +955,9 @@ func (o *Config) LogConfig() {
 		if o.RenderMethod != RenderMethod(DefaultRenderMethod) {
 			log.Info().Msgf("✨ - render-method: %s", o.RenderMethod)
 		}
+		if o.CrossCheckRenderMethod != "" {
+			log.Info().Msgf("✨ - cross-check-render-method: %s", o.CrossCheckRenderMethod)
+		}
 	}
 
 	log.Info().Msgf("✨ - base-branch: %s", o.BaseBranch)
@@ -707,6 +968,15 @@ func (o *Config) LogConfig() {
 	if o.ArgocdConfigPath != DefaultArgocdConfigPath {
 		log.Info().Msgf("✨ - argocd-config-dir: %s", o.ArgocdConfigPath)
 	}
+	if o.TargetArgocdChartVersion != "" {
+		log.Info().Msgf("✨ - target-argocd-chart-version: %s", o.TargetArgocdChartVersion)
+	}
+	if o.TargetArgocdConfigPath != "" {
+		log.Info().Msgf("✨ - target-argocd-config-dir: %s", o.TargetArgocdConfigPath)
+	}
+	if o.BranchArgocdConfigPath != "" {
+		log.Info().Msgf("✨ - branch-argocd-config-dir: %s", o.BranchArgocdConfigPath)
+	}
 	if o.RepoSelector.Repo != "" {
 		if o.RepoSelector.IsAutoDetected {
 			log.Info().Msgf("✨ - repo: %s (auto-detected)", o.RepoSelector.Repo)
@@ -730,6 +1000,12 @@ func (o *Config) LogConfig() {
 	if o.FileRegex != nil {
 		log.Info().Msgf("✨ - file-regex: %s", o.FileRegex.String())
 	}
+	if len(o.IncludeFiles) > 0 {
+		log.Info().Msgf("✨ - include-files: %s", strings.Join(o.IncludeFiles, ", "))
+	}
+	if len(o.BootstrapFolders) > 0 {
+		log.Info().Msgf("✨ - bootstrap-folders: %s", strings.Join(o.BootstrapFolders, ", "))
+	}
 	if o.DiffIgnore != "" {
 		log.Info().Msgf("✨ - diff-ignore: %s", o.DiffIgnore)
 	}
@@ -755,6 +1031,9 @@ func (o *Config) LogConfig() {
 		} else {
 			log.Info().Msgf("✨ --- Skip applications with no watch-pattern annotation")
 		}
+		if o.InferDependencies {
+			log.Info().Msgf("✨ --- Infer dependencies of applications with no watch-pattern annotation")
+		}
 	}
 	if len(o.Selectors) > 0 {
 		selectorStrings := make([]string, len(o.Selectors))
@@ -763,6 +1042,22 @@ func (o *Config) LogConfig() {
 		}
 		log.Info().Msgf("✨ - selectors: %s", strings.Join(selectorStrings, ", "))
 	}
+	for _, option := range []struct {
+		name   string
+		values []string
+	}{
+		{"destination-name", o.DestinationNames},
+		{"destination-server", o.DestinationServers},
+		{"destination-namespace", o.DestinationNamespaces},
+		{"project", o.Projects},
+		{"source-repo-url", o.SourceRepoURLs},
+		{"source-chart", o.SourceCharts},
+		{"source-path", o.SourcePaths},
+	} {
+		if len(option.values) > 0 {
+			log.Info().Msgf("✨ - %s: %s", option.name, strings.Join(option.values, ", "))
+		}
+	}
 	if len(o.RedirectRevisions) > 0 {
 		log.Info().Msgf("✨ - redirect-target-revisions: %s", o.RedirectRevisions)
 	}
@@ -797,11 +1092,33 @@ func (o *Config) LogConfig() {
 		log.Info().Msgf("✨ - hide-deleted-app-diff: %t", o.HideDeletedAppDiff)
 	}
 	if len(o.IgnoreResourceRules) > 0 {
-		ignoreResourceRuleStrings := make([]string, len(o.IgnoreResourceRules))
-		for i, ignoreResourceRule := range o.IgnoreResourceRules {
-			ignoreResourceRuleStrings[i] = ignoreResourceRule.String()
+		var ignoreResourceRuleStrings, onlyResourceRuleStrings []string
+		for _, ignoreResourceRule := range o.IgnoreResourceRules {
+			if ignoreResourceRule.Include {
+				onlyResourceRuleStrings = append(onlyResourceRuleStrings, ignoreResourceRule.String())
+			} else {
+				ignoreResourceRuleStrings = append(ignoreResourceRuleStrings, ignoreResourceRule.String())
+			}
+		}
+		if len(ignoreResourceRuleStrings) > 0 {
+			log.Info().Msgf("✨ - ignore-resources: %s", strings.Join(ignoreResourceRuleStrings, ", "))
+		}
+		if len(onlyResourceRuleStrings) > 0 {
+			log.Info().Msgf("✨ - only-resources: %s", strings.Join(onlyResourceRuleStrings, ", "))
 		}
-		log.Info().Msgf("✨ - ignore-resources: %s", strings.Join(ignoreResourceRuleStrings, ", "))
+	}
+	if len(o.ImmutableFields) > 0 {
+		immutableFieldStrings := make([]string, len(o.ImmutableFields))
+		for i, immutableField := range o.ImmutableFields {
+			immutableFieldStrings[i] = immutableField.String()
+		}
+		log.Info().Msgf("✨ - immutable-fields: %s", strings.Join(immutableFieldStrings, ", "))
+	}
+	if len(o.IgnoreDifferences) > 0 {
+		log.Info().Msgf("✨ - ignore-differences: %d rules", len(o.IgnoreDifferences))
+	}
+	if len(o.FailOnDeletedKinds) > 0 {
+		log.Info().Msgf("✨ - fail-on-deleted-kinds: %s", strings.Join(o.FailOnDeletedKinds, ", "))
 	}
 	if DefaultDisableClientThrottling != o.DisableClientThrottling {
 		log.Info().Msgf("✨ - disable-client-throttling: %t", o.DisableClientThrottling)
@@ -821,4 +1138,34 @@ func (o *Config) LogConfig() {
 	if o.FailOnDuplicateGeneratedApplications {
 		log.Info().Msgf("✨ - fail-on-duplicate-generated-applications: %t", o.FailOnDuplicateGeneratedApplications)
 	}
+	if o.FailOnManifestProblems {
+		log.Info().Msgf("✨ - fail-on-manifest-problems: %t", o.FailOnManifestProblems)
+	}
+	if o.ShowAppSpecChanges {
+		log.Info().Msgf("✨ - show-app-spec-changes: %t", o.ShowAppSpecChanges)
+	}
+	if o.ShowAppSetChanges {
+		log.Info().Msgf("✨ - show-appset-changes: %t", o.ShowAppSetChanges)
+	}
+	if o.ValidateAppProjects {
+		log.Info().Msgf("✨ - validate-app-projects: %t", o.ValidateAppProjects)
+	}
+	if o.FailOnSharedResources {
+		log.Info().Msgf("✨ - fail-on-shared-resources: %t", o.FailOnSharedResources)
+	}
+	if o.ShowPlan {
+		log.Info().Msgf("✨ - show-plan: %t", o.ShowPlan)
+	}
+	if o.ExcludeIgnoreExtraneous {
+		log.Info().Msgf("✨ - exclude-ignore-extraneous: %t", o.ExcludeIgnoreExtraneous)
+	}
+	if o.ShowSyncPlan {
+		log.Info().Msgf("✨ - show-sync-plan: %t", o.ShowSyncPlan)
+	}
+	if o.IncludeHelmHooks {
+		log.Info().Msgf("✨ - include-helm-hooks: %t", o.IncludeHelmHooks)
+	}
+	if o.CheckDeterminism {
+		log.Info().Msgf("✨ - check-determinism: %t", o.CheckDeterminism)
+	}
 }
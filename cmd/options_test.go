@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateArgoCDReinstall(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{
+			name: "no reinstall",
+			cfg:  Config{CheckDeterminism: true, CrossCheckRenderMethod: RenderMethodServerAPI},
+		},
+		{
+			name: "target chart version",
+			cfg:  Config{CreateCluster: true, TargetArgocdChartVersion: "8.0.0"},
+		},
+		{
+			name:    "target chart version without creating a cluster",
+			cfg:     Config{TargetArgocdChartVersion: "8.0.0"},
+			wantErr: "--target-argocd-chart-version and --target-argocd-config-dir require --create-cluster=true, since Argo CD is reinstalled",
+		},
+		{
+			name:    "target config with check determinism",
+			cfg:     Config{CreateCluster: true, TargetArgocdConfigPath: "./argocd-config-next", CheckDeterminism: true},
+			wantErr: "--check-determinism can not be combined with --target-argocd-chart-version or --target-argocd-config-dir",
+		},
+		{
+			name:    "target config with cross-check render method",
+			cfg:     Config{CreateCluster: true, TargetArgocdConfigPath: "./argocd-config-next", CrossCheckRenderMethod: RenderMethodServerAPI},
+			wantErr: "--cross-check-render-method can not be combined with --target-argocd-chart-version or --target-argocd-config-dir",
+		},
+		{
+			name: "branch config",
+			cfg:  Config{CreateCluster: true, BranchArgocdConfigPath: "argocd-config"},
+		},
+		{
+			name:    "branch config without creating a cluster",
+			cfg:     Config{BranchArgocdConfigPath: "argocd-config"},
+			wantErr: "--branch-argocd-config-dir requires --create-cluster=true, since Argo CD is reinstalled when the config differs between the branches",
+		},
+		{
+			name:    "branch config with target config",
+			cfg:     Config{CreateCluster: true, BranchArgocdConfigPath: "argocd-config", TargetArgocdConfigPath: "./argocd-config-next"},
+			wantErr: "--branch-argocd-config-dir and --target-argocd-config-dir are mutually exclusive",
+		},
+		{
+			name:    "branch config with check determinism",
+			cfg:     Config{CreateCluster: true, BranchArgocdConfigPath: "argocd-config", CheckDeterminism: true},
+			wantErr: "--check-determinism can not be combined with --branch-argocd-config-dir",
+		},
+		{
+			name:    "branch config with cross-check render method",
+			cfg:     Config{CreateCluster: true, BranchArgocdConfigPath: "argocd-config", CrossCheckRenderMethod: RenderMethodServerAPI},
+			wantErr: "--cross-check-render-method can not be combined with --branch-argocd-config-dir",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.validateArgoCDReinstall()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTargetArgoCDChartVersionAndConfig(t *testing.T) {
+	base := Config{ArgocdChartVersion: "7.8.0", ArgocdConfigPath: "./argocd-config"}
+
+	chartVersion, configPath := base.targetArgoCDChartVersionAndConfig()
+	assert.Equal(t, "7.8.0", chartVersion)
+	assert.Equal(t, "./argocd-config", configPath)
+
+	upgrade := base
+	upgrade.TargetArgocdChartVersion = "8.0.0"
+	chartVersion, configPath = upgrade.targetArgoCDChartVersionAndConfig()
+	assert.Equal(t, "8.0.0", chartVersion)
+	assert.Equal(t, "./argocd-config", configPath, "the config falls back to the one of the base branch")
+
+	configChange := base
+	configChange.TargetArgocdConfigPath = "./argocd-config-next"
+	chartVersion, configPath = configChange.targetArgoCDChartVersionAndConfig()
+	assert.Equal(t, "7.8.0", chartVersion, "the chart version falls back to the one of the base branch")
+	assert.Equal(t, "./argocd-config-next", configPath)
+}
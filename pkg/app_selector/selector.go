@@ -2,9 +2,12 @@ package app_selector
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
 )
 
 // Operator represents the comparison operator for selectors
@@ -15,6 +18,14 @@ const (
 	Eq Operator = iota
 	// Ne represents the inequality operator
 	Ne
+	// In represents the set-based 'in' operator
+	In
+	// NotIn represents the set-based 'notin' operator
+	NotIn
+	// Exists represents the existence operator ('key')
+	Exists
+	// DoesNotExist represents the non-existence operator ('!key')
+	DoesNotExist
 )
 
 // String returns the string representation of the Operator
@@ -24,21 +35,64 @@ func (o Operator) String() string {
 		return "="
 	case Ne:
 		return "!="
+	case In:
+		return "in"
+	case NotIn:
+		return "notin"
+	case Exists:
+		return "exists"
+	case DoesNotExist:
+		return "!"
 	default:
 		return "unknown"
 	}
 }
 
-// Selector represents a key-value selector with an operator
+// Selector represents a single label requirement with an operator
 type Selector struct {
 	Key      string
-	Value    string
+	Value    string   // used by Eq and Ne
+	Values   []string // used by In and NotIn
 	Operator Operator
 }
 
 // String returns the string representation of the Selector
 func (s *Selector) String() string {
-	return fmt.Sprintf("%s%s%s", s.Key, s.Operator, s.Value)
+	switch s.Operator {
+	case In, NotIn:
+		return fmt.Sprintf("%s %s (%s)", s.Key, s.Operator, strings.Join(s.Values, ","))
+	case Exists:
+		return s.Key
+	case DoesNotExist:
+		return fmt.Sprintf("!%s", s.Key)
+	default:
+		return fmt.Sprintf("%s%s%s", s.Key, s.Operator, s.Value)
+	}
+}
+
+// Matches checks if the selector matches the given labels.
+//
+// Set-based and existence operators follow Kubernetes semantics, so 'notin' and '!key'
+// match resources without the label. For backwards compatibility '=' and '!=' require
+// the label to exist.
+func (s *Selector) Matches(labels map[string]string) bool {
+	value, exists := labels[s.Key]
+	switch s.Operator {
+	case Eq:
+		return exists && value == s.Value
+	case Ne:
+		return exists && value != s.Value
+	case In:
+		return exists && slices.Contains(s.Values, value)
+	case NotIn:
+		return !exists || !slices.Contains(s.Values, value)
+	case Exists:
+		return exists
+	case DoesNotExist:
+		return !exists
+	default:
+		return false
+	}
 }
 
 // InvalidSelectorError represents an error in selector format
@@ -51,75 +105,88 @@ func (e *InvalidSelectorError) Error() string {
 	return fmt.Sprintf("invalid selector '%s': %s", e.Selector, e.Reason)
 }
 
-// FromString creates a new Selector from a string representation
-func FromString(s string) (*Selector, error) {
-	notEqual := strings.Split(s, "!=")
-	equalDouble := strings.Split(s, "==")
-	equalSingle := strings.Split(s, "=")
-
-	var selector *Selector
-
-	switch {
-	case len(notEqual) == 2:
-		selector = &Selector{
-			Key:      strings.TrimSpace(notEqual[0]),
-			Value:    strings.TrimSpace(notEqual[1]),
-			Operator: Ne,
-		}
-	case len(equalDouble) == 2:
-		selector = &Selector{
-			Key:      strings.TrimSpace(equalDouble[0]),
-			Value:    strings.TrimSpace(equalDouble[1]),
-			Operator: Eq,
+// ParseSelectors parses a full Kubernetes label selector expression into a slice of Selectors.
+// Example: "team notin (a,b),tier,!legacy,env=prod"
+func ParseSelectors(s string) ([]Selector, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	requirements, err := labels.ParseToRequirements(s)
+	if err != nil {
+		log.Error().Msgf("❌ Invalid label selector format: %s", s)
+		return nil, &InvalidSelectorError{
+			Selector: s,
+			Reason:   err.Error(),
 		}
-	case len(equalSingle) == 2:
-		// Only use single equals if it's not actually a double equals
-		if !strings.Contains(s, "==") {
-			selector = &Selector{
-				Key:      strings.TrimSpace(equalSingle[0]),
-				Value:    strings.TrimSpace(equalSingle[1]),
-				Operator: Eq,
-			}
-		} else {
+	}
+
+	selectors := make([]Selector, 0, len(requirements))
+	for _, r := range requirements {
+		selector, err := fromRequirement(r)
+		if err != nil {
 			log.Error().Msgf("❌ Invalid label selector format: %s", s)
 			return nil, &InvalidSelectorError{
 				Selector: s,
-				Reason:   "invalid format",
+				Reason:   err.Error(),
 			}
 		}
-	default:
-		log.Error().Msgf("❌ Invalid label selector format: %s", s)
-		return nil, &InvalidSelectorError{
-			Selector: s,
-			Reason:   "invalid format",
-		}
+		selectors = append(selectors, *selector)
 	}
 
-	// Validate selector
-	if selector.Key == "" || selector.Value == "" {
-		log.Error().Msgf("❌ Invalid label selector format: empty key or value: %s", s)
-		return nil, &InvalidSelectorError{
-			Selector: s,
-			Reason:   "empty key or value",
-		}
+	return selectors, nil
+}
+
+// FromString creates a new Selector from the string representation of a single requirement
+func FromString(s string) (*Selector, error) {
+	selectors, err := ParseSelectors(s)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check for invalid characters in key
-	if strings.Contains(selector.Key, "!") || strings.Contains(selector.Key, "=") {
-		log.Error().Msgf("❌ Invalid label selector format: key contains invalid characters: %s", s)
+	if len(selectors) != 1 {
+		log.Error().Msgf("❌ Invalid label selector format: %s", s)
 		return nil, &InvalidSelectorError{
 			Selector: s,
-			Reason:   "key contains invalid characters",
+			Reason:   "expected exactly one requirement",
 		}
 	}
 
-	// Check for invalid characters in value
-	if strings.Contains(selector.Value, "!") || strings.Contains(selector.Value, "=") {
-		log.Error().Msgf("❌ Invalid label selector format: value contains invalid characters: %s", s)
-		return nil, &InvalidSelectorError{
-			Selector: s,
-			Reason:   "value contains invalid characters",
+	return &selectors[0], nil
+}
+
+// fromRequirement converts a parsed Kubernetes label requirement into a Selector
+func fromRequirement(r labels.Requirement) (*Selector, error) {
+	selector := &Selector{Key: r.Key()}
+
+	switch r.Operator() {
+	case selection.Equals, selection.DoubleEquals:
+		selector.Operator = Eq
+	case selection.NotEquals:
+		selector.Operator = Ne
+	case selection.In:
+		selector.Operator = In
+	case selection.NotIn:
+		selector.Operator = NotIn
+	case selection.Exists:
+		selector.Operator = Exists
+	case selection.DoesNotExist:
+		selector.Operator = DoesNotExist
+	default:
+		return nil, fmt.Errorf("unsupported operator '%s'", r.Operator())
+	}
+
+	values := r.ValuesUnsorted()
+	slices.Sort(values)
+
+	switch selector.Operator {
+	case Eq, Ne:
+		if len(values) != 1 || values[0] == "" {
+			return nil, fmt.Errorf("empty key or value")
 		}
+		selector.Value = values[0]
+	case In, NotIn:
+		selector.Values = values
 	}
 
 	return selector, nil
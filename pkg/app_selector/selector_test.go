@@ -109,8 +109,26 @@ func TestFromString(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "Invalid format - no operator",
-			input:   "invalid",
+			name:  "Existence selector without operator",
+			input: "tier",
+			want: &Selector{
+				Key:      "tier",
+				Operator: Exists,
+			},
+			wantErr: false,
+		},
+		{
+			name:  "Non-existence selector",
+			input: "!tier",
+			want: &Selector{
+				Key:      "tier",
+				Operator: DoesNotExist,
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Invalid format - multiple requirements",
+			input:   "app=myapp,env=prod",
 			want:    nil,
 			wantErr: true,
 		},
@@ -168,3 +186,98 @@ func TestFromString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name:  "simple expressions",
+			input: "app=myapp, env!=prod",
+			want:  []string{"app=myapp", "env!=prod"},
+		},
+		{
+			name:  "set-based expressions",
+			input: "team notin (b,a),tier in (frontend)",
+			want:  []string{"team notin (a,b)", "tier in (frontend)"},
+		},
+		{
+			name:  "existence expressions",
+			input: "tier,!legacy",
+			want:  []string{"!legacy", "tier"},
+		},
+		{
+			name:    "unbalanced parentheses",
+			input:   "team in (a,b",
+			wantErr: true,
+		},
+		{
+			name:    "empty value",
+			input:   "key=",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSelectors(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSelectors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseSelectors() returned %d selectors, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("ParseSelectors()[%d] = %q, want %q", i, got[i].String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelector_Matches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "tier": "backend"}
+
+	tests := []struct {
+		selector string
+		labels   map[string]string
+		want     bool
+	}{
+		{selector: "team=payments", labels: labels, want: true},
+		{selector: "team!=payments", labels: labels, want: false},
+		{selector: "env!=prod", labels: labels, want: false},
+		{selector: "team in (payments,search)", labels: labels, want: true},
+		{selector: "team in (search)", labels: labels, want: false},
+		{selector: "team notin (search,ads)", labels: labels, want: true},
+		{selector: "team notin (payments)", labels: labels, want: false},
+		{selector: "team notin (payments)", labels: nil, want: true},
+		{selector: "tier", labels: labels, want: true},
+		{selector: "tier", labels: nil, want: false},
+		{selector: "!tier", labels: labels, want: false},
+		{selector: "!tier", labels: nil, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := FromString(tt.selector)
+			if err != nil {
+				t.Fatalf("FromString() error = %v", err)
+			}
+			if got := selector.Matches(tt.labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
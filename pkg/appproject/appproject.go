@@ -0,0 +1,291 @@
+package appproject
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+// DefaultProject is the project of Applications without spec.project
+const DefaultProject = "default"
+
+// inClusterServer is the server of the 'in-cluster' cluster Argo CD registers by default
+const inClusterServer = "https://kubernetes.default.svc"
+
+// Project is the part of an AppProject that Applications are validated against
+type Project struct {
+	Name                       string
+	SourceRepos                []string
+	SourceNamespaces           []string
+	Destinations               []Destination
+	ClusterResourceWhitelist   []GroupKind
+	ClusterResourceBlacklist   []GroupKind
+	NamespaceResourceWhitelist []GroupKind
+	NamespaceResourceBlacklist []GroupKind
+}
+
+// Destination is a cluster (server or name) and namespace. In an AppProject the fields are globs.
+type Destination struct {
+	Server    string
+	Name      string
+	Namespace string
+}
+
+// GroupKind is a resource group and kind. In an AppProject the fields are globs.
+type GroupKind struct {
+	Group string
+	Kind  string
+}
+
+// FromResources returns the AppProjects among the resources by name
+func FromResources(resources []fileparsing.Resource) map[string]Project {
+	projects := map[string]Project{}
+	for _, r := range resources {
+		if r.Yaml.GetKind() != "AppProject" || !strings.HasPrefix(r.Yaml.GetAPIVersion(), "argoproj.io/") {
+			continue
+		}
+		if r.Yaml.GetName() == "" {
+			continue
+		}
+		projects[r.Yaml.GetName()] = fromUnstructured(&r.Yaml)
+	}
+	return projects
+}
+
+// LoadFolder returns the AppProjects in the YAML files of a folder, e.g. the secrets folder.
+// A missing folder results in no AppProjects.
+func LoadFolder(folder string) (map[string]Project, error) {
+	if _, err := os.Stat(folder); errors.Is(err, os.ErrNotExist) {
+		return map[string]Project{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	files, _ := fileparsing.FileFilter{}.GetYamlFiles(folder)
+	resources, _ := fileparsing.ParseYaml(folder, files, git.Target)
+	return FromResources(resources), nil
+}
+
+func fromUnstructured(obj *unstructured.Unstructured) Project {
+	project := Project{Name: obj.GetName()}
+	project.SourceRepos, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "sourceRepos")
+	project.SourceNamespaces, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "sourceNamespaces")
+
+	destinations, _, _ := unstructured.NestedSlice(obj.Object, "spec", "destinations")
+	for _, d := range destinations {
+		if destination, ok := d.(map[string]any); ok {
+			project.Destinations = append(project.Destinations, Destination{
+				Server:    stringField(destination, "server"),
+				Name:      stringField(destination, "name"),
+				Namespace: stringField(destination, "namespace"),
+			})
+		}
+	}
+
+	project.ClusterResourceWhitelist = groupKinds(obj, "clusterResourceWhitelist")
+	project.ClusterResourceBlacklist = groupKinds(obj, "clusterResourceBlacklist")
+	project.NamespaceResourceWhitelist = groupKinds(obj, "namespaceResourceWhitelist")
+	project.NamespaceResourceBlacklist = groupKinds(obj, "namespaceResourceBlacklist")
+	return project
+}
+
+func groupKinds(obj *unstructured.Unstructured, field string) []GroupKind {
+	items, _, _ := unstructured.NestedSlice(obj.Object, "spec", field)
+	var result []GroupKind
+	for _, item := range items {
+		if gk, ok := item.(map[string]any); ok {
+			result = append(result, GroupKind{Group: stringField(gk, "group"), Kind: stringField(gk, "kind")})
+		}
+	}
+	return result
+}
+
+func stringField(m map[string]any, field string) string {
+	value, _ := m[field].(string)
+	return value
+}
+
+// globMatch matches a value against a glob. '?' matches a single character and '*' matches
+// any sequence of characters. With separators, '*' does not match '/' and '**' does.
+func globMatch(pattern, value string, separators bool) bool {
+	if pattern == "*" {
+		return true
+	}
+	expr := regexp.QuoteMeta(pattern)
+	if separators {
+		expr = strings.ReplaceAll(expr, `\*\*`, "\x00")
+		expr = strings.ReplaceAll(expr, `\*`, "[^/]*")
+		expr = strings.ReplaceAll(expr, "\x00", ".*")
+	} else {
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+	}
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	matched, err := regexp.MatchString("^"+expr+"$", value)
+	return err == nil && matched
+}
+
+// globMatchWithNegation matches like globMatch, but a pattern starting with '!' matches
+// the values the rest of the pattern does not match
+func globMatchWithNegation(pattern, value string, separators bool) bool {
+	if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+		return !globMatch(negated, value, separators)
+	}
+	return globMatch(pattern, value, separators)
+}
+
+func isDenyPattern(pattern string) bool {
+	return strings.HasPrefix(pattern, "!")
+}
+
+// permitted returns true if a pattern matches the value and no deny pattern ('!pattern')
+// excludes it. Like in Argo CD, a deny pattern permits every value it does not exclude.
+func permitted(patterns []string, value string, separators bool) bool {
+	allowed := false
+	for _, pattern := range patterns {
+		if globMatchWithNegation(pattern, value, separators) {
+			allowed = true
+		} else if isDenyPattern(pattern) {
+			return false
+		}
+	}
+	return allowed
+}
+
+func normalizeRepoURL(url string) string {
+	url = strings.ToLower(strings.TrimSpace(url))
+	url = strings.TrimSuffix(url, "/")
+	return strings.TrimSuffix(url, ".git")
+}
+
+// isSourcePermitted checks a repository URL against sourceRepos
+func (p Project) isSourcePermitted(repoURL string) bool {
+	patterns := make([]string, 0, len(p.SourceRepos))
+	for _, pattern := range p.SourceRepos {
+		if negated, ok := strings.CutPrefix(pattern, "!"); ok {
+			patterns = append(patterns, "!"+normalizeRepoURL(negated))
+		} else {
+			patterns = append(patterns, normalizeRepoURL(pattern))
+		}
+	}
+	return permitted(patterns, normalizeRepoURL(repoURL), true)
+}
+
+// isSourceNamespacePermitted checks the namespace of an Application against sourceNamespaces
+func (p Project) isSourceNamespacePermitted(namespace string) bool {
+	return permitted(p.SourceNamespaces, namespace, false)
+}
+
+// isDestinationPermitted checks a destination against the destinations of the project
+func (p Project) isDestinationPermitted(d Destination) bool {
+	// The 'in-cluster' cluster can be referenced by name or server
+	if d.Name == "" && d.Server == inClusterServer {
+		d.Name = "in-cluster"
+	} else if d.Server == "" && d.Name == "in-cluster" {
+		d.Server = inClusterServer
+	}
+
+	allowed := false
+	for _, item := range p.Destinations {
+		serverMatched, nameMatched := clusterMatches(item, d)
+		namespaceMatched := globMatchWithNegation(item.Namespace, d.Namespace, false)
+		switch {
+		case (serverMatched || nameMatched) && namespaceMatched:
+			allowed = true
+		case ((!nameMatched && isDenyPattern(item.Name)) || (!serverMatched && isDenyPattern(item.Server))) && namespaceMatched:
+			return false
+		case !namespaceMatched && isDenyPattern(item.Namespace) && (serverMatched || nameMatched):
+			return false
+		}
+	}
+	return allowed
+}
+
+// clusterMatches checks the cluster of a destination against a destination entry. Clusters
+// are not registered here, so a destination given by name can not be compared with an entry
+// given by server (and the other way around). Such entries are treated as matching.
+func clusterMatches(item Destination, d Destination) (serverMatched bool, nameMatched bool) {
+	comparableServer := d.Server != "" && item.Server != ""
+	comparableName := d.Name != "" && item.Name != ""
+	if !comparableServer && !comparableName {
+		return true, true
+	}
+	serverMatched = comparableServer && globMatchWithNegation(item.Server, d.Server, false)
+	nameMatched = comparableName && globMatchWithNegation(item.Name, d.Name, false)
+	return serverMatched, nameMatched
+}
+
+// isGroupKindPermitted checks a resource type against the resource allow and deny lists.
+// Like in Argo CD, an empty clusterResourceWhitelist denies all cluster-scoped resources and
+// an empty namespaceResourceWhitelist allows all namespaced resources.
+func (p Project) isGroupKindPermitted(gk GroupKind, namespaced bool) (bool, string) {
+	if namespaced {
+		if len(p.NamespaceResourceWhitelist) > 0 && !inList(gk, p.NamespaceResourceWhitelist) {
+			return false, "it is not in namespaceResourceWhitelist"
+		}
+		if inList(gk, p.NamespaceResourceBlacklist) {
+			return false, "it is in namespaceResourceBlacklist"
+		}
+		return true, ""
+	}
+
+	if !inList(gk, p.ClusterResourceWhitelist) {
+		return false, "it is not in clusterResourceWhitelist"
+	}
+	if inList(gk, p.ClusterResourceBlacklist) {
+		return false, "it is in clusterResourceBlacklist"
+	}
+	return true, ""
+}
+
+func inList(gk GroupKind, list []GroupKind) bool {
+	for _, item := range list {
+		if globMatch(item.Group, gk.Group, false) && globMatch(item.Kind, gk.Kind, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterScopedKinds are the built-in kinds that are not namespaced
+var clusterScopedKinds = map[string]bool{
+	"APIService":                       true,
+	"CertificateSigningRequest":        true,
+	"ComponentStatus":                  true,
+	"CSIDriver":                        true,
+	"CSINode":                          true,
+	"CustomResourceDefinition":         true,
+	"FlowSchema":                       true,
+	"IngressClass":                     true,
+	"MutatingWebhookConfiguration":     true,
+	"Namespace":                        true,
+	"Node":                             true,
+	"PersistentVolume":                 true,
+	"PodSecurityPolicy":                true,
+	"PriorityClass":                    true,
+	"PriorityLevelConfiguration":       true,
+	"RuntimeClass":                     true,
+	"StorageClass":                     true,
+	"ValidatingAdmissionPolicy":        true,
+	"ValidatingAdmissionPolicyBinding": true,
+	"ValidatingWebhookConfiguration":   true,
+	"VolumeAttachment":                 true,
+}
+
+// IsClusterScoped guesses if a resource is cluster-scoped. The cluster is not asked, so
+// besides the built-in kinds, kinds starting with 'Cluster' (e.g. ClusterRole or
+// ClusterIssuer) are treated as cluster-scoped. It is the fallback for kinds whose scope
+// is not known from the cluster or a rendered CustomResourceDefinition.
+func IsClusterScoped(resource *unstructured.Unstructured) bool {
+	kind := resource.GetKind()
+	return clusterScopedKinds[kind] || strings.HasPrefix(kind, "Cluster")
+}
+
+func groupKindOf(resource *unstructured.Unstructured) GroupKind {
+	gvk := resource.GroupVersionKind()
+	return GroupKind{Group: gvk.Group, Kind: gvk.Kind}
+}
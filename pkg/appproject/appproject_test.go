@@ -0,0 +1,237 @@
+package appproject
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func parse(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	obj := map[string]any{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &obj))
+	return &unstructured.Unstructured{Object: obj}
+}
+
+const projectYaml = `
+apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: team-a
+spec:
+  sourceRepos:
+    - https://github.com/org/*
+    - '!https://github.com/org/secret'
+  sourceNamespaces:
+    - team-a-*
+  destinations:
+    - server: https://kubernetes.default.svc
+      namespace: team-a-*
+    - name: prod
+      namespace: '!kube-system'
+  clusterResourceWhitelist:
+    - group: rbac.authorization.k8s.io
+      kind: ClusterRole
+  namespaceResourceBlacklist:
+    - group: ''
+      kind: ResourceQuota
+`
+
+func TestLoadFolder(t *testing.T) {
+	folder := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(folder, "project.yaml"), []byte(projectYaml), 0644))
+
+	projects, err := LoadFolder(folder)
+	require.NoError(t, err)
+	require.Contains(t, projects, "team-a")
+
+	project := projects["team-a"]
+	assert.Equal(t, []string{"https://github.com/org/*", "!https://github.com/org/secret"}, project.SourceRepos)
+	assert.Equal(t, []Destination{
+		{Server: "https://kubernetes.default.svc", Namespace: "team-a-*"},
+		{Name: "prod", Namespace: "!kube-system"},
+	}, project.Destinations)
+	assert.Equal(t, []GroupKind{{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}}, project.ClusterResourceWhitelist)
+	assert.Equal(t, []GroupKind{{Group: "", Kind: "ResourceQuota"}}, project.NamespaceResourceBlacklist)
+
+	projects, err = LoadFolder(filepath.Join(folder, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, projects)
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		value      string
+		separators bool
+		want       bool
+	}{
+		{"*", "anything/at/all", true, true},
+		{"team-*", "team-a", false, true},
+		{"team-?", "team-ab", false, false},
+		{"https://github.com/org/*", "https://github.com/org/repo", true, true},
+		{"https://github.com/org/*", "https://github.com/org/group/repo", true, false},
+		{"https://github.com/org/**", "https://github.com/org/group/repo", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, globMatch(tt.pattern, tt.value, tt.separators))
+		})
+	}
+}
+
+func TestIsSourcePermitted(t *testing.T) {
+	project := fromUnstructured(parse(t, projectYaml))
+
+	assert.True(t, project.isSourcePermitted("https://github.com/org/app.git"))
+	assert.True(t, project.isSourcePermitted("https://GitHub.com/org/app/"))
+	assert.False(t, project.isSourcePermitted("https://github.com/org/secret"))
+	// Like in Argo CD, a deny pattern permits everything it does not exclude
+	assert.True(t, project.isSourcePermitted("https://gitlab.com/org/app"))
+
+	project.SourceRepos = []string{"https://github.com/org/*"}
+	assert.False(t, project.isSourcePermitted("https://gitlab.com/org/app"))
+}
+
+func TestIsDestinationPermitted(t *testing.T) {
+	project := fromUnstructured(parse(t, projectYaml))
+
+	tests := []struct {
+		name        string
+		destination Destination
+		want        bool
+	}{
+		{"in-cluster by server", Destination{Server: "https://kubernetes.default.svc", Namespace: "team-a-web"}, true},
+		{"in-cluster by name", Destination{Name: "in-cluster", Namespace: "team-a-web"}, true},
+		{"namespace not allowed", Destination{Server: "https://kubernetes.default.svc", Namespace: "default"}, false},
+		{"named cluster", Destination{Name: "prod", Namespace: "default"}, true},
+		{"denied namespace", Destination{Name: "prod", Namespace: "kube-system"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, project.isDestinationPermitted(tt.destination))
+		})
+	}
+}
+
+func TestIsGroupKindPermitted(t *testing.T) {
+	project := fromUnstructured(parse(t, projectYaml))
+
+	ok, _ := project.isGroupKindPermitted(GroupKind{Group: "apps", Kind: "Deployment"}, true)
+	assert.True(t, ok)
+
+	ok, reason := project.isGroupKindPermitted(GroupKind{Kind: "ResourceQuota"}, true)
+	assert.False(t, ok)
+	assert.Equal(t, "it is in namespaceResourceBlacklist", reason)
+
+	ok, _ = project.isGroupKindPermitted(GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}, false)
+	assert.True(t, ok)
+
+	ok, reason = project.isGroupKindPermitted(GroupKind{Kind: "Namespace"}, false)
+	assert.False(t, ok)
+	assert.Equal(t, "it is not in clusterResourceWhitelist", reason)
+}
+
+func TestValidate(t *testing.T) {
+	projects := map[string]Project{"team-a": fromUnstructured(parse(t, projectYaml))}
+
+	application := parse(t, `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: web
+  namespace: argocd
+spec:
+  project: team-a
+  sources:
+    - repoURL: https://github.com/org/web
+    - repoURL: https://github.com/org/secret
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: team-a-web
+`)
+	resources := []unstructured.Unstructured{
+		*parse(t, `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}}`),
+		*parse(t, `{apiVersion: v1, kind: Namespace, metadata: {name: team-a-web}}`),
+		*parse(t, `{apiVersion: v1, kind: ConfigMap, metadata: {name: config, namespace: monitoring}}`),
+	}
+	app := NewApp(application, "web", "apps/web.yaml", resources)
+	assert.Equal(t, "team-a", app.Project)
+	assert.Equal(t, []string{"https://github.com/org/web", "https://github.com/org/secret"}, app.RepoURLs)
+
+	violations := Validate(projects, []App{app}, "argocd", nil)
+	var messages []string
+	for _, v := range violations {
+		assert.Equal(t, "web", v.App)
+		assert.Equal(t, "apps/web.yaml", v.FileName)
+		assert.Equal(t, "team-a", v.Project)
+		messages = append(messages, v.Message)
+	}
+	assert.Equal(t, []string{
+		"source repository 'https://github.com/org/secret' is not in sourceRepos",
+		"Namespace 'team-a-web' is not permitted because it is not in clusterResourceWhitelist",
+		"ConfigMap 'monitoring/config' is deployed to server 'https://kubernetes.default.svc' and namespace 'monitoring', which is not in destinations",
+	}, messages)
+}
+
+func TestValidate_SourceNamespaceAndUnknownProject(t *testing.T) {
+	projects := map[string]Project{"team-a": fromUnstructured(parse(t, projectYaml))}
+
+	outside := NewApp(parse(t, `
+metadata: {name: web, namespace: team-b}
+spec:
+  project: team-a
+  source: {repoURL: https://github.com/org/web}
+  destination: {name: prod, namespace: web}
+`), "web", "web.yaml", nil)
+	unknown := NewApp(parse(t, `
+metadata: {name: other}
+spec:
+  source: {repoURL: https://gitlab.com/org/other}
+  destination: {name: prod, namespace: kube-system}
+`), "other", "other.yaml", nil)
+	assert.Equal(t, DefaultProject, unknown.Project)
+
+	violations := Validate(projects, []App{outside, unknown}, "argocd", nil)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "Application namespace 'team-b' is not in sourceNamespaces", violations[0].Message)
+}
+
+func TestValidate_ResourceScopes(t *testing.T) {
+	// No clusterResourceWhitelist, so all cluster-scoped resources are denied
+	projects := map[string]Project{"team-a": fromUnstructured(parse(t, `
+metadata: {name: team-a}
+spec:
+  sourceRepos: ['*']
+  destinations: [{server: '*', namespace: '*'}]
+`))}
+	app := NewApp(parse(t, `
+metadata: {name: db}
+spec:
+  project: team-a
+  source: {repoURL: https://github.com/org/db}
+  destination: {server: https://kubernetes.default.svc, namespace: db}
+`), "db", "db.yaml", []unstructured.Unstructured{
+		*parse(t, `{apiVersion: postgresql.cnpg.io/v1, kind: Cluster, metadata: {name: db}}`),
+		*parse(t, `{apiVersion: gateway.networking.k8s.io/v1, kind: GatewayClass, metadata: {name: default}}`),
+	})
+
+	scopes := map[string]bool{"Cluster": false, "GatewayClass": true}
+	isClusterScoped := func(resource *unstructured.Unstructured) bool {
+		return scopes[resource.GetKind()]
+	}
+
+	violations := Validate(projects, []App{app}, "argocd", isClusterScoped)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "GatewayClass.gateway.networking.k8s.io 'default' is not permitted because it is not in clusterResourceWhitelist", violations[0].Message)
+
+	// Without known scopes the kinds are guessed from their names
+	violations = Validate(projects, []App{app}, "argocd", nil)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "Cluster.postgresql.cnpg.io 'db' is not permitted because it is not in clusterResourceWhitelist", violations[0].Message)
+}
@@ -0,0 +1,164 @@
+package appproject
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// App is an Application, as written in the repository, and the resources it renders
+type App struct {
+	Name        string
+	FileName    string
+	Namespace   string // namespace of the Application resource itself
+	Project     string
+	Destination Destination
+	RepoURLs    []string
+	Resources   []unstructured.Unstructured
+}
+
+// NewApp reads the project, destination and sources of an unpatched Application
+func NewApp(application *unstructured.Unstructured, name string, fileName string, resources []unstructured.Unstructured) App {
+	app := App{
+		Name:      name,
+		FileName:  fileName,
+		Namespace: application.GetNamespace(),
+		Resources: resources,
+	}
+	app.Project, _, _ = unstructured.NestedString(application.Object, "spec", "project")
+	if app.Project == "" {
+		app.Project = DefaultProject
+	}
+
+	destination, _, _ := unstructured.NestedMap(application.Object, "spec", "destination")
+	app.Destination = Destination{
+		Server:    stringField(destination, "server"),
+		Name:      stringField(destination, "name"),
+		Namespace: stringField(destination, "namespace"),
+	}
+
+	if source, found, _ := unstructured.NestedMap(application.Object, "spec", "source"); found {
+		app.RepoURLs = append(app.RepoURLs, stringField(source, "repoURL"))
+	}
+	sources, _, _ := unstructured.NestedSlice(application.Object, "spec", "sources")
+	for _, s := range sources {
+		if source, ok := s.(map[string]any); ok {
+			app.RepoURLs = append(app.RepoURLs, stringField(source, "repoURL"))
+		}
+	}
+	return app
+}
+
+// Violation is something an AppProject does not permit, which makes the sync of the
+// Application fail
+type Violation struct {
+	App      string
+	FileName string
+	Project  string
+	Message  string
+}
+
+// Validate checks the Applications against their AppProjects. Applications in projects
+// that are not found (or are templated) are skipped, since the project may only exist in
+// the cluster. controlPlaneNamespace is the namespace Argo CD is installed in, which
+// Applications can always be created in. isClusterScoped tells whether a resource is
+// cluster-scoped. If it is nil, IsClusterScoped guesses it from the kind.
+func Validate(projects map[string]Project, apps []App, controlPlaneNamespace string, isClusterScoped func(*unstructured.Unstructured) bool) []Violation {
+	if isClusterScoped == nil {
+		isClusterScoped = IsClusterScoped
+	}
+
+	var violations []Violation
+	for _, app := range apps {
+		project, found := projects[app.Project]
+		if !found {
+			log.Debug().Str("App", app.Name).Msgf("AppProject '%s' not found. Skipping validation", app.Project)
+			continue
+		}
+
+		for _, message := range project.validate(app, controlPlaneNamespace, isClusterScoped) {
+			violations = append(violations, Violation{
+				App:      app.Name,
+				FileName: app.FileName,
+				Project:  project.Name,
+				Message:  message,
+			})
+		}
+	}
+
+	for _, v := range violations {
+		log.Warn().Str("App", v.App).Msgf("⚠️ AppProject '%s' does not permit: %s", v.Project, v.Message)
+	}
+	return violations
+}
+
+func (p Project) validate(app App, controlPlaneNamespace string, isClusterScoped func(*unstructured.Unstructured) bool) []string {
+	var messages []string
+
+	if app.Namespace != "" && app.Namespace != controlPlaneNamespace && !p.isSourceNamespacePermitted(app.Namespace) {
+		messages = append(messages, fmt.Sprintf("Application namespace '%s' is not in sourceNamespaces", app.Namespace))
+	}
+
+	for _, repoURL := range app.RepoURLs {
+		if repoURL != "" && !p.isSourcePermitted(repoURL) {
+			messages = append(messages, fmt.Sprintf("source repository '%s' is not in sourceRepos", repoURL))
+		}
+	}
+
+	if !p.isDestinationPermitted(app.Destination) {
+		messages = append(messages, fmt.Sprintf("destination %s is not in destinations", describeDestination(app.Destination)))
+	}
+
+	// Resources deployed to other namespaces than the destination namespace are checked
+	// once per namespace
+	checkedNamespaces := map[string]bool{app.Destination.Namespace: true}
+
+	for i := range app.Resources {
+		resource := &app.Resources[i]
+		namespaced := !isClusterScoped(resource)
+
+		if ok, reason := p.isGroupKindPermitted(groupKindOf(resource), namespaced); !ok {
+			messages = append(messages, fmt.Sprintf("%s is not permitted because %s", describeResource(resource), reason))
+		}
+
+		if !namespaced || resource.GetNamespace() == "" || checkedNamespaces[resource.GetNamespace()] {
+			continue
+		}
+		checkedNamespaces[resource.GetNamespace()] = true
+		destination := app.Destination
+		destination.Namespace = resource.GetNamespace()
+		if !p.isDestinationPermitted(destination) {
+			messages = append(messages, fmt.Sprintf("%s is deployed to %s, which is not in destinations", describeResource(resource), describeDestination(destination)))
+		}
+	}
+
+	return messages
+}
+
+func describeDestination(d Destination) string {
+	var cluster string
+	switch {
+	case d.Server != "":
+		cluster = fmt.Sprintf("server '%s'", d.Server)
+	case d.Name != "":
+		cluster = fmt.Sprintf("cluster '%s'", d.Name)
+	default:
+		cluster = "an unknown cluster"
+	}
+	return fmt.Sprintf("%s and namespace '%s'", cluster, d.Namespace)
+}
+
+func describeResource(resource *unstructured.Unstructured) string {
+	name := resource.GetName()
+	if resource.GetNamespace() != "" {
+		name = resource.GetNamespace() + "/" + name
+	}
+	gk := groupKindOf(resource)
+	kind := gk.Kind
+	if gk.Group != "" {
+		kind = strings.Join([]string{gk.Kind, gk.Group}, ".")
+	}
+	return fmt.Sprintf("%s '%s'", kind, name)
+}
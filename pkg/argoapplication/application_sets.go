@@ -27,13 +27,13 @@ func ConvertAppSetsToAppsInBothBranches(
 	debug bool,
 	failOnDuplicateGeneratedApplications bool,
 	appSelectionOptions ApplicationSelectionOptions,
-) (*ArgoSelection, *ArgoSelection, time.Duration, error) {
+) (*ArgoSelection, *ArgoSelection, []AppSetChange, time.Duration, error) {
 	startTime := time.Now()
 
 	log.Info().Msg("🤖 Converting ApplicationSets to Applications for both branches")
 
 	baseTempFolder := fmt.Sprintf("%s/%s", tempFolder, git.Base)
-	baseApps, err := processAppSets(
+	baseApps, baseGenerated, err := processAppSets(
 		argocd,
 		baseApps,
 		baseBranch,
@@ -47,11 +47,11 @@ func ConvertAppSetsToAppsInBothBranches(
 
 	if err != nil {
 		log.Error().Str("branch", baseBranch.Name).Msg("❌ Failed to generate base apps")
-		return nil, nil, time.Since(startTime), err
+		return nil, nil, nil, time.Since(startTime), err
 	}
 
 	targetTempFolder := fmt.Sprintf("%s/%s", tempFolder, git.Target)
-	targetApps, err = processAppSets(
+	targetApps, targetGenerated, err := processAppSets(
 		argocd,
 		targetApps,
 		targetBranch,
@@ -64,12 +64,17 @@ func ConvertAppSetsToAppsInBothBranches(
 	)
 	if err != nil {
 		log.Error().Str("branch", targetBranch.Name).Msg("❌ Failed to generate target apps")
-		return nil, nil, time.Since(startTime), err
+		return nil, nil, nil, time.Since(startTime), err
+	}
+
+	appSetChanges := compareGeneratedApplications(baseGenerated, targetGenerated)
+	if len(appSetChanges) > 0 {
+		log.Info().Msgf("🤖 %d ApplicationSets generate different Applications in the two branches", len(appSetChanges))
 	}
 
 	log.Debug().Msgf("Converted ApplicationSets to Applications in %s", time.Since(startTime).Round(time.Second))
 
-	return baseApps, targetApps, time.Since(startTime), nil
+	return baseApps, targetApps, appSetChanges, time.Since(startTime), nil
 }
 
 func processAppSets(
@@ -82,7 +87,7 @@ func processAppSets(
 	appSelectionOptions ApplicationSelectionOptions,
 	repoSelector repository.Selector,
 	redirectRevisions []string,
-) (*ArgoSelection, error) {
+) (*ArgoSelection, []generatedApplications, error) {
 
 	appSetConversionResult, err := convertAppSetsToApps(
 		argocd,
@@ -94,8 +99,9 @@ func processAppSets(
 	)
 	if err != nil {
 		log.Error().Str("branch", branch.Name).Msg("❌ Failed to generate apps")
-		return nil, err
+		return nil, nil, err
 	}
+	generated := appSetConversionResult.generated
 
 	if appSetConversionResult.appSetsProcessedCount > 0 {
 		log.Info().Str("branch", branch.Name).Msgf(
@@ -112,7 +118,7 @@ func processAppSets(
 		return &ArgoSelection{
 			SelectedApps: appSetConversionResult.argoResource,
 			SkippedApps:  appSets.SkippedApps,
-		}, nil
+		}, generated, nil
 	}
 
 	// if there is no apps after conversion just return the apps that were skipped
@@ -120,7 +126,7 @@ func processAppSets(
 		return &ArgoSelection{
 			SelectedApps: appSetConversionResult.argoResource,
 			SkippedApps:  appSets.SkippedApps,
-		}, nil
+		}, generated, nil
 	}
 
 	selection := ApplicationSelection(appSetConversionResult.argoResource, appSelectionOptions)
@@ -156,7 +162,7 @@ func processAppSets(
 	)
 	if err != nil {
 		log.Error().Str("branch", branch.Name).Msg("❌ Failed to patch new Applications from ApplicationSets")
-		return nil, err
+		return nil, nil, err
 	}
 
 	log.Debug().Str("branch", branch.Name).Msgf("Patched all %d applications", len(patchedApps))
@@ -178,7 +184,7 @@ func processAppSets(
 	return &ArgoSelection{
 		SelectedApps: patchedApps,
 		SkippedApps:  append(appSets.SkippedApps, selection.SkippedApps...),
-	}, nil
+	}, generated, nil
 }
 
 type AppSetConversionResult struct {
@@ -186,6 +192,7 @@ type AppSetConversionResult struct {
 	generatedApplicationsCount int // real applications (not application sets)
 	appSetsProcessedCount      int
 	argoResource               []ArgoResource
+	generated                  []generatedApplications // Applications generated by each ApplicationSet
 }
 
 // appSetGenerateResult holds the output of a single ApplicationSet generation call.
@@ -280,8 +287,10 @@ func convertAppSetsToApps(
 
 	appsNew := make([]ArgoResource, 0, len(plainApps)+generatedApplicationsCount)
 	appsNew = append(appsNew, plainApps...)
-	for _, apps := range orderedResults {
+	generated := make([]generatedApplications, 0, len(onlyAppSets))
+	for i, apps := range orderedResults {
 		appsNew = append(appsNew, apps...)
+		generated = append(generated, generatedApplications{appSet: onlyAppSets[i], apps: apps})
 	}
 
 	return &AppSetConversionResult{
@@ -289,6 +298,7 @@ func convertAppSetsToApps(
 		originalApplicationsCount:  len(plainApps),
 		generatedApplicationsCount: generatedApplicationsCount,
 		argoResource:               appsNew,
+		generated:                  generated,
 	}, nil
 }
 
@@ -337,6 +347,9 @@ func generateAppsFromAppSet(
 
 		docCopy := doc.DeepCopy()
 		app := NewArgoResource(docCopy, Application, name, name, appSet.FileName, branch.Type())
+		app.copyDiffSettingsFrom(appSet)
+		app.SetOriginalFromAppSet(appSet)
+		app.GeneratedFrom = appSet.Yaml
 		apps = append(apps, *app)
 	}
 
@@ -7,6 +7,8 @@ import (
 	"github.com/rs/zerolog/log"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/dag-andersen/argocd-diff-preview/pkg/appproject"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/bootstrap"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/repository"
@@ -22,6 +24,16 @@ type ArgoResource struct {
 	Name     string // The name is the original name of the Application
 	FileName string
 	Branch   git.BranchType
+
+	// Original is the resource as written in the repository, before it was patched
+	// to render in the local cluster. It is nil until the resource is patched.
+	Original *unstructured.Unstructured
+
+	// GeneratedFrom is the ApplicationSet the Application was generated from, if any
+	GeneratedFrom *unstructured.Unstructured
+
+	// Selection explains why the resource was selected or skipped
+	Selection SelectionDecision
 }
 
 // NewArgoResource creates a new ArgoResource
@@ -40,6 +52,33 @@ func (a *ArgoResource) GetLongName() string {
 	return fmt.Sprintf("%s [%s|%s]", a.Name, a.Branch.ShortName(), a.FileName)
 }
 
+// Unpatched returns the resource as written in the repository, before it was patched
+// to render in the local cluster
+func (a *ArgoResource) Unpatched() *unstructured.Unstructured {
+	if a.Original != nil {
+		return a.Original
+	}
+	return a.Yaml
+}
+
+// DestinationCluster returns the cluster the Application deploys to, as written in the
+// repository: the destination server, or the destination name if no server is set
+func (a *ArgoResource) DestinationCluster() string {
+	app := a.Unpatched()
+	if app == nil {
+		return ""
+	}
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	if server != "" {
+		return server
+	}
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+	if name == "in-cluster" {
+		return "https://kubernetes.default.svc"
+	}
+	return name
+}
+
 // AsString returns the YAML representation of the resource
 func (a *ArgoResource) AsString() (string, error) {
 	bytes, err := yaml.Marshal(a.Yaml)
@@ -78,9 +117,26 @@ func GetApplicationsForBranches(
 	repoSelector repository.Selector,
 	redirectRevisions []string,
 ) (*ArgoSelection, *ArgoSelection, error) {
+	// The ignore file of the target branch is used for both branches, so changing it
+	// does not show up as Applications being added or removed
+	ignorePatterns, err := fileparsing.ReadIgnoreFile(targetBranch.FolderName())
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(ignorePatterns) > 0 {
+		log.Info().Msgf("🤖 Ignoring paths matching %d patterns in '%s'", len(ignorePatterns), fileparsing.IgnoreFileName)
+	}
+
+	fileFilter := fileparsing.FileFilter{
+		FileRegex:      appSelectionOptions.FileRegex,
+		IncludeGlobs:   appSelectionOptions.IncludeFiles,
+		IgnorePatterns: ignorePatterns,
+	}
+
 	baseApps, err := getApplications(
 		argocdNamespace,
 		baseBranch,
+		fileFilter,
 		appSelectionOptions,
 		repoSelector,
 		redirectRevisions,
@@ -92,6 +148,7 @@ func GetApplicationsForBranches(
 	targetApps, err := getApplications(
 		argocdNamespace,
 		targetBranch,
+		fileFilter,
 		appSelectionOptions,
 		repoSelector,
 		redirectRevisions,
@@ -107,26 +164,51 @@ func GetApplicationsForBranches(
 func getApplications(
 	argocdNamespace string,
 	branch *git.Branch,
+	fileFilter fileparsing.FileFilter,
 	appSelectionOptions ApplicationSelectionOptions,
 	repoSelector repository.Selector,
 	redirectRevisions []string,
 ) (*ArgoSelection, error) {
 	log.Info().Str("branch", branch.Name).Msg("🤖 Fetching all files for branch")
 
-	yamlFiles := fileparsing.GetYamlFiles(branch.FolderName(), appSelectionOptions.FileRegex)
+	yamlFiles, excludedFiles := fileFilter.GetYamlFiles(branch.FolderName())
 	log.Info().Str("branch", branch.Name).Msgf("🤖 Found %d files in dir '%s'", len(yamlFiles), branch.FolderName())
 
-	k8sResources := fileparsing.ParseYaml(branch.FolderName(), yamlFiles, branch.Type())
+	k8sResources, parseProblems := fileparsing.ParseYaml(branch.FolderName(), yamlFiles, branch.Type())
+
+	if len(appSelectionOptions.BootstrapFolders) > 0 {
+		bootstrapResources, bootstrapProblems, err := bootstrap.Render(branch.FolderName(), appSelectionOptions.BootstrapFolders, argocdNamespace, branch.Type())
+		if err != nil {
+			return nil, err
+		}
+		log.Info().Str("branch", branch.Name).Msgf("🤖 Rendered %d resources from %d bootstrap folders", len(bootstrapResources), len(appSelectionOptions.BootstrapFolders))
+		k8sResources = append(k8sResources, bootstrapResources...)
+		parseProblems = append(parseProblems, bootstrapProblems...)
+	}
 	log.Info().Str("branch", branch.Name).Msgf("🤖 Which resulted in %d Kubernetes resources", len(k8sResources))
 
+	problems := ValidateResources(k8sResources, parseProblems)
+	if len(problems) > 0 {
+		log.Warn().Str("branch", branch.Name).Msgf("⚠️ Found %d problems in Application[Set] manifests", len(problems))
+	}
+
 	allApps := FromResourceToApplication(k8sResources)
+	appProjects := appproject.FromResources(k8sResources)
 
 	log.Info().Str("branch", branch.Name).Msgf("🤖 Which resulted in %d Argo CD Applications or ApplicationSets", len(allApps))
 
+	// Files excluded by the ignore file, include globs or file regex are not parsed. They are
+	// only listed in the selection report
+	if len(excludedFiles) > 0 {
+		log.Info().Str("branch", branch.Name).Msgf("🤖 Excluded %d paths by '%s', include-files or file-regex", len(excludedFiles), fileparsing.IgnoreFileName)
+	}
+
 	if len(allApps) == 0 {
 		return &ArgoSelection{
-			SelectedApps: allApps,
-			SkippedApps:  []ArgoResource{},
+			SelectedApps:  allApps,
+			ExcludedFiles: excludedFiles,
+			Problems:      problems,
+			AppProjects:   appProjects,
 		}, nil
 	}
 
@@ -147,6 +229,10 @@ func getApplications(
 		)
 	}
 
+	selection.ExcludedFiles = excludedFiles
+	selection.Problems = problems
+	selection.AppProjects = appProjects
+
 	if len(selection.SelectedApps) == 0 {
 		return selection, nil
 	}
@@ -167,7 +253,10 @@ func getApplications(
 	log.Debug().Str("branch", branch.Name).Msgf("Patched %d Application[Sets]", len(patchedApps))
 
 	return &ArgoSelection{
-		SelectedApps: patchedApps,
-		SkippedApps:  selection.SkippedApps,
+		SelectedApps:  patchedApps,
+		SkippedApps:   selection.SkippedApps,
+		ExcludedFiles: selection.ExcludedFiles,
+		Problems:      selection.Problems,
+		AppProjects:   selection.AppProjects,
 	}, nil
 }
@@ -0,0 +1,62 @@
+package argoapplication
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetApplications_KeepsAppProjectsOfPatchedApplications(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	branch := git.NewBranch("feature", git.Target)
+	repoDir := writeRepo(t, map[string]string{
+		filepath.Join(branch.FolderName(), "apps/guestbook.yaml"): `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+  namespace: argocd
+spec:
+  project: team
+  source:
+    repoURL: https://github.com/org/repo.git
+    path: apps/guestbook
+    targetRevision: main
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: guestbook
+`,
+		filepath.Join(branch.FolderName(), "projects/team.yaml"): `
+apiVersion: argoproj.io/v1alpha1
+kind: AppProject
+metadata:
+  name: team
+  namespace: argocd
+spec:
+  destinations:
+    - server: https://kubernetes.default.svc
+      namespace: guestbook
+`,
+	})
+	t.Chdir(repoDir)
+
+	selection, err := getApplications(
+		"argocd",
+		branch,
+		fileparsing.FileFilter{},
+		ApplicationSelectionOptions{},
+		*mustNewTestSelector(t, "org/repo", ""),
+		nil,
+	)
+	require.NoError(t, err)
+
+	// The Application is patched, so the selection is rebuilt from the patched Applications
+	require.Len(t, selection.SelectedApps, 1)
+	assert.Contains(t, selection.AppProjects, "team")
+}
@@ -0,0 +1,100 @@
+package argoapplication
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// generatedApplications are the Applications an ApplicationSet generated in a branch
+type generatedApplications struct {
+	appSet ArgoResource
+	apps   []ArgoResource
+}
+
+// AppSetChange describes how the Applications generated by an ApplicationSet differ
+// between the base and target branch
+type AppSetChange struct {
+	Name     string // name of the ApplicationSet
+	FileName string
+	Added    []string // names of the Applications only generated in the target branch
+	Removed  []string // names of the Applications only generated in the base branch
+	Modified []GeneratedApplicationChange
+}
+
+// GeneratedApplicationChange is an Application generated in both branches with a different spec
+type GeneratedApplicationChange struct {
+	Name   string
+	Base   *unstructured.Unstructured
+	Target *unstructured.Unstructured
+}
+
+// compareGeneratedApplications pairs the ApplicationSets of both branches by name and
+// returns the ones generating different Applications. The generated Applications are
+// compared by their Original, which has the project, syncPolicy and targetRevisions of the
+// unpatched template.
+func compareGeneratedApplications(base, target []generatedApplications) []AppSetChange {
+	type appSetApps struct {
+		fileName string
+		base     map[string]*unstructured.Unstructured
+		target   map[string]*unstructured.Unstructured
+	}
+	appSets := map[string]*appSetApps{}
+	collect := func(generated []generatedApplications, isBase bool) {
+		for _, g := range generated {
+			entry, found := appSets[g.appSet.Name]
+			if !found {
+				entry = &appSetApps{
+					base:   map[string]*unstructured.Unstructured{},
+					target: map[string]*unstructured.Unstructured{},
+				}
+				appSets[g.appSet.Name] = entry
+			}
+			apps := entry.target
+			if isBase {
+				apps = entry.base
+			}
+			// Prefer the file of the ApplicationSet in the target branch
+			if !isBase || entry.fileName == "" {
+				entry.fileName = g.appSet.FileName
+			}
+			for _, app := range g.apps {
+				apps[app.Name] = app.Unpatched()
+			}
+		}
+	}
+	collect(base, true)
+	collect(target, false)
+
+	var changes []AppSetChange
+	for name, apps := range appSets {
+		change := AppSetChange{Name: name, FileName: apps.fileName}
+		for appName, targetApp := range apps.target {
+			baseApp, found := apps.base[appName]
+			switch {
+			case !found:
+				change.Added = append(change.Added, appName)
+			case !reflect.DeepEqual(baseApp.Object, targetApp.Object):
+				change.Modified = append(change.Modified, GeneratedApplicationChange{Name: appName, Base: baseApp, Target: targetApp})
+			}
+		}
+		for appName := range apps.base {
+			if _, found := apps.target[appName]; !found {
+				change.Removed = append(change.Removed, appName)
+			}
+		}
+
+		if len(change.Added) == 0 && len(change.Removed) == 0 && len(change.Modified) == 0 {
+			continue
+		}
+		slices.Sort(change.Added)
+		slices.Sort(change.Removed)
+		slices.SortFunc(change.Modified, func(a, b GeneratedApplicationChange) int { return cmp.Compare(a.Name, b.Name) })
+		changes = append(changes, change)
+	}
+
+	slices.SortFunc(changes, func(a, b AppSetChange) int { return cmp.Compare(a.Name, b.Name) })
+	return changes
+}
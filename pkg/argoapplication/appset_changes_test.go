@@ -0,0 +1,189 @@
+package argoapplication
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func generatedTestApp(name, path string, branch git.BranchType) ArgoResource {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]any{"name": name},
+		"spec":       map[string]any{"source": map[string]any{"path": path}},
+	}}
+	return *NewArgoResource(obj, Application, name, name, "appsets/cluster-addons.yaml", branch)
+}
+
+func TestCompareGeneratedApplications(t *testing.T) {
+	appSet := func(name string, branch git.BranchType) ArgoResource {
+		return *NewArgoResource(&unstructured.Unstructured{}, ApplicationSet, name, name, "appsets/"+name+".yaml", branch)
+	}
+
+	base := []generatedApplications{
+		{
+			appSet: appSet("cluster-addons", git.Base),
+			apps: []ArgoResource{
+				generatedTestApp("cert-manager", "addons/cert-manager", git.Base),
+				generatedTestApp("ingress", "addons/ingress", git.Base),
+				generatedTestApp("old-addon", "addons/old-addon", git.Base),
+			},
+		},
+		{
+			appSet: appSet("unchanged", git.Base),
+			apps:   []ArgoResource{generatedTestApp("guestbook", "apps/guestbook", git.Base)},
+		},
+	}
+	target := []generatedApplications{
+		{
+			appSet: appSet("cluster-addons", git.Target),
+			apps: []ArgoResource{
+				generatedTestApp("cert-manager", "addons/cert-manager", git.Target),
+				generatedTestApp("ingress", "addons/ingress-v2", git.Target),
+				generatedTestApp("new-addon", "addons/new-addon", git.Target),
+				generatedTestApp("another-addon", "addons/another-addon", git.Target),
+			},
+		},
+		{
+			appSet: appSet("unchanged", git.Target),
+			apps:   []ArgoResource{generatedTestApp("guestbook", "apps/guestbook", git.Target)},
+		},
+		{
+			appSet: appSet("new-appset", git.Target),
+			apps:   []ArgoResource{generatedTestApp("podinfo", "apps/podinfo", git.Target)},
+		},
+	}
+
+	changes := compareGeneratedApplications(base, target)
+	require.Len(t, changes, 2)
+
+	assert.Equal(t, "cluster-addons", changes[0].Name)
+	assert.Equal(t, "appsets/cluster-addons.yaml", changes[0].FileName)
+	assert.Equal(t, []string{"another-addon", "new-addon"}, changes[0].Added)
+	assert.Equal(t, []string{"old-addon"}, changes[0].Removed)
+	require.Len(t, changes[0].Modified, 1)
+	assert.Equal(t, "ingress", changes[0].Modified[0].Name)
+	assert.Equal(t, "addons/ingress", changes[0].Modified[0].Base.Object["spec"].(map[string]any)["source"].(map[string]any)["path"])
+
+	assert.Equal(t, "new-appset", changes[1].Name)
+	assert.Equal(t, []string{"podinfo"}, changes[1].Added)
+	assert.Empty(t, changes[1].Removed)
+	assert.Empty(t, changes[1].Modified)
+}
+
+const changesTestAppSet = `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cluster-addons
+  namespace: argocd
+spec:
+  generators:
+    - list:
+        elements:
+          - addon: cert-manager
+  template:
+    metadata:
+      name: '{{.addon}}'
+    spec:
+      project: addons
+      source:
+        repoURL: https://github.com/org/repo.git
+        path: 'addons/{{.addon}}'
+        targetRevision: %s
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: '{{.addon}}'
+      syncPolicy:
+        automated:
+          prune: %s
+`
+
+// patchedTestAppSet patches the ApplicationSet for the branch and generates its Application
+// from the patched template, the way Argo CD would. The generator renders a templated
+// targetRevision to 'main'.
+func patchedTestAppSet(t *testing.T, targetRevision, prune string, branch *git.Branch) generatedApplications {
+	t.Helper()
+
+	var node unstructured.Unstructured
+	require.NoError(t, yaml.Unmarshal(fmt.Appendf(nil, changesTestAppSet, targetRevision, prune), &node))
+	appSet := NewArgoResource(&node, ApplicationSet, "cluster-addons", "cluster-addons", "appsets/cluster-addons.yaml", branch.Type())
+	patched, err := PatchApplication("argocd", *appSet, branch, *mustNewTestSelector(t, "org/repo", ""), nil)
+	require.NoError(t, err)
+
+	spec, _, err := unstructured.NestedMap(patched.Yaml.Object, "spec", "template", "spec")
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(spec, "addons/cert-manager", "source", "path"))
+	require.NoError(t, unstructured.SetNestedField(spec, "cert-manager", "destination", "namespace"))
+	if revision, _, _ := unstructured.NestedString(spec, "source", "targetRevision"); isTemplated(revision) {
+		require.NoError(t, unstructured.SetNestedField(spec, "main", "source", "targetRevision"))
+	}
+	generated := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]any{"name": "cert-manager"},
+		"spec":       spec,
+	}}
+	app := NewArgoResource(generated, Application, "cert-manager", "cert-manager", patched.FileName, branch.Type())
+	app.SetOriginalFromAppSet(*patched)
+
+	return generatedApplications{appSet: *patched, apps: []ArgoResource{*app}}
+}
+
+func TestCompareGeneratedApplications_PatchedApplicationSets(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	base := git.NewBranch("main", git.Base)
+	target := git.NewBranch("feature", git.Target)
+
+	t.Run("redirected sources and removed syncPolicy are not a change", func(t *testing.T) {
+		changes := compareGeneratedApplications(
+			[]generatedApplications{patchedTestAppSet(t, "main", "true", base)},
+			[]generatedApplications{patchedTestAppSet(t, "main", "true", target)},
+		)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("changed syncPolicy is a change", func(t *testing.T) {
+		changes := compareGeneratedApplications(
+			[]generatedApplications{patchedTestAppSet(t, "main", "true", base)},
+			[]generatedApplications{patchedTestAppSet(t, "main", "false", target)},
+		)
+		require.Len(t, changes, 1)
+		require.Len(t, changes[0].Modified, 1)
+
+		modified := changes[0].Modified[0]
+		prune, _, _ := unstructured.NestedBool(modified.Base.Object, "spec", "syncPolicy", "automated", "prune")
+		assert.True(t, prune)
+		targetRevision, _, _ := unstructured.NestedString(modified.Target.Object, "spec", "source", "targetRevision")
+		assert.Equal(t, "main", targetRevision)
+		project, _, _ := unstructured.NestedString(modified.Target.Object, "spec", "project")
+		assert.Equal(t, "addons", project)
+	})
+	t.Run("redirected templated targetRevision is not a change", func(t *testing.T) {
+		changes := compareGeneratedApplications(
+			[]generatedApplications{patchedTestAppSet(t, "'{{.revision}}'", "true", base)},
+			[]generatedApplications{patchedTestAppSet(t, "'{{.revision}}'", "true", target)},
+		)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("changed templated targetRevision is a change", func(t *testing.T) {
+		changes := compareGeneratedApplications(
+			[]generatedApplications{patchedTestAppSet(t, "'{{.revision}}'", "true", base)},
+			[]generatedApplications{patchedTestAppSet(t, "'{{.ref}}'", "true", target)},
+		)
+		require.Len(t, changes, 1)
+		require.Len(t, changes[0].Modified, 1)
+
+		targetRevision, _, _ := unstructured.NestedString(changes[0].Modified[0].Target.Object, "spec", "source", "targetRevision")
+		assert.Equal(t, "{{.ref}}", targetRevision)
+	})
+}
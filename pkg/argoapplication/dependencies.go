@@ -0,0 +1,404 @@
+package argoapplication
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/repository"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Dependencies are the repository paths an Application reads when it is rendered.
+// Paths are relative to the repository root. Folders end with '/' and cover everything
+// below them, and an empty path covers the whole repository. Paths from git generators
+// may contain glob patterns.
+
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomization holds the fields of a kustomization.yaml that reference other files
+type kustomization struct {
+	Resources             []string `json:"resources"`
+	Components            []string `json:"components"`
+	Bases                 []string `json:"bases"`
+	PatchesStrategicMerge []string `json:"patchesStrategicMerge"`
+	Patches               []struct {
+		Path string `json:"path"`
+	} `json:"patches"`
+	PatchesJSON6902 []struct {
+		Path string `json:"path"`
+	} `json:"patchesJson6902"`
+}
+
+// chart holds the fields of a Chart.yaml that reference other files
+type chart struct {
+	Dependencies []struct {
+		Repository string `json:"repository"`
+	} `json:"dependencies"`
+}
+
+// dependencyCollector walks the repository checkout and collects the dependencies of an Application
+type dependencyCollector struct {
+	repoDir      string
+	dependencies []string
+	visited      map[string]bool
+}
+
+// InferDependencies statically infers the files in the repository the Application reads.
+// It follows the source paths, Helm valueFiles (including '$ref' paths), Kustomize
+// resources, components, patches and bases, and local Helm chart dependencies.
+// Sources from other repositories than the one selected by repoSelector are ignored.
+//
+// templated is true if a source of an ApplicationSet is templated, in which case the
+// dependencies can only be inferred for the generated Applications.
+func (a *ArgoResource) InferDependencies(repoDir string, repoSelector repository.Selector) (dependencies []string, templated bool) {
+	if a.Yaml == nil {
+		return nil, false
+	}
+
+	resource := a.Yaml
+	if a.Original != nil {
+		resource = a.Original
+	}
+
+	specPath := []string{"spec"}
+	if a.Kind == ApplicationSet {
+		specPath = []string{"spec", "template", "spec"}
+	}
+	spec, _, err := unstructured.NestedMap(resource.Object, specPath...)
+	if err != nil || spec == nil {
+		return nil, false
+	}
+
+	c := &dependencyCollector{repoDir: repoDir, visited: map[string]bool{}}
+
+	sources := specSources(spec)
+	refs := map[string]map[string]any{}
+	for _, source := range sources {
+		if ref, _, _ := unstructured.NestedString(source, "ref"); ref != "" {
+			refs[ref] = source
+		}
+	}
+
+	for _, source := range sources {
+		repoURL, _, _ := unstructured.NestedString(source, "repoURL")
+		sourcePath, hasPath, _ := unstructured.NestedString(source, "path")
+		if isTemplated(repoURL) || isTemplated(sourcePath) {
+			templated = true
+			continue
+		}
+
+		isLocal := repoSelector.Matches(repoURL)
+		if isLocal && hasPath {
+			c.addFolder(sourcePath)
+		}
+
+		valueFiles, _, _ := unstructured.NestedStringSlice(source, "helm", "valueFiles")
+		fileParameters, _, _ := unstructured.NestedSlice(source, "helm", "fileParameters")
+		for _, parameter := range fileParameters {
+			if parameterMap, ok := parameter.(map[string]any); ok {
+				if parameterPath, found, _ := unstructured.NestedString(parameterMap, "path"); found {
+					valueFiles = append(valueFiles, parameterPath)
+				}
+			}
+		}
+
+		for _, valueFile := range valueFiles {
+			if isTemplated(valueFile) {
+				templated = true
+				continue
+			}
+			c.addValueFile(valueFile, sourcePath, isLocal, refs, repoSelector)
+		}
+	}
+
+	dependencies = c.dependencies
+	if a.Kind == ApplicationSet {
+		dependencies = append(dependencies, gitGeneratorPaths(resource, repoSelector)...)
+	} else if a.GeneratedFrom != nil {
+		dependencies = append(dependencies, gitGeneratorPaths(a.GeneratedFrom, repoSelector)...)
+	}
+
+	slices.Sort(dependencies)
+	return removeCoveredDependencies(slices.Compact(dependencies)), templated
+}
+
+// removeCoveredDependencies removes dependencies that are already covered by a folder
+// dependency. The dependencies must be sorted.
+func removeCoveredDependencies(dependencies []string) []string {
+	var result []string
+	for _, dependency := range dependencies {
+		covered := slices.ContainsFunc(result, func(folder string) bool {
+			return (folder == "" || strings.HasSuffix(folder, "/")) && strings.HasPrefix(dependency, folder)
+		})
+		if !covered {
+			result = append(result, dependency)
+		}
+	}
+	return result
+}
+
+// filterByInferredDependencies checks if any of the changed files is one of the inferred dependencies of the application
+func (a *ArgoResource) filterByInferredDependencies(filesChanged []string, repoDir string, repoSelector repository.Selector, watchIfNoWatchPatternFound bool) (bool, SelectionRule, string) {
+	dependencies, templated := a.InferDependencies(repoDir, repoSelector)
+
+	for _, dependency := range dependencies {
+		if file, found := findMatchingDependency(dependency, filesChanged); found {
+			return true, RuleInferredDependencies, fmt.Sprintf("file changed '%s' matches inferred dependency '%s'", file, dependency)
+		}
+	}
+
+	if templated {
+		return true, RuleInferredDependencies, "sources are templated, so dependencies are inferred for the generated Applications"
+	}
+
+	if len(dependencies) == 0 {
+		return watchIfNoWatchPatternFound, RuleNoWatchPattern, "no watch-pattern or manifest-generate-paths annotation found and no dependencies could be inferred"
+	}
+
+	log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("Inferred dependencies: %v", dependencies)
+
+	return false, RuleInferredDependencies, fmt.Sprintf("no files changed match the %d inferred dependencies", len(dependencies))
+}
+
+// findMatchingDependency returns the first changed file covered by the dependency
+func findMatchingDependency(dependency string, filesChanged []string) (string, bool) {
+	for _, file := range filesChanged {
+		file = strings.TrimPrefix(path.Clean("/"+file), "/")
+		if matchesDependency(dependency, file) {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// matchesDependency returns true if the file is covered by the dependency
+func matchesDependency(dependency, file string) bool {
+	if index := strings.IndexAny(dependency, "*?["); index >= 0 {
+		// Glob patterns from git generators may use '**', which path.Match does not
+		// support, so everything below the static part of the pattern is matched.
+		dependency = dependency[:strings.LastIndex(dependency[:index], "/")+1]
+	}
+	if dependency == "" {
+		return true
+	}
+	if strings.HasSuffix(dependency, "/") {
+		return strings.HasPrefix(file, dependency)
+	}
+	return file == dependency
+}
+
+// addValueFile adds a Helm value file. '$ref/...' paths are resolved against the ref
+// source, paths with a leading '/' against the repository root and other paths
+// against the source path.
+func (c *dependencyCollector) addValueFile(valueFile, sourcePath string, isLocal bool, refs map[string]map[string]any, repoSelector repository.Selector) {
+	if u, err := url.Parse(valueFile); err == nil && u.Scheme != "" {
+		return
+	}
+
+	if strings.HasPrefix(valueFile, "$") {
+		refName, refPath, _ := strings.Cut(strings.TrimPrefix(valueFile, "$"), "/")
+		refSource, found := refs[refName]
+		if !found {
+			return
+		}
+		refRepoURL, _, _ := unstructured.NestedString(refSource, "repoURL")
+		if repoSelector.Matches(refRepoURL) {
+			c.addFile(refPath)
+		}
+		return
+	}
+
+	if !isLocal {
+		return
+	}
+
+	if strings.HasPrefix(valueFile, "/") {
+		c.addFile(valueFile)
+		return
+	}
+	c.addFile(path.Join(sourcePath, valueFile))
+}
+
+// addFile adds a single file
+func (c *dependencyCollector) addFile(file string) {
+	file, ok := cleanRepoPath(file)
+	if !ok || file == "" {
+		return
+	}
+	c.dependencies = append(c.dependencies, file)
+}
+
+// addFolder adds a folder and follows the kustomization.yaml and Chart.yaml in it
+func (c *dependencyCollector) addFolder(folder string) {
+	folder, ok := cleanRepoPath(folder)
+	if !ok || c.visited[folder] {
+		return
+	}
+	c.visited[folder] = true
+
+	if folder == "" {
+		c.dependencies = append(c.dependencies, "")
+	} else {
+		c.dependencies = append(c.dependencies, folder+"/")
+	}
+
+	c.followKustomization(folder)
+	c.followChart(folder)
+}
+
+// followKustomization adds the resources, components, patches and bases referenced by the kustomization in the folder
+func (c *dependencyCollector) followKustomization(folder string) {
+	var k kustomization
+	if !c.readYaml(folder, kustomizationFileNames, &k) {
+		return
+	}
+
+	references := slices.Concat(k.Resources, k.Components, k.Bases, k.PatchesStrategicMerge)
+	for _, patch := range k.Patches {
+		references = append(references, patch.Path)
+	}
+	for _, patch := range k.PatchesJSON6902 {
+		references = append(references, patch.Path)
+	}
+
+	for _, reference := range references {
+		// Skip inline patches and remote resources
+		if reference == "" || strings.Contains(reference, "\n") || strings.Contains(reference, "://") {
+			continue
+		}
+		c.addPath(path.Join(folder, reference))
+	}
+}
+
+// followChart adds the local 'file://' dependencies of the Helm chart in the folder
+func (c *dependencyCollector) followChart(folder string) {
+	var ch chart
+	if !c.readYaml(folder, []string{"Chart.yaml"}, &ch) {
+		return
+	}
+
+	for _, dependency := range ch.Dependencies {
+		if local, found := strings.CutPrefix(dependency.Repository, "file://"); found {
+			c.addFolder(path.Join(folder, local))
+		}
+	}
+}
+
+// addPath adds a folder or a file depending on what exists in the repository
+func (c *dependencyCollector) addPath(p string) {
+	p, ok := cleanRepoPath(p)
+	if !ok {
+		return
+	}
+	info, err := os.Stat(filepath.Join(c.repoDir, p))
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		c.addFolder(p)
+	} else {
+		c.addFile(p)
+	}
+}
+
+// readYaml reads the first of the files that exists in the folder into out
+func (c *dependencyCollector) readYaml(folder string, fileNames []string, out any) bool {
+	for _, fileName := range fileNames {
+		content, err := os.ReadFile(filepath.Join(c.repoDir, folder, fileName))
+		if err != nil {
+			continue
+		}
+		if err := yaml.Unmarshal(content, out); err != nil {
+			log.Debug().Err(err).Msgf("Failed to parse '%s' while inferring dependencies", path.Join(folder, fileName))
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// cleanRepoPath cleans a path relative to the repository root. It returns false
+// for paths outside the repository.
+func cleanRepoPath(p string) (string, bool) {
+	p = path.Clean(strings.TrimPrefix(strings.TrimSpace(p), "/"))
+	if p == "." {
+		return "", true
+	}
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", false
+	}
+	return p, true
+}
+
+// specSources returns every source of an Application spec (source, sources and
+// the dry source of the source hydrator)
+func specSources(spec map[string]any) []map[string]any {
+	var sources []map[string]any
+	if source, found, _ := unstructured.NestedMap(spec, "source"); found {
+		sources = append(sources, source)
+	}
+	if multiple, found, _ := unstructured.NestedSlice(spec, "sources"); found {
+		for _, source := range multiple {
+			if sourceMap, ok := source.(map[string]any); ok {
+				sources = append(sources, sourceMap)
+			}
+		}
+	}
+	if drySource, found, _ := unstructured.NestedMap(spec, "sourceHydrator", "drySource"); found {
+		sources = append(sources, drySource)
+	}
+	return sources
+}
+
+// gitGeneratorPaths returns the file and directory paths of the git generators of an
+// ApplicationSet, including generators nested in matrix and merge generators
+func gitGeneratorPaths(appSet *unstructured.Unstructured, repoSelector repository.Selector) []string {
+	generators, _, _ := unstructured.NestedSlice(appSet.Object, "spec", "generators")
+
+	var paths []string
+	var walk func(generators []any)
+	walk = func(generators []any) {
+		for _, generator := range generators {
+			generatorMap, ok := generator.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if git, found, _ := unstructured.NestedMap(generatorMap, "git"); found {
+				repoURL, _, _ := unstructured.NestedString(git, "repoURL")
+				if repoSelector.Matches(repoURL) {
+					for _, field := range []string{"files", "directories"} {
+						items, _, _ := unstructured.NestedSlice(git, field)
+						for _, item := range items {
+							itemMap, ok := item.(map[string]any)
+							if !ok {
+								continue
+							}
+							if p, found, _ := unstructured.NestedString(itemMap, "path"); found && !isTemplated(p) {
+								if p, ok := cleanRepoPath(p); ok {
+									paths = append(paths, p)
+								}
+							}
+						}
+					}
+				}
+			}
+
+			for _, nested := range []string{"matrix", "merge"} {
+				if nestedGenerators, found, _ := unstructured.NestedSlice(generatorMap, nested, "generators"); found {
+					walk(nestedGenerators)
+				}
+			}
+		}
+	}
+	walk(generators)
+
+	return paths
+}
@@ -0,0 +1,251 @@
+package argoapplication
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/repository"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// writeRepo writes the files to a temporary repository checkout and returns its path
+func writeRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	repoDir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(repoDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	return repoDir
+}
+
+func dependencyTestResource(t *testing.T, kind ApplicationKind, manifest string) *ArgoResource {
+	t.Helper()
+	var obj unstructured.Unstructured
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &obj.Object))
+	return NewArgoResource(&obj, kind, "app", "app", "apps/app.yaml", "")
+}
+
+func TestInferDependencies(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	repoDir := writeRepo(t, map[string]string{
+		"apps/guestbook/overlays/prod/kustomization.yaml": `
+resources:
+  - ../../base
+  - https://github.com/example/remote//base
+  - ../../../../outside
+components:
+  - ../../components/monitoring
+patches:
+  - path: patch-replicas.yaml
+  - patch: |-
+      - op: replace
+        path: /spec/replicas
+        value: 3
+patchesStrategicMerge:
+  - patch-memory.yaml
+`,
+		"apps/guestbook/base/kustomization.yml":                   "resources:\n  - deployment.yaml\n",
+		"apps/guestbook/base/deployment.yaml":                     "kind: Deployment",
+		"apps/guestbook/components/monitoring/kustomization.yaml": "kind: Component",
+		"charts/app/Chart.yaml": `
+dependencies:
+  - name: common
+    repository: file://../common
+  - name: redis
+    repository: https://charts.bitnami.com/bitnami
+`,
+		"charts/common/Chart.yaml": "dependencies:\n  - name: app\n    repository: file://../app\n",
+		"values/prod.yaml":         "replicas: 3",
+	})
+
+	repoSelector := repository.Selector{Repo: "example/gitops"}
+
+	tests := []struct {
+		name          string
+		kind          ApplicationKind
+		manifest      string
+		want          []string
+		wantTemplated bool
+	}{
+		{
+			name: "kustomize overlay is followed recursively",
+			kind: Application,
+			manifest: `
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: apps/guestbook/overlays/prod`,
+			want: []string{
+				"apps/guestbook/base/",
+				"apps/guestbook/components/monitoring/",
+				"apps/guestbook/overlays/prod/",
+			},
+		},
+		{
+			name: "helm value files and local chart dependencies",
+			kind: Application,
+			manifest: `
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: charts/app
+    helm:
+      valueFiles:
+        - values-prod.yaml
+        - /values/prod.yaml
+        - https://example.com/values.yaml
+      fileParameters:
+        - name: config
+          path: files/config.json`,
+			want: []string{
+				"charts/app/",
+				"charts/common/",
+				"values/prod.yaml",
+			},
+		},
+		{
+			name: "value files from a ref source",
+			kind: Application,
+			manifest: `
+spec:
+  sources:
+    - repoURL: https://charts.example.com
+      chart: app
+      helm:
+        valueFiles:
+          - $values/values/prod.yaml
+          - $other/values.yaml
+    - repoURL: https://github.com/example/gitops.git
+      ref: values
+    - repoURL: https://github.com/example/other.git
+      ref: other`,
+			want: []string{"values/prod.yaml"},
+		},
+		{
+			name: "sources from other repositories are ignored",
+			kind: Application,
+			manifest: `
+spec:
+  source:
+    repoURL: https://github.com/example/other.git
+    path: apps/guestbook/base`,
+			want: nil,
+		},
+		{
+			name: "repository root",
+			kind: Application,
+			manifest: `
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: .`,
+			want: []string{""},
+		},
+		{
+			name: "application set with git generator and templated path",
+			kind: ApplicationSet,
+			manifest: `
+spec:
+  generators:
+    - matrix:
+        generators:
+          - git:
+              repoURL: https://github.com/example/gitops.git
+              directories:
+                - path: apps/*
+          - list:
+              elements: []
+  template:
+    spec:
+      source:
+        repoURL: https://github.com/example/gitops.git
+        path: '{{.path.path}}'`,
+			want:          []string{"apps/*"},
+			wantTemplated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := dependencyTestResource(t, tt.kind, tt.manifest)
+			got, templated := app.InferDependencies(repoDir, repoSelector)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantTemplated, templated)
+		})
+	}
+}
+
+func TestFilterByInferredDependencies(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	repoDir := writeRepo(t, map[string]string{
+		"apps/guestbook/kustomization.yaml": "resources:\n  - ../shared/config.yaml\n",
+		"apps/shared/config.yaml":           "kind: ConfigMap",
+	})
+	repoSelector := repository.Selector{Repo: "example/gitops"}
+
+	app := dependencyTestResource(t, Application, `
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: apps/guestbook`)
+
+	tests := []struct {
+		name                       string
+		filesChanged               []string
+		watchIfNoWatchPatternFound bool
+		want                       bool
+		wantRule                   SelectionRule
+	}{
+		{name: "file in source path", filesChanged: []string{"apps/guestbook/deployment.yaml"}, want: true, wantRule: RuleInferredDependencies},
+		{name: "kustomize resource outside source path", filesChanged: []string{"apps/shared/config.yaml"}, want: true, wantRule: RuleInferredDependencies},
+		{name: "unrelated file", filesChanged: []string{"apps/other/deployment.yaml"}, watchIfNoWatchPatternFound: true, want: false, wantRule: RuleInferredDependencies},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rule, _ := app.filterByInferredDependencies(tt.filesChanged, repoDir, repoSelector, tt.watchIfNoWatchPatternFound)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantRule, rule)
+		})
+	}
+
+	t.Run("falls back to watch-if-no-watch-pattern-found when nothing can be inferred", func(t *testing.T) {
+		chartApp := dependencyTestResource(t, Application, `
+spec:
+  source:
+    repoURL: https://charts.example.com
+    chart: app`)
+		got, rule, _ := chartApp.filterByInferredDependencies([]string{"apps/guestbook/deployment.yaml"}, repoDir, repoSelector, true)
+		assert.True(t, got)
+		assert.Equal(t, RuleNoWatchPattern, rule)
+	})
+}
+
+func TestMatchesDependency(t *testing.T) {
+	tests := []struct {
+		dependency string
+		file       string
+		want       bool
+	}{
+		{"", "anything.yaml", true},
+		{"apps/foo/", "apps/foo/values.yaml", true},
+		{"apps/foo/", "apps/foobar/values.yaml", false},
+		{"values/prod.yaml", "values/prod.yaml", true},
+		{"values/prod.yaml", "values/prod.yaml.bak", false},
+		{"clusters/*/config.json", "clusters/prod/config.json", true},
+		{"clusters/*/config.json", "apps/prod/config.json", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, matchesDependency(tt.dependency, tt.file), "%s ~ %s", tt.dependency, tt.file)
+	}
+}
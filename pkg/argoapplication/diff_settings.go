@@ -0,0 +1,112 @@
+package argoapplication
+
+import (
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// diffSettingAnnotations lists the annotations that tune how the diff of a single
+// application is generated. They are copied from an ApplicationSet to the
+// Applications it generates.
+var diffSettingAnnotations = []string{
+	AnnotationDiffIgnore,
+	AnnotationIgnoreResources,
+	AnnotationLineCount,
+	AnnotationHideDiff,
+}
+
+// DiffSettings holds per-application overrides of the global diff options
+type DiffSettings struct {
+	DiffIgnore          *regexp.Regexp                       // extra line regex ignored in addition to --diff-ignore
+	IgnoreResourceRules []resource_filter.IgnoreResourceRule // extra rules applied in addition to --ignore-resources
+	LineCount           *uint                                // overrides --line-count when set
+	HideDiff            bool                                 // only show the application header, not the resource diffs
+}
+
+// ParseDiffSettings reads the per-application diff settings from the annotations of an Application.
+// Invalid values are logged and ignored, so a typo never breaks the whole run.
+func ParseDiffSettings(appName string, annotations map[string]string) DiffSettings {
+	var settings DiffSettings
+
+	if value := strings.TrimSpace(annotations[AnnotationDiffIgnore]); value != "" {
+		regex, err := regexp.Compile(value)
+		if err != nil {
+			// Same fallback as --diff-ignore: treat the value as a literal string
+			log.Warn().Str("App", appName).Msgf("⚠️ Invalid regex in '%s: %s'. Matching it as a literal string", AnnotationDiffIgnore, value)
+			regex = regexp.MustCompile(regexp.QuoteMeta(value))
+		}
+		settings.DiffIgnore = regex
+	}
+
+	if value := strings.TrimSpace(annotations[AnnotationIgnoreResources]); value != "" {
+		rules, err := resource_filter.FromString(value)
+		if err != nil {
+			log.Warn().Str("App", appName).Msgf("⚠️ Ignoring invalid '%s' annotation: %s", AnnotationIgnoreResources, err)
+		} else {
+			settings.IgnoreResourceRules = rules
+		}
+	}
+
+	if value := strings.TrimSpace(annotations[AnnotationLineCount]); value != "" {
+		lineCount, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			log.Warn().Str("App", appName).Msgf("⚠️ Ignoring invalid '%s: %s' annotation. Expected a non-negative number", AnnotationLineCount, value)
+		} else {
+			count := uint(lineCount)
+			settings.LineCount = &count
+		}
+	}
+
+	if value := strings.TrimSpace(annotations[AnnotationHideDiff]); value != "" {
+		hide, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Warn().Str("App", appName).Msgf("⚠️ Ignoring invalid '%s: %s' annotation. Expected 'true' or 'false'", AnnotationHideDiff, value)
+		} else {
+			settings.HideDiff = hide
+		}
+	}
+
+	return settings
+}
+
+// copyDiffSettingsFrom copies the diff setting annotations from an ApplicationSet to
+// an Application generated by it. Annotations already present on the Application
+// (e.g. set through the template) take precedence.
+func (a *ArgoResource) copyDiffSettingsFrom(appSet ArgoResource) {
+	if a.Yaml == nil || appSet.Yaml == nil {
+		return
+	}
+
+	appSetAnnotations, found, err := unstructured.NestedStringMap(appSet.Yaml.Object, "metadata", "annotations")
+	if err != nil || !found || len(appSetAnnotations) == 0 {
+		return
+	}
+
+	annotations := maps.Clone(a.Yaml.GetAnnotations())
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	changed := false
+	for _, key := range diffSettingAnnotations {
+		value, exists := appSetAnnotations[key]
+		if !exists {
+			continue
+		}
+		if _, alreadySet := annotations[key]; alreadySet {
+			continue
+		}
+		annotations[key] = value
+		changed = true
+	}
+
+	if changed {
+		a.Yaml.SetAnnotations(annotations)
+	}
+}
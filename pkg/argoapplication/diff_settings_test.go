@@ -0,0 +1,91 @@
+package argoapplication
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseDiffSettings(t *testing.T) {
+	t.Run("no annotations", func(t *testing.T) {
+		settings := ParseDiffSettings("app", nil)
+		assert.Nil(t, settings.DiffIgnore)
+		assert.Nil(t, settings.IgnoreResourceRules)
+		assert.Nil(t, settings.LineCount)
+		assert.False(t, settings.HideDiff)
+	})
+
+	t.Run("all annotations", func(t *testing.T) {
+		settings := ParseDiffSettings("app", map[string]string{
+			AnnotationDiffIgnore:      "image: .*",
+			AnnotationIgnoreResources: ":ConfigMap:*, apps:Deployment:web",
+			AnnotationLineCount:       "10",
+			AnnotationHideDiff:        "true",
+		})
+		require.NotNil(t, settings.DiffIgnore)
+		assert.True(t, settings.DiffIgnore.MatchString("  image: nginx:1.27"))
+		require.Len(t, settings.IgnoreResourceRules, 2)
+		assert.Equal(t, "ConfigMap", settings.IgnoreResourceRules[0].Kind)
+		assert.Equal(t, "web", settings.IgnoreResourceRules[1].Name)
+		require.NotNil(t, settings.LineCount)
+		assert.Equal(t, uint(10), *settings.LineCount)
+		assert.True(t, settings.HideDiff)
+	})
+
+	t.Run("invalid values are ignored", func(t *testing.T) {
+		settings := ParseDiffSettings("app", map[string]string{
+			AnnotationIgnoreResources: "not-a-rule",
+			AnnotationLineCount:       "-1",
+			AnnotationHideDiff:        "yes please",
+		})
+		assert.Nil(t, settings.IgnoreResourceRules)
+		assert.Nil(t, settings.LineCount)
+		assert.False(t, settings.HideDiff)
+	})
+
+	t.Run("invalid regex falls back to literal match", func(t *testing.T) {
+		settings := ParseDiffSettings("app", map[string]string{
+			AnnotationDiffIgnore: "foo[",
+		})
+		require.NotNil(t, settings.DiffIgnore)
+		assert.True(t, settings.DiffIgnore.MatchString("x: foo["))
+		assert.False(t, settings.DiffIgnore.MatchString("x: foo"))
+	})
+}
+
+func TestCopyDiffSettingsFrom(t *testing.T) {
+	appSet := ArgoResource{
+		Kind: ApplicationSet,
+		Yaml: &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{
+				"name": "my-appset",
+				"annotations": map[string]any{
+					AnnotationHideDiff:     "true",
+					AnnotationLineCount:    "1",
+					AnnotationWatchPattern: "apps/.*",
+				},
+			},
+		}},
+	}
+
+	app := ArgoResource{
+		Kind: Application,
+		Yaml: &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{
+				"name": "generated",
+				"annotations": map[string]any{
+					AnnotationLineCount: "7",
+				},
+			},
+		}},
+	}
+
+	app.copyDiffSettingsFrom(appSet)
+
+	annotations := app.Yaml.GetAnnotations()
+	assert.Equal(t, "true", annotations[AnnotationHideDiff])
+	assert.Equal(t, "7", annotations[AnnotationLineCount], "annotation from the template should win")
+	assert.NotContains(t, annotations, AnnotationWatchPattern, "only diff settings should be copied")
+}
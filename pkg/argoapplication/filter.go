@@ -9,6 +9,9 @@ import (
 	"github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
 	argocdpath "github.com/argoproj/argo-cd/v3/util/app/path"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/app_selector"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/appproject"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/repository"
 	"github.com/rs/zerolog/log"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -26,18 +29,65 @@ const (
 	RenderChanged RenderMode = "changed"
 )
 
+// SelectionRule is the rule that decided whether an Application[Set] was selected or skipped
+type SelectionRule string
+
+const (
+	RuleNoFilter              SelectionRule = "no-filter" // no rule limited the selection
+	RuleRenderAlways          SelectionRule = "render=always"
+	RuleRenderNever           SelectionRule = "render=never"
+	RuleIgnoreAnnotation      SelectionRule = "ignore-annotation"
+	RuleSelector              SelectionRule = "selector"
+	RuleSpec                  SelectionRule = "destination/project/source"
+	RuleFileChanged           SelectionRule = "application-file-changed"
+	RuleWatchPattern          SelectionRule = "watch-pattern"
+	RuleManifestGeneratePaths SelectionRule = "manifest-generate-paths"
+	RuleNoWatchPattern        SelectionRule = "no-watch-pattern"
+	RuleInferredDependencies  SelectionRule = "inferred-dependencies"
+	RuleIdenticalCopy         SelectionRule = "identical-copy"
+)
+
+// SelectionDecision explains why an Application[Set] was selected or skipped
+type SelectionDecision struct {
+	Rule   SelectionRule
+	Reason string
+}
+
 const (
 	AnnotationWatchPattern = "argocd-diff-preview/watch-pattern"
 	AnnotationIgnore       = "argocd-diff-preview/ignore"
 	AnnotationRender       = "argocd-diff-preview/render"
+
+	// Per-application diff settings. See DiffSettings.
+	AnnotationDiffIgnore      = "argocd-diff-preview/diff-ignore"
+	AnnotationIgnoreResources = "argocd-diff-preview/ignore-resources"
+	AnnotationLineCount       = "argocd-diff-preview/line-count"
+	AnnotationHideDiff        = "argocd-diff-preview/hide-diff"
 )
 
 type ApplicationSelectionOptions struct {
 	FileRegex                  *regexp.Regexp
+	IncludeFiles               []string // gitignore syntax globs. Only matching files are searched for applications
+	BootstrapFolders           []string // Helm charts and Kustomizations rendered locally to find applications
 	Selector                   []app_selector.Selector
 	FilesChanged               []string
 	IgnoreInvalidWatchPattern  bool
 	WatchIfNoWatchPatternFound bool
+
+	// InferDependencies selects applications without watch annotations by the files they
+	// are inferred to read from the repository checkout of their branch.
+	InferDependencies bool
+	RepoSelector      repository.Selector
+
+	// Spec based selection. An empty list means the field is not used for selection.
+	// An application is selected if its value matches any of the values in the list.
+	DestinationNames      []string
+	DestinationServers    []string
+	DestinationNamespaces []string
+	Projects              []string
+	SourceRepoURLs        []string
+	SourceCharts          []string
+	SourcePaths           []string
 }
 
 const maxFilesChangedDisplay = 20
@@ -85,11 +135,34 @@ func (appSelectionOptions ApplicationSelectionOptions) LogRules() {
 			formatFilesChanged(appSelectionOptions.FilesChanged),
 		)
 	}
+
+	for _, criterion := range appSelectionOptions.specCriteria() {
+		log.Info().Msgf(
+			"🤖 Will only select Application[Sets] with %s: '%s'",
+			criterion.name,
+			strings.Join(criterion.allowed, "', '"),
+		)
+	}
+
+	if appSelectionOptions.InferDependencies && len(appSelectionOptions.FilesChanged) > 0 {
+		log.Info().Msg("🤖 Will infer dependencies of Application[Sets] without watch-pattern or manifest-generate-paths annotations")
+	}
 }
 
 type ArgoSelection struct {
 	SelectedApps []ArgoResource
 	SkippedApps  []ArgoResource
+
+	// ExcludedFiles are the files and folders excluded by the ignore file or
+	// --include-files. They are not parsed, so their Applications are unknown.
+	ExcludedFiles []fileparsing.ExcludedFile
+
+	// Problems are the Application[Set] manifests in the branch that could not be
+	// parsed or are not valid
+	Problems []ManifestProblem
+
+	// AppProjects are the AppProjects found in the branch by name
+	AppProjects map[string]appproject.Project
 }
 
 func ApplicationSelection(
@@ -111,47 +184,75 @@ func ApplicationSelection(
 	}
 }
 
-// Filter checks if the application matches the given selectors and watches the given files
+// Filter checks if the application matches the given selectors and watches the given files.
+// The decision is stored in a.Selection.
 func (a *ArgoResource) Filter(
 	appSelectionOptions ApplicationSelectionOptions,
 ) bool {
+	selected, decision := a.filter(appSelectionOptions)
+	a.Selection = decision
+
+	if selected {
+		log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is selected because: %s", a.Kind.ShortName(), decision.Reason)
+	} else {
+		log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is not selected because: %s", a.Kind.ShortName(), decision.Reason)
+	}
+
+	return selected
+}
+
+// filter returns if the application is selected and the rule that decided it.
+// For selected applications the rule is the last rule that limited the selection.
+func (a *ArgoResource) filter(
+	appSelectionOptions ApplicationSelectionOptions,
+) (bool, SelectionDecision) {
 	// First check render mode annotation
 	switch a.GetRenderMode() {
 	case RenderNever:
-		log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is not selected because: application is ignored because render mode is '%s'", a.Kind.ShortName(), RenderNever)
-		return false
+		return false, SelectionDecision{RuleRenderNever, fmt.Sprintf("application is ignored because render mode is '%s'", RenderNever)}
 	case RenderAlways:
-		log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is selected because: application is forced because render mode is '%s'", a.Kind.ShortName(), RenderAlways)
-		return true
+		return true, SelectionDecision{RuleRenderAlways, fmt.Sprintf("application is forced because render mode is '%s'", RenderAlways)}
 	}
 
 	// Then check legacy ignore annotation
 	selected, reason := a.filterByIgnoreAnnotation()
 	if !selected {
-		log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is not selected because: %s", a.Kind.ShortName(), reason)
-		return false
+		return false, SelectionDecision{RuleIgnoreAnnotation, reason}
 	}
 
+	decision := SelectionDecision{RuleNoFilter, "no selection rules limit the application"}
+
 	// Then check selectors
 	if len(appSelectionOptions.Selector) > 0 {
 		selected, reason := a.filterBySelectors(appSelectionOptions.Selector)
 		if !selected {
-			log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is not selected because: %s", a.Kind.ShortName(), reason)
-			return false
+			return false, SelectionDecision{RuleSelector, reason}
 		}
+		decision = SelectionDecision{RuleSelector, reason}
+	}
+
+	// Then check destination, project and sources
+	if appSelectionOptions.HasSpecCriteria() {
+		selected, reason := a.filterBySpec(appSelectionOptions)
+		if !selected {
+			return false, SelectionDecision{RuleSpec, reason}
+		}
+		decision = SelectionDecision{RuleSpec, reason}
 	}
 
 	// Then check files changed
 	if len(appSelectionOptions.FilesChanged) > 0 {
-		selected, reason := a.filterByFilesChanged(appSelectionOptions.FilesChanged, appSelectionOptions.IgnoreInvalidWatchPattern, appSelectionOptions.WatchIfNoWatchPatternFound)
+		selected, rule, reason := a.filterByFilesChanged(appSelectionOptions.FilesChanged, appSelectionOptions.IgnoreInvalidWatchPattern, appSelectionOptions.WatchIfNoWatchPatternFound)
+		if rule == RuleNoWatchPattern && appSelectionOptions.InferDependencies {
+			selected, rule, reason = a.filterByInferredDependencies(appSelectionOptions.FilesChanged, a.Branch.FolderName(), appSelectionOptions.RepoSelector, appSelectionOptions.WatchIfNoWatchPatternFound)
+		}
 		if !selected {
-			log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is not selected because: %s", a.Kind.ShortName(), reason)
-			return false
+			return false, SelectionDecision{rule, reason}
 		}
-		log.Debug().Str(a.Kind.ShortName(), a.GetLongName()).Msgf("%s is selected because: %s", a.Kind.ShortName(), reason)
+		decision = SelectionDecision{rule, reason}
 	}
 
-	return true
+	return true, decision
 }
 
 func (a *ArgoResource) filterByIgnoreAnnotation() (bool, string) {
@@ -199,21 +300,19 @@ func (a *ArgoResource) filterBySelectors(selectors []app_selector.Selector) (boo
 		return false, "no YAML found"
 	}
 
-	// Get all labels directly from unstructured
-	labels, found, err := unstructured.NestedStringMap(a.Yaml.Object, "metadata", "labels")
-	if err != nil || !found || len(labels) == 0 {
+	// Get all labels directly from unstructured.
+	// An app without labels can still match 'notin' and '!key' requirements.
+	labels, _, err := unstructured.NestedStringMap(a.Yaml.Object, "metadata", "labels")
+	if err != nil {
 		return false, "no labels found"
 	}
 
 	// Check each selector against the labels
 	for _, s := range selectors {
-		labelValue, exists := labels[s.Key]
-		if !exists {
-			return false, "label not found"
-		}
-
-		matches := labelValue == s.Value
-		if (s.Operator == app_selector.Eq && !matches) || (s.Operator == app_selector.Ne && matches) {
+		if !s.Matches(labels) {
+			if _, exists := labels[s.Key]; !exists && (s.Operator == app_selector.Eq || s.Operator == app_selector.Ne) {
+				return false, "label not found"
+			}
 			return false, fmt.Sprintf("label does not match selector: '%s'", s.String())
 		}
 	}
@@ -221,42 +320,55 @@ func (a *ArgoResource) filterBySelectors(selectors []app_selector.Selector) (boo
 	return true, "labels matches selectors"
 }
 
-// filterByFilesChanged checks if the application watches any of the changed files and returns a reason for the selection
-func (a *ArgoResource) filterByFilesChanged(filesChanged []string, ignoreInvalidWatchPattern bool, watchIfNoWatchPatternFound bool) (bool, string) {
+// filterByFilesChanged checks if the application watches any of the changed files and returns the rule and reason for the selection
+func (a *ArgoResource) filterByFilesChanged(filesChanged []string, ignoreInvalidWatchPattern bool, watchIfNoWatchPatternFound bool) (bool, SelectionRule, string) {
 	if len(filesChanged) == 0 {
-		return false, "no files changed"
+		return false, RuleFileChanged, "no files changed"
 	}
 
 	// check if the application itself is in the list of files changed
 	if slices.Contains(filesChanged, a.FileName) {
-		return true, "application itself is in the list of files changed"
+		return true, RuleFileChanged, "application itself is in the list of files changed"
+	}
+
+	// Applications rendered from a bootstrap folder have the folder as FileName
+	if strings.HasSuffix(a.FileName, "/") {
+		if i := slices.IndexFunc(filesChanged, func(file string) bool { return strings.HasPrefix(file, a.FileName) }); i >= 0 {
+			return true, RuleFileChanged, fmt.Sprintf("file changed '%s' is in the bootstrap folder the application is rendered from", filesChanged[i])
+		}
 	}
 
 	// Get annotations directly from unstructured
 	annotations, found, err := unstructured.NestedStringMap(a.Yaml.Object, "metadata", "annotations")
 	if err != nil || !found || len(annotations) == 0 {
-		return watchIfNoWatchPatternFound, "no watch-pattern or manifest-generate-paths annotation found"
+		return watchIfNoWatchPatternFound, RuleNoWatchPattern, "no watch-pattern or manifest-generate-paths annotation found"
 	}
 
 	effectiveWatchPattern, effectiveManifestGeneratePaths := a.effectiveWatchAnnotations(annotations)
 
 	if effectiveWatchPattern == "" && effectiveManifestGeneratePaths == "" {
-		return watchIfNoWatchPatternFound, "no effective watch-pattern or manifest-generate-paths annotation found"
+		return watchIfNoWatchPatternFound, RuleNoWatchPattern, "no effective watch-pattern or manifest-generate-paths annotation found"
 	}
 
 	if effectiveWatchPattern != "" {
 		if selectedWatchPattern, reasonWatchPattern := a.filterByAnnotationWatchPattern(effectiveWatchPattern, filesChanged, ignoreInvalidWatchPattern); selectedWatchPattern {
-			return true, reasonWatchPattern
+			return true, RuleWatchPattern, reasonWatchPattern
 		}
 	}
 
 	if effectiveManifestGeneratePaths != "" {
 		if selectedManifestGeneratePaths, reasonManifestGeneratePaths := a.filterByManifestGeneratePaths(effectiveManifestGeneratePaths, filesChanged); selectedManifestGeneratePaths {
-			return true, reasonManifestGeneratePaths
+			return true, RuleManifestGeneratePaths, reasonManifestGeneratePaths
 		}
 	}
 
-	return false, "files changed does not match watch-pattern or manifest-generate-paths"
+	if effectiveWatchPattern == "" {
+		return false, RuleManifestGeneratePaths, "files changed does not match manifest-generate-paths"
+	}
+	if effectiveManifestGeneratePaths == "" {
+		return false, RuleWatchPattern, "files changed does not match watch-pattern"
+	}
+	return false, RuleWatchPattern, "files changed does not match watch-pattern or manifest-generate-paths"
 }
 
 func (a *ArgoResource) effectiveWatchAnnotations(annotations map[string]string) (watchPattern string, manifestGeneratePaths string) {
@@ -0,0 +1,250 @@
+package argoapplication
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/repository"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// specCriterion is a single spec field an application can be selected by
+type specCriterion struct {
+	name    string                                    // used in the selection reason, e.g. "destination namespace"
+	allowed []string                                  // values from ApplicationSelectionOptions
+	values  func(spec map[string]any) []string        // values found in the Application spec
+	matches func(value string, allowed []string) bool // compares a spec value against the allowed values
+}
+
+// specCriteria returns the spec criteria that are configured in the selection options
+func (appSelectionOptions ApplicationSelectionOptions) specCriteria() []specCriterion {
+	all := []specCriterion{
+		{name: "destination name", allowed: appSelectionOptions.DestinationNames, values: specStrings("destination", "name"), matches: matchesExact},
+		{name: "destination server", allowed: appSelectionOptions.DestinationServers, values: specStrings("destination", "server"), matches: matchesServer},
+		{name: "destination namespace", allowed: appSelectionOptions.DestinationNamespaces, values: specStrings("destination", "namespace"), matches: matchesExact},
+		{name: "project", allowed: appSelectionOptions.Projects, values: specStrings("project"), matches: matchesExact},
+		{name: "source repoURL", allowed: appSelectionOptions.SourceRepoURLs, values: sourceStrings("repoURL"), matches: matchesRepoURL},
+		{name: "source chart", allowed: appSelectionOptions.SourceCharts, values: sourceStrings("chart"), matches: matchesExact},
+		{name: "source path", allowed: appSelectionOptions.SourcePaths, values: sourceStrings("path"), matches: matchesSourcePath},
+	}
+
+	var configured []specCriterion
+	for _, c := range all {
+		if len(c.allowed) > 0 {
+			configured = append(configured, c)
+		}
+	}
+	return configured
+}
+
+// HasSpecCriteria returns true if applications are selected by any spec field
+func (appSelectionOptions ApplicationSelectionOptions) HasSpecCriteria() bool {
+	return len(appSelectionOptions.specCriteria()) > 0
+}
+
+// filterBySpec checks if the destination, project and sources of the application match the
+// selection options. For ApplicationSets the template is checked. Templated values
+// (e.g. '{{.cluster}}') can't be evaluated before generation, so they are let through here
+// and the generated Applications are checked instead.
+//
+// Patched resources are checked against their Original, since patching rewrites the
+// project and destination.
+func (a *ArgoResource) filterBySpec(appSelectionOptions ApplicationSelectionOptions) (bool, string) {
+	if a.Yaml == nil {
+		return false, "no YAML found"
+	}
+
+	// Use the unpatched resource, since patching changes the project and destination
+	resource := a.Yaml
+	if a.Original != nil {
+		resource = a.Original
+	}
+
+	specPath := []string{"spec"}
+	if a.Kind == ApplicationSet {
+		if _, found, _ := unstructured.NestedString(resource.Object, "spec", "templatePatch"); found {
+			return true, "ApplicationSet uses templatePatch, so spec fields are checked on the generated Applications"
+		}
+		specPath = []string{"spec", "template", "spec"}
+	}
+
+	spec, _, err := unstructured.NestedMap(resource.Object, specPath...)
+	if err != nil || spec == nil {
+		return false, "no spec found"
+	}
+
+	for _, criterion := range appSelectionOptions.specCriteria() {
+		values := criterion.values(spec)
+
+		if slices.ContainsFunc(values, isTemplated) {
+			continue
+		}
+
+		if !slices.ContainsFunc(values, func(v string) bool { return criterion.matches(v, criterion.allowed) }) {
+			if len(values) == 0 {
+				return false, fmt.Sprintf("%s is not set, expected one of '%s'", criterion.name, strings.Join(criterion.allowed, "', '"))
+			}
+			return false, fmt.Sprintf("%s '%s' does not match any of '%s'", criterion.name, strings.Join(values, "', '"), strings.Join(criterion.allowed, "', '"))
+		}
+	}
+
+	return true, "spec matches destination, project and source selection"
+}
+
+// SetOriginalFromAppSet sets the Original of an Application generated from a patched
+// ApplicationSet. Patching the ApplicationSet sets the template project to 'default', removes
+// the template syncPolicy and redirects the template sources to the branch, so these fields
+// are restored from the original template. A templated field keeps its generated value,
+// since the value it would have been rendered to is unknown. A templated targetRevision that
+// was redirected is set back to the template expression, so the Applications generated in
+// both branches do not differ by the branch they were redirected to.
+func (a *ArgoResource) SetOriginalFromAppSet(appSet ArgoResource) {
+	if a.Yaml == nil || appSet.Original == nil {
+		return
+	}
+
+	original := a.Original
+	if original == nil {
+		original = a.Yaml.DeepCopy()
+	}
+	templateSpec, _, _ := unstructured.NestedMap(appSet.Original.Object, "spec", "template", "spec")
+	var patchedTemplateSpec map[string]any
+	if appSet.Yaml != nil {
+		patchedTemplateSpec, _, _ = unstructured.NestedMap(appSet.Yaml.Object, "spec", "template", "spec")
+	}
+
+	project, found, err := unstructured.NestedString(templateSpec, "project")
+	if err == nil && found {
+		_ = unstructured.SetNestedField(original.Object, project, "spec", "project")
+	} else {
+		unstructured.RemoveNestedField(original.Object, "spec", "project")
+	}
+
+	if syncPolicy, found, err := unstructured.NestedMap(templateSpec, "syncPolicy"); err == nil && found {
+		_ = unstructured.SetNestedMap(original.Object, syncPolicy, "spec", "syncPolicy")
+	} else {
+		unstructured.RemoveNestedField(original.Object, "spec", "syncPolicy")
+	}
+
+	if source, found, _ := unstructured.NestedMap(original.Object, "spec", "source"); found {
+		templateSource, _, _ := unstructured.NestedMap(templateSpec, "source")
+		patchedTemplateSource, _, _ := unstructured.NestedMap(patchedTemplateSpec, "source")
+		restoreTargetRevision(source, templateSource, patchedTemplateSource)
+		_ = unstructured.SetNestedMap(original.Object, source, "spec", "source")
+	}
+	if sources, found, _ := unstructured.NestedSlice(original.Object, "spec", "sources"); found {
+		templateSources, _, _ := unstructured.NestedSlice(templateSpec, "sources")
+		patchedTemplateSources, _, _ := unstructured.NestedSlice(patchedTemplateSpec, "sources")
+		for i, source := range sources {
+			sourceMap, ok := source.(map[string]any)
+			if !ok || i >= len(templateSources) {
+				continue
+			}
+			templateSource, _ := templateSources[i].(map[string]any)
+			var patchedTemplateSource map[string]any
+			if i < len(patchedTemplateSources) {
+				patchedTemplateSource, _ = patchedTemplateSources[i].(map[string]any)
+			}
+			restoreTargetRevision(sourceMap, templateSource, patchedTemplateSource)
+		}
+		_ = unstructured.SetNestedSlice(original.Object, sources, "spec", "sources")
+	}
+
+	a.Original = original
+}
+
+// restoreTargetRevision sets the targetRevision of a generated source back to the one of the
+// original template source, which may have been redirected to the branch. A templated
+// targetRevision is only set back if the patched template source no longer has it, since the
+// generated value is then the branch instead of the rendered template.
+func restoreTargetRevision(source, templateSource, patchedTemplateSource map[string]any) {
+	if templateSource == nil {
+		return
+	}
+	targetRevision, ok := templateSource["targetRevision"].(string)
+	switch {
+	case !ok:
+		delete(source, "targetRevision")
+	case !isTemplated(targetRevision):
+		source["targetRevision"] = targetRevision
+	case patchedTemplateSource != nil && patchedTemplateSource["targetRevision"] != targetRevision:
+		source["targetRevision"] = targetRevision
+	}
+}
+
+// specStrings returns a function reading a single string field from the spec
+func specStrings(fields ...string) func(spec map[string]any) []string {
+	return func(spec map[string]any) []string {
+		value, found, err := unstructured.NestedString(spec, fields...)
+		if err != nil || !found || value == "" {
+			return nil
+		}
+		return []string{value}
+	}
+}
+
+// sourceStrings returns a function reading a field from every source of the spec
+// (source, sources and the dry source of the source hydrator)
+func sourceStrings(field string) func(spec map[string]any) []string {
+	return func(spec map[string]any) []string {
+		var sources []any
+		if source, found, _ := unstructured.NestedMap(spec, "source"); found {
+			sources = append(sources, source)
+		}
+		if multiple, found, _ := unstructured.NestedSlice(spec, "sources"); found {
+			sources = append(sources, multiple...)
+		}
+		if drySource, found, _ := unstructured.NestedMap(spec, "sourceHydrator", "drySource"); found {
+			sources = append(sources, drySource)
+		}
+
+		var values []string
+		for _, source := range sources {
+			sourceMap, ok := source.(map[string]any)
+			if !ok {
+				continue
+			}
+			if value, found, _ := unstructured.NestedString(sourceMap, field); found && value != "" {
+				values = append(values, value)
+			}
+		}
+		return values
+	}
+}
+
+func isTemplated(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// matchesExact returns true if the value is one of the allowed values
+func matchesExact(value string, allowed []string) bool {
+	return slices.Contains(allowed, value)
+}
+
+// matchesServer compares cluster URLs ignoring a trailing slash
+func matchesServer(value string, allowed []string) bool {
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		return strings.TrimSuffix(value, "/") == strings.TrimSuffix(a, "/")
+	})
+}
+
+// matchesRepoURL accepts full repository URLs as well as short 'owner/repo' paths,
+// using the same matching as --repo
+func matchesRepoURL(value string, allowed []string) bool {
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		selector := repository.Selector{Repo: a}
+		return selector.Matches(value)
+	})
+}
+
+// matchesSourcePath compares source paths after cleaning them, so 'apps/foo/' matches './apps/foo'
+func matchesSourcePath(value string, allowed []string) bool {
+	clean := func(p string) string {
+		return strings.Trim(path.Clean("/"+strings.TrimSpace(p)), "/")
+	}
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		return clean(value) == clean(a)
+	})
+}
@@ -0,0 +1,233 @@
+package argoapplication
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const specTestApp = `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: payments
+spec:
+  project: payments
+  destination:
+    name: prod-eu
+    server: https://prod-eu.example.com/
+    namespace: payments
+  sources:
+    - repoURL: https://github.com/example/gitops.git
+      path: ./apps/payments/
+    - repoURL: https://kubernetes.github.io/ingress-nginx
+      chart: ingress-nginx
+      targetRevision: 4.10.0`
+
+const specTestAppSet = `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: payments
+spec:
+  generators:
+    - list:
+        elements:
+          - cluster: prod-eu
+  template:
+    metadata:
+      name: '{{.cluster}}-payments'
+    spec:
+      project: payments
+      destination:
+        name: '{{.cluster}}'
+        namespace: payments
+      source:
+        repoURL: https://github.com/example/gitops.git
+        path: apps/payments`
+
+func TestFilterBySpec(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	tests := []struct {
+		name    string
+		yaml    string
+		kind    ApplicationKind
+		options ApplicationSelectionOptions
+		want    bool
+	}{
+		{
+			name:    "matching destination name",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{DestinationNames: []string{"prod-us", "prod-eu"}},
+			want:    true,
+		},
+		{
+			name:    "non-matching destination name",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{DestinationNames: []string{"prod-us"}},
+			want:    false,
+		},
+		{
+			name:    "destination server ignores trailing slash",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{DestinationServers: []string{"https://prod-eu.example.com"}},
+			want:    true,
+		},
+		{
+			name:    "matching namespace and project",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{DestinationNamespaces: []string{"payments"}, Projects: []string{"payments"}},
+			want:    true,
+		},
+		{
+			name:    "all criteria must match",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{DestinationNamespaces: []string{"payments"}, Projects: []string{"default"}},
+			want:    false,
+		},
+		{
+			name:    "repoURL as owner/repo",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{SourceRepoURLs: []string{"example/gitops"}},
+			want:    true,
+		},
+		{
+			name:    "chart matches any source",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{SourceCharts: []string{"ingress-nginx"}},
+			want:    true,
+		},
+		{
+			name:    "source path is cleaned",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{SourcePaths: []string{"apps/payments"}},
+			want:    true,
+		},
+		{
+			name:    "source path does not match",
+			yaml:    specTestApp,
+			kind:    Application,
+			options: ApplicationSelectionOptions{SourcePaths: []string{"apps/orders"}},
+			want:    false,
+		},
+		{
+			name:    "templated destination of ApplicationSet is checked after generation",
+			yaml:    specTestAppSet,
+			kind:    ApplicationSet,
+			options: ApplicationSelectionOptions{DestinationNames: []string{"prod-us"}},
+			want:    true,
+		},
+		{
+			name:    "static field of ApplicationSet template",
+			yaml:    specTestAppSet,
+			kind:    ApplicationSet,
+			options: ApplicationSelectionOptions{Projects: []string{"default"}},
+			want:    false,
+		},
+		{
+			name:    "chart not set on ApplicationSet template",
+			yaml:    specTestAppSet,
+			kind:    ApplicationSet,
+			options: ApplicationSelectionOptions{SourceCharts: []string{"ingress-nginx"}},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node unstructured.Unstructured
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &node))
+
+			app := &ArgoResource{
+				Yaml:     &node,
+				Kind:     tt.kind,
+				Name:     node.GetName(),
+				FileName: "app.yaml",
+			}
+
+			got, reason := app.filterBySpec(tt.options)
+			assert.Equal(t, tt.want, got, reason)
+			assert.Equal(t, tt.want, app.Filter(tt.options), "Filter should agree with filterBySpec")
+		})
+	}
+}
+
+func TestFilterBySpec_Reason(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	var node unstructured.Unstructured
+	assert.NoError(t, yaml.Unmarshal([]byte(specTestApp), &node))
+	app := &ArgoResource{Yaml: &node, Kind: Application, Name: "payments", FileName: "app.yaml"}
+
+	_, reason := app.filterBySpec(ApplicationSelectionOptions{Projects: []string{"default", "platform"}})
+	assert.Equal(t, "project 'payments' does not match any of 'default', 'platform'", reason)
+
+	_, reason = app.filterBySpec(ApplicationSelectionOptions{SourceCharts: []string{"cert-manager"}})
+	assert.Equal(t, "source chart 'ingress-nginx' does not match any of 'cert-manager'", reason)
+}
+
+func TestFilterBySpec_UsesOriginalAfterPatching(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	var node unstructured.Unstructured
+	assert.NoError(t, yaml.Unmarshal([]byte(specTestApp), &node))
+	app := &ArgoResource{Yaml: &node, Kind: Application, Name: "payments", FileName: "app.yaml"}
+
+	options := ApplicationSelectionOptions{
+		DestinationNames: []string{"prod-eu"},
+		Projects:         []string{"payments"},
+	}
+	assert.True(t, app.Filter(options))
+
+	// Patching moves the project to 'default' and the destination to the local cluster
+	assert.NoError(t, app.SetProjectToDefault())
+	assert.NoError(t, app.SetDestinationServerToLocal())
+	app.Original = nil
+	assert.False(t, app.Filter(options), "without the original, the patched spec is checked")
+
+	var original unstructured.Unstructured
+	assert.NoError(t, yaml.Unmarshal([]byte(specTestApp), &original))
+	app.Original = &original
+	assert.True(t, app.Filter(options), "the original spec should be checked")
+}
+
+func TestSetOriginalFromAppSet(t *testing.T) {
+	var appSetNode unstructured.Unstructured
+	assert.NoError(t, yaml.Unmarshal([]byte(specTestAppSet), &appSetNode))
+	appSet := ArgoResource{Yaml: &appSetNode, Kind: ApplicationSet, Name: "payments", FileName: "appset.yaml", Original: appSetNode.DeepCopy()}
+	assert.NoError(t, appSet.SetProjectToDefault())
+
+	var generated unstructured.Unstructured
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: prod-eu-payments
+spec:
+  project: default
+  destination:
+    name: prod-eu
+    namespace: payments`), &generated))
+	app := &ArgoResource{Yaml: &generated, Kind: Application, Name: "prod-eu-payments", FileName: "appset.yaml"}
+
+	app.SetOriginalFromAppSet(appSet)
+
+	project, _, _ := unstructured.NestedString(app.Original.Object, "spec", "project")
+	assert.Equal(t, "payments", project)
+	project, _, _ = unstructured.NestedString(app.Yaml.Object, "spec", "project")
+	assert.Equal(t, "default", project, "the patched Application should not change")
+
+	assert.True(t, app.Filter(ApplicationSelectionOptions{Projects: []string{"payments"}, DestinationNames: []string{"prod-eu"}}))
+}
@@ -189,6 +189,61 @@ metadata:
 			},
 			want: false,
 		},
+		{
+			name: "notin operator",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  labels:
+    team: payments`,
+			selectors: []app_selector.Selector{
+				{Key: "team", Values: []string{"ads", "search"}, Operator: app_selector.NotIn},
+			},
+			want: true,
+		},
+		{
+			name: "notin operator matches app without labels",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app`,
+			selectors: []app_selector.Selector{
+				{Key: "team", Values: []string{"ads", "search"}, Operator: app_selector.NotIn},
+			},
+			want: true,
+		},
+		{
+			name: "in operator with non-matching value",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  labels:
+    team: payments`,
+			selectors: []app_selector.Selector{
+				{Key: "team", Values: []string{"ads", "search"}, Operator: app_selector.In},
+			},
+			want: false,
+		},
+		{
+			name: "exists operator",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  labels:
+    tier: backend`,
+			selectors: []app_selector.Selector{
+				{Key: "tier", Operator: app_selector.Exists},
+				{Key: "legacy", Operator: app_selector.DoesNotExist},
+			},
+			want: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -583,7 +638,7 @@ metadata:
 			}
 
 			// Run filter
-			got, _ := app.filterByFilesChanged(tt.filesChanged, tt.ignoreInvalidWatchPattern, tt.watchIfNoWatchPatternFound)
+			got, _, _ := app.filterByFilesChanged(tt.filesChanged, tt.ignoreInvalidWatchPattern, tt.watchIfNoWatchPatternFound)
 
 			// Check result
 			assert.Equal(t, tt.want, got)
@@ -591,6 +646,28 @@ metadata:
 	}
 }
 
+func TestFilterByFilesChanged_BootstrapFolder(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	var node unstructured.Unstructured
+	err := yaml.Unmarshal([]byte(`
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app`), &node)
+	assert.NoError(t, err)
+
+	// Applications rendered from a bootstrap folder have the folder as FileName
+	app := &ArgoResource{Yaml: &node, Kind: Application, Name: "test-app", FileName: "bootstrap/"}
+
+	got, rule, _ := app.filterByFilesChanged([]string{"bootstrap/values.yaml"}, false, false)
+	assert.True(t, got)
+	assert.Equal(t, RuleFileChanged, rule)
+
+	got, _, _ = app.filterByFilesChanged([]string{"bootstrap-old/values.yaml"}, false, false)
+	assert.False(t, got)
+}
+
 func TestFilter(t *testing.T) {
 
 	zerolog.SetGlobalLevel(zerolog.FatalLevel)
@@ -1047,6 +1124,197 @@ metadata:
 	}
 }
 
+func TestFilter_SelectionDecision(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	tests := []struct {
+		name         string
+		yaml         string
+		options      ApplicationSelectionOptions
+		wantSelected bool
+		wantRule     SelectionRule
+	}{
+		{
+			name: "no options",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app`,
+			options:      ApplicationSelectionOptions{},
+			wantSelected: true,
+			wantRule:     RuleNoFilter,
+		},
+		{
+			name: "render never",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  annotations:
+    argocd-diff-preview/render: never`,
+			options:      ApplicationSelectionOptions{},
+			wantSelected: false,
+			wantRule:     RuleRenderNever,
+		},
+		{
+			name: "render always",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  annotations:
+    argocd-diff-preview/render: always`,
+			options:      ApplicationSelectionOptions{FilesChanged: []string{"other.yaml"}, WatchIfNoWatchPatternFound: false},
+			wantSelected: true,
+			wantRule:     RuleRenderAlways,
+		},
+		{
+			name: "ignore annotation",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  annotations:
+    argocd-diff-preview/ignore: "true"`,
+			options:      ApplicationSelectionOptions{},
+			wantSelected: false,
+			wantRule:     RuleIgnoreAnnotation,
+		},
+		{
+			name: "selector does not match",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  labels:
+    team: a`,
+			options:      ApplicationSelectionOptions{Selector: []app_selector.Selector{{Key: "team", Value: "b", Operator: app_selector.Eq}}},
+			wantSelected: false,
+			wantRule:     RuleSelector,
+		},
+		{
+			name: "selector matches",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  labels:
+    team: a`,
+			options:      ApplicationSelectionOptions{Selector: []app_selector.Selector{{Key: "team", Value: "a", Operator: app_selector.Eq}}},
+			wantSelected: true,
+			wantRule:     RuleSelector,
+		},
+		{
+			name: "project does not match",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+spec:
+  project: default`,
+			options:      ApplicationSelectionOptions{Projects: []string{"payments"}},
+			wantSelected: false,
+			wantRule:     RuleSpec,
+		},
+		{
+			name: "watch-pattern matches after selector",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  labels:
+    team: a
+  annotations:
+    argocd-diff-preview/watch-pattern: "apps/.*"`,
+			options: ApplicationSelectionOptions{
+				Selector:     []app_selector.Selector{{Key: "team", Value: "a", Operator: app_selector.Eq}},
+				FilesChanged: []string{"apps/values.yaml"},
+			},
+			wantSelected: true,
+			wantRule:     RuleWatchPattern,
+		},
+		{
+			name: "watch-pattern does not match",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  annotations:
+    argocd-diff-preview/watch-pattern: "apps/.*"`,
+			options:      ApplicationSelectionOptions{FilesChanged: []string{"other/values.yaml"}},
+			wantSelected: false,
+			wantRule:     RuleWatchPattern,
+		},
+		{
+			name: "manifest-generate-paths does not match",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app
+  annotations:
+    argocd.argoproj.io/manifest-generate-paths: "."
+spec:
+  source:
+    path: apps/test`,
+			options:      ApplicationSelectionOptions{FilesChanged: []string{"other/values.yaml"}},
+			wantSelected: false,
+			wantRule:     RuleManifestGeneratePaths,
+		},
+		{
+			name: "no watch-pattern",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app`,
+			options:      ApplicationSelectionOptions{FilesChanged: []string{"other/values.yaml"}, WatchIfNoWatchPatternFound: false},
+			wantSelected: false,
+			wantRule:     RuleNoWatchPattern,
+		},
+		{
+			name: "application file changed",
+			yaml: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: test-app`,
+			options:      ApplicationSelectionOptions{FilesChanged: []string{"test.yaml"}},
+			wantSelected: true,
+			wantRule:     RuleFileChanged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node unstructured.Unstructured
+			assert.NoError(t, yaml.Unmarshal([]byte(tt.yaml), &node))
+
+			app := &ArgoResource{
+				Yaml:     &node,
+				Kind:     Application,
+				Id:       "test-app",
+				Name:     "test-app",
+				FileName: "test.yaml",
+			}
+
+			got := app.Filter(tt.options)
+			assert.Equal(t, tt.wantSelected, got)
+			assert.Equal(t, tt.wantRule, app.Selection.Rule, app.Selection.Reason)
+			assert.NotEmpty(t, app.Selection.Reason)
+		})
+	}
+}
+
 func TestFilterByAnnotationWatchPattern(t *testing.T) {
 
 	zerolog.SetGlobalLevel(zerolog.FatalLevel)
@@ -1144,10 +1412,10 @@ spec:
 		FileName: "appset.yaml",
 	}
 
-	got, _ := appSet.filterByFilesChanged([]string{"apps/backend/deployment.yaml"}, false, false)
+	got, _, _ := appSet.filterByFilesChanged([]string{"apps/backend/deployment.yaml"}, false, false)
 	assert.False(t, got, "ApplicationSet metadata manifest-generate-paths should not be evaluated")
 
-	got, _ = appSet.filterByFilesChanged([]string{"apps/backend/deployment.yaml"}, false, true)
+	got, _, _ = appSet.filterByFilesChanged([]string{"apps/backend/deployment.yaml"}, false, true)
 	assert.True(t, got, "ApplicationSet should fall back to watchIfNoWatchPatternFound when no supported watch annotation exists")
 }
 
@@ -45,6 +45,11 @@ func PatchApplication(
 	repoSelector repository.Selector,
 	redirectRevisions []string,
 ) (*ArgoResource, error) {
+	// Keep the unpatched resource around, so selection can still look at the original spec
+	if app.Original == nil && app.Yaml != nil {
+		app.Original = app.Yaml.DeepCopy()
+	}
+
 	// Chain the modifications
 	app.SetNamespace(argocdNamespace)
 
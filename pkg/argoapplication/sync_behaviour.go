@@ -0,0 +1,188 @@
+package argoapplication
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Annotations Argo CD reads from the rendered resources to decide how to sync them
+const (
+	AnnotationSyncOptions    = "argocd.argoproj.io/sync-options"
+	AnnotationCompareOptions = "argocd.argoproj.io/compare-options"
+	AnnotationHook           = "argocd.argoproj.io/hook"
+	AnnotationSyncWave       = "argocd.argoproj.io/sync-wave"
+	AnnotationHelmHook       = "helm.sh/hook"
+	AnnotationHelmHookWeight = "helm.sh/hook-weight"
+)
+
+// Sync phases in the order Argo CD runs them
+const (
+	PhasePreSync  = "PreSync"
+	PhaseSync     = "Sync"
+	PhasePostSync = "PostSync"
+	PhaseSyncFail = "SyncFail"
+)
+
+// helmHookPhases maps the Helm hooks Argo CD runs during a sync to its own sync phases.
+// Other Helm hooks, like pre-delete and test, are not run during a sync.
+var helmHookPhases = map[string]string{
+	"crd-install":  PhaseSync,
+	"pre-install":  PhasePreSync,
+	"pre-upgrade":  PhasePreSync,
+	"post-install": PhasePostSync,
+	"post-upgrade": PhasePostSync,
+}
+
+// SyncBehaviour is how Argo CD syncs a resource. It is read from the annotations of the
+// resource and the sync options of its Application.
+type SyncBehaviour struct {
+	NoPrune          bool   // Prune=false: the resource is not pruned when it is removed from the Application
+	NoDelete         bool   // Delete=false: the resource is kept when the Application is deleted
+	Replace          bool   // Replace=true: the resource is replaced instead of applied
+	ServerSideApply  bool   // ServerSideApply=true
+	IgnoreExtraneous bool   // IgnoreExtraneous: the resource does not affect the sync status when it is not in Git
+	Hook             string // the hook types, e.g. PreSync
+	SyncWave         string
+	HelmHook         string // the Helm hook types, e.g. pre-install
+	HelmHookWeight   string
+}
+
+// ParseSyncBehaviour reads the sync behaviour of a resource. The sync options of the resource
+// take precedence over the sync options of the Application.
+func ParseSyncBehaviour(resource *unstructured.Unstructured, appSyncOptions []string) SyncBehaviour {
+	if resource == nil {
+		return SyncBehaviour{}
+	}
+	annotations := resource.GetAnnotations()
+
+	options := parseOptions(appSyncOptions)
+	for key, value := range parseOptions(strings.Split(annotations[AnnotationSyncOptions], ",")) {
+		options[key] = value
+	}
+
+	return SyncBehaviour{
+		NoPrune:          options["Prune"] == "false",
+		NoDelete:         options["Delete"] == "false",
+		Replace:          options["Replace"] == "true",
+		ServerSideApply:  options["ServerSideApply"] == "true",
+		IgnoreExtraneous: hasOption(annotations[AnnotationCompareOptions], "IgnoreExtraneous"),
+		Hook:             strings.TrimSpace(annotations[AnnotationHook]),
+		SyncWave:         strings.TrimSpace(annotations[AnnotationSyncWave]),
+		HelmHook:         strings.TrimSpace(annotations[AnnotationHelmHook]),
+		HelmHookWeight:   strings.TrimSpace(annotations[AnnotationHelmHookWeight]),
+	}
+}
+
+// parseOptions parses sync options like 'Prune=false' into a map
+func parseOptions(options []string) map[string]string {
+	result := map[string]string{}
+	for _, option := range options {
+		key, value, found := strings.Cut(strings.TrimSpace(option), "=")
+		if !found || key == "" {
+			continue
+		}
+		result[key] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+func hasOption(options string, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if strings.TrimSpace(o) == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Badges describes the sync behaviour that differs from a plain apply, e.g. "won't be pruned"
+func (s SyncBehaviour) Badges() []string {
+	var badges []string
+	if s.NoPrune {
+		badges = append(badges, "won't be pruned")
+	}
+	if s.NoDelete {
+		badges = append(badges, "won't be deleted")
+	}
+	if s.Replace {
+		badges = append(badges, "replaced")
+	}
+	if s.ServerSideApply {
+		badges = append(badges, "server-side apply")
+	}
+	if s.IgnoreExtraneous {
+		badges = append(badges, "ignored if extraneous")
+	}
+	if s.Hook != "" {
+		badges = append(badges, fmt.Sprintf("hook: %s", s.Hook))
+	}
+	if s.HelmHook != "" {
+		badges = append(badges, fmt.Sprintf("helm hook: %s", s.HelmHook))
+	}
+	if s.SyncWave != "" && s.SyncWave != "0" {
+		badges = append(badges, fmt.Sprintf("sync wave: %s", s.SyncWave))
+	}
+	return badges
+}
+
+// IsHook returns true if the resource is an Argo CD or Helm hook
+func (s SyncBehaviour) IsHook() bool {
+	return s.Hook != "" || s.HelmHook != ""
+}
+
+// Phases returns the sync phases the resource is applied in. Resources that are not hooks are
+// applied in the Sync phase. Hooks that do not run during a sync, like PostDelete and Skip,
+// return no phases. Argo CD hooks take precedence over Helm hooks.
+func (s SyncBehaviour) Phases() []string {
+	if s.Hook == "" && s.HelmHook == "" {
+		return []string{PhaseSync}
+	}
+
+	var phases []string
+	addPhase := func(phase string) {
+		if phase != "" && !slices.Contains(phases, phase) {
+			phases = append(phases, phase)
+		}
+	}
+	if s.Hook != "" {
+		for _, hook := range strings.Split(s.Hook, ",") {
+			switch hook = strings.TrimSpace(hook); hook {
+			case PhasePreSync, PhaseSync, PhasePostSync, PhaseSyncFail:
+				addPhase(hook)
+			}
+		}
+		return phases
+	}
+	for _, hook := range strings.Split(s.HelmHook, ",") {
+		addPhase(helmHookPhases[strings.TrimSpace(hook)])
+	}
+	return phases
+}
+
+// Wave returns the sync wave of the resource. Helm hook weights are used as the wave of Helm
+// hooks without a sync wave. The wave is 0 if it is not set or not a number.
+func (s SyncBehaviour) Wave() int {
+	wave := s.SyncWave
+	if wave == "" && s.Hook == "" {
+		wave = s.HelmHookWeight
+	}
+	value, err := strconv.Atoi(wave)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// SyncOptions returns spec.syncPolicy.syncOptions of the Application as written in the repository
+func (a *ArgoResource) SyncOptions() []string {
+	app := a.Unpatched()
+	if app == nil {
+		return nil
+	}
+	options, _, _ := unstructured.NestedStringSlice(app.Object, "spec", "syncPolicy", "syncOptions")
+	return options
+}
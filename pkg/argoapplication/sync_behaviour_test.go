@@ -0,0 +1,158 @@
+package argoapplication
+
+import (
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func resourceWithAnnotations(annotations map[string]string) *unstructured.Unstructured {
+	resource := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "config"},
+	}}
+	resource.SetAnnotations(annotations)
+	return resource
+}
+
+func TestParseSyncBehaviour(t *testing.T) {
+	t.Run("no annotations", func(t *testing.T) {
+		behaviour := ParseSyncBehaviour(resourceWithAnnotations(nil), nil)
+		assert.Equal(t, SyncBehaviour{}, behaviour)
+		assert.Empty(t, behaviour.Badges())
+	})
+
+	t.Run("resource annotations", func(t *testing.T) {
+		behaviour := ParseSyncBehaviour(resourceWithAnnotations(map[string]string{
+			AnnotationSyncOptions:    "Prune=false, Delete=false,Replace=true",
+			AnnotationCompareOptions: "IgnoreExtraneous",
+			AnnotationHook:           "PreSync",
+			AnnotationSyncWave:       "-1",
+		}), nil)
+		assert.Equal(t, SyncBehaviour{
+			NoPrune:          true,
+			NoDelete:         true,
+			Replace:          true,
+			IgnoreExtraneous: true,
+			Hook:             "PreSync",
+			SyncWave:         "-1",
+		}, behaviour)
+		assert.Equal(t, []string{"won't be pruned", "won't be deleted", "replaced", "ignored if extraneous", "hook: PreSync", "sync wave: -1"}, behaviour.Badges())
+	})
+
+	t.Run("Application sync options", func(t *testing.T) {
+		behaviour := ParseSyncBehaviour(resourceWithAnnotations(nil), []string{"ServerSideApply=true", "CreateNamespace=true"})
+		assert.True(t, behaviour.ServerSideApply)
+		assert.Equal(t, []string{"server-side apply"}, behaviour.Badges())
+	})
+
+	t.Run("resource sync options take precedence", func(t *testing.T) {
+		behaviour := ParseSyncBehaviour(resourceWithAnnotations(map[string]string{
+			AnnotationSyncOptions: "ServerSideApply=false",
+		}), []string{"ServerSideApply=true", "Replace=true"})
+		assert.False(t, behaviour.ServerSideApply)
+		assert.True(t, behaviour.Replace)
+	})
+
+	t.Run("sync wave 0 is the default", func(t *testing.T) {
+		behaviour := ParseSyncBehaviour(resourceWithAnnotations(map[string]string{AnnotationSyncWave: "0"}), nil)
+		assert.Empty(t, behaviour.Badges())
+	})
+}
+
+func TestArgoResourceSyncOptions(t *testing.T) {
+	app := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"syncPolicy": map[string]any{"syncOptions": []any{"ServerSideApply=true"}},
+		},
+	}}
+	resource := NewArgoResource(&unstructured.Unstructured{Object: map[string]any{}}, Application, "app", "app", "app.yaml", git.Target)
+	resource.Original = app
+
+	assert.Equal(t, []string{"ServerSideApply=true"}, resource.SyncOptions())
+}
+
+func TestSyncBehaviourPhasesAndWave(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		phases      []string
+		wave        int
+		hook        bool
+	}{
+		{name: "plain resource", annotations: nil, phases: []string{PhaseSync}, wave: 0},
+		{name: "sync wave", annotations: map[string]string{AnnotationSyncWave: "-2"}, phases: []string{PhaseSync}, wave: -2},
+		{name: "invalid sync wave", annotations: map[string]string{AnnotationSyncWave: "first"}, phases: []string{PhaseSync}, wave: 0},
+		{name: "Argo CD hooks", annotations: map[string]string{AnnotationHook: "PreSync, PostSync", AnnotationSyncWave: "3"}, phases: []string{PhasePreSync, PhasePostSync}, wave: 3, hook: true},
+		{name: "skipped hook", annotations: map[string]string{AnnotationHook: "Skip"}, phases: nil, wave: 0, hook: true},
+		{name: "Helm hook", annotations: map[string]string{AnnotationHelmHook: "pre-install,pre-upgrade", AnnotationHelmHookWeight: "5"}, phases: []string{PhasePreSync}, wave: 5, hook: true},
+		{name: "Helm test hook", annotations: map[string]string{AnnotationHelmHook: "test"}, phases: nil, wave: 0, hook: true},
+		{name: "Argo CD hook takes precedence", annotations: map[string]string{AnnotationHook: "PostSync", AnnotationHelmHook: "pre-install", AnnotationHelmHookWeight: "5"}, phases: []string{PhasePostSync}, wave: 0, hook: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			behaviour := ParseSyncBehaviour(resourceWithAnnotations(tt.annotations), nil)
+			assert.Equal(t, tt.phases, behaviour.Phases())
+			assert.Equal(t, tt.wave, behaviour.Wave())
+			assert.Equal(t, tt.hook, behaviour.IsHook())
+		})
+	}
+
+	t.Run("Helm hook badge", func(t *testing.T) {
+		behaviour := ParseSyncBehaviour(resourceWithAnnotations(map[string]string{AnnotationHelmHook: "post-install"}), nil)
+		assert.Equal(t, []string{"helm hook: post-install"}, behaviour.Badges())
+	})
+}
+
+func TestArgoResourceSyncOptions_GeneratedByApplicationSet(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	var node unstructured.Unstructured
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+spec:
+  generators:
+    - list:
+        elements:
+          - name: guestbook
+  template:
+    metadata:
+      name: '{{.name}}'
+    spec:
+      project: default
+      source:
+        repoURL: https://github.com/org/repo.git
+        path: guestbook
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: guestbook
+      syncPolicy:
+        syncOptions:
+          - ServerSideApply=true`), &node))
+	branch := git.NewBranch("feature", git.Target)
+
+	// Patching removes the syncPolicy of the template, so it is not in the generated Application
+	appSet, err := PatchApplication("argocd", *NewArgoResource(&node, ApplicationSet, "guestbook", "guestbook", "appset.yaml", git.Target), branch, *mustNewTestSelector(t, "org/repo", ""), nil)
+	require.NoError(t, err)
+	spec, _, err := unstructured.NestedMap(appSet.Yaml.Object, "spec", "template", "spec")
+	require.NoError(t, err)
+	app := NewArgoResource(&unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]any{"name": "guestbook"},
+		"spec":       spec,
+	}}, Application, "guestbook", "guestbook", appSet.FileName, git.Target)
+	app.SetOriginalFromAppSet(*appSet)
+
+	assert.Equal(t, []string{"ServerSideApply=true"}, app.SyncOptions())
+}
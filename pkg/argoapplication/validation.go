@@ -0,0 +1,190 @@
+package argoapplication
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/argoproj/argo-cd/v3/pkg/apis/application/v1alpha1"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+// ManifestProblem is an Application or ApplicationSet manifest that could not be parsed
+// or is not valid. Such manifests are either silently dropped or fail to render, so they
+// are reported instead.
+type ManifestProblem struct {
+	Branch   git.BranchType
+	FileName string
+	Kind     string // "Application", "ApplicationSet" or empty if the manifest could not be parsed
+	Name     string
+	Message  string
+	// Warning is set for fields unknown to the Argo CD version this tool is built with. They
+	// may be fields of a newer Argo CD version, so the manifest can still be valid.
+	Warning bool
+}
+
+// ValidateResources returns the problems of the Application[Sets] among the resources,
+// together with the problems found while parsing them
+func ValidateResources(resources []fileparsing.Resource, parseProblems []fileparsing.ParseProblem) []ManifestProblem {
+	var problems []ManifestProblem
+
+	for _, p := range parseProblems {
+		problems = append(problems, ManifestProblem{
+			Branch:   p.Branch,
+			FileName: p.FileName,
+			Message:  p.Message,
+		})
+	}
+
+	for _, r := range resources {
+		if !strings.HasPrefix(r.Yaml.GetAPIVersion(), "argoproj.io/") {
+			continue
+		}
+
+		var messages, warnings []string
+		switch r.Yaml.GetKind() {
+		case "Application":
+			messages, warnings = validateApplication(&r.Yaml)
+		case "ApplicationSet":
+			messages, warnings = validateApplicationSet(&r.Yaml)
+		default:
+			continue
+		}
+
+		for _, message := range messages {
+			problems = append(problems, ManifestProblem{
+				Branch:   r.Branch,
+				FileName: r.FileName,
+				Kind:     r.Yaml.GetKind(),
+				Name:     r.Yaml.GetName(),
+				Message:  message,
+			})
+		}
+		for _, warning := range warnings {
+			problems = append(problems, ManifestProblem{
+				Branch:   r.Branch,
+				FileName: r.FileName,
+				Kind:     r.Yaml.GetKind(),
+				Name:     r.Yaml.GetName(),
+				Message:  warning,
+				Warning:  true,
+			})
+		}
+	}
+
+	for _, p := range problems {
+		if p.Warning {
+			log.Warn().Str("branch", string(p.Branch)).Str("file", p.FileName).Msgf("⚠️ Manifest warning: %s", p.Message)
+			continue
+		}
+		log.Warn().Str("branch", string(p.Branch)).Str("file", p.FileName).Msgf("⚠️ Invalid manifest: %s", p.Message)
+	}
+
+	return problems
+}
+
+// validateApplication returns the problems and the warnings of an Application
+func validateApplication(obj *unstructured.Unstructured) ([]string, []string) {
+	var messages []string
+	if obj.GetName() == "" {
+		messages = append(messages, "metadata.name is missing")
+	}
+
+	var app v1alpha1.Application
+	problem, warnings := validateSchema(obj, &app, "Application")
+	if problem != "" {
+		return append(messages, problem), nil
+	}
+
+	spec, found, _ := unstructured.NestedMap(obj.Object, "spec")
+	if !found {
+		return append(messages, "spec is missing"), warnings
+	}
+	return append(messages, validateApplicationSpec(spec, "spec")...), warnings
+}
+
+// validateApplicationSet returns the problems and the warnings of an ApplicationSet
+func validateApplicationSet(obj *unstructured.Unstructured) ([]string, []string) {
+	var messages []string
+	if obj.GetName() == "" {
+		messages = append(messages, "metadata.name is missing")
+	}
+
+	var appSet v1alpha1.ApplicationSet
+	problem, warnings := validateSchema(obj, &appSet, "ApplicationSet")
+	if problem != "" {
+		return append(messages, problem), nil
+	}
+
+	generators, _, _ := unstructured.NestedSlice(obj.Object, "spec", "generators")
+	if len(generators) == 0 {
+		messages = append(messages, "spec.generators is missing")
+	}
+
+	// A template patch can add the source and destination, so the template is not
+	// required to be complete
+	if templatePatch, _, _ := unstructured.NestedString(obj.Object, "spec", "templatePatch"); templatePatch != "" {
+		return messages, warnings
+	}
+
+	spec, found, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+	if !found {
+		return append(messages, "spec.template.spec is missing"), warnings
+	}
+	return append(messages, validateApplicationSpec(spec, "spec.template.spec")...), warnings
+}
+
+// validateSchema converts the object to the Argo CD type. A value of the wrong type is a
+// problem. Unknown fields are only warnings, since they may be fields of a newer Argo CD
+// version than the one this tool is built with.
+func validateSchema(obj *unstructured.Unstructured, into any, kind string) (string, []string) {
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into); err != nil {
+		return fmt.Sprintf("does not match the %s schema: %s", kind, err), nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(obj.Object, into, true); err != nil {
+		return "", []string{fmt.Sprintf("has fields unknown to the %s schema of this Argo CD version: %s", kind, strings.TrimPrefix(err.Error(), "strict decoding error: "))}
+	}
+	return "", nil
+}
+
+// validateApplicationSpec checks the fields Argo CD needs to render an Application
+func validateApplicationSpec(spec map[string]any, path string) []string {
+	var messages []string
+
+	_, hasSource := spec["source"]
+	sources, hasSources := spec["sources"].([]any)
+	_, hasSourceHydrator := spec["sourceHydrator"]
+
+	switch {
+	case !hasSource && !hasSources && !hasSourceHydrator:
+		messages = append(messages, fmt.Sprintf("%[1]s.source, %[1]s.sources or %[1]s.sourceHydrator is required", path))
+	case hasSource:
+		if source, ok := spec["source"].(map[string]any); !ok || source["repoURL"] == nil || source["repoURL"] == "" {
+			messages = append(messages, fmt.Sprintf("%s.source.repoURL is missing", path))
+		}
+	}
+	for i, s := range sources {
+		if source, ok := s.(map[string]any); !ok || source["repoURL"] == nil || source["repoURL"] == "" {
+			messages = append(messages, fmt.Sprintf("%s.sources[%d].repoURL is missing", path, i))
+		}
+	}
+
+	destination, ok := spec["destination"].(map[string]any)
+	if !ok {
+		return append(messages, fmt.Sprintf("%s.destination is missing", path))
+	}
+	server, _ := destination["server"].(string)
+	name, _ := destination["name"].(string)
+	switch {
+	case server == "" && name == "":
+		messages = append(messages, fmt.Sprintf("%[1]s.destination.server or %[1]s.destination.name is required", path))
+	case server != "" && name != "":
+		messages = append(messages, fmt.Sprintf("%[1]s.destination.server and %[1]s.destination.name are mutually exclusive", path))
+	}
+
+	return messages
+}
@@ -0,0 +1,201 @@
+package argoapplication
+
+import (
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateResources(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	tests := []struct {
+		name     string
+		manifest string
+		want     []string
+		warnings []string
+	}{
+		{
+			name: "valid application",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  project: default
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: apps/guestbook
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: guestbook`,
+		},
+		{
+			name: "unknown field",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    pth: apps/guestbook
+  destination:
+    name: in-cluster`,
+			warnings: []string{`has fields unknown to the Application schema of this Argo CD version: unknown field "spec.source.pth"`},
+		},
+		{
+			name: "unknown field and missing destination",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: apps/guestbook
+  newField: true`,
+			want:     []string{"spec.destination is missing"},
+			warnings: []string{`has fields unknown to the Application schema of this Argo CD version: unknown field "spec.newField"`},
+		},
+		{
+			name: "wrong type",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: [apps, guestbook]
+  destination:
+    name: in-cluster`,
+			want: []string{"does not match the Application schema: unrecognized type: string"},
+		},
+		{
+			name: "missing source and conflicting destination",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+    name: in-cluster`,
+			want: []string{
+				"spec.source, spec.sources or spec.sourceHydrator is required",
+				"spec.destination.server and spec.destination.name are mutually exclusive",
+			},
+		},
+		{
+			name: "multiple sources without repoURL",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: guestbook
+spec:
+  sources:
+    - repoURL: https://github.com/example/gitops.git
+      path: apps/guestbook
+    - chart: redis
+  destination:
+    server: https://kubernetes.default.svc`,
+			want: []string{"spec.sources[1].repoURL is missing"},
+		},
+		{
+			name: "application set without generators and destination",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+spec:
+  template:
+    metadata:
+      name: '{{.name}}'
+    spec:
+      source:
+        repoURL: https://github.com/example/gitops.git
+        path: '{{.path}}'`,
+			want: []string{
+				"spec.generators is missing",
+				"spec.template.spec.destination is missing",
+			},
+		},
+		{
+			name: "application set with template patch",
+			manifest: `
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: guestbook
+spec:
+  generators:
+    - list:
+        elements: []
+  template:
+    metadata:
+      name: '{{.name}}'
+    spec:
+      project: default
+  templatePatch: |
+    spec:
+      destination:
+        server: '{{.server}}'`,
+		},
+		{
+			name: "other resources are ignored",
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: Application`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, parseProblems := fileparsing.ParseYamlString("apps/guestbook.yaml", tt.manifest, git.Target)
+			require.Empty(t, parseProblems)
+			require.Len(t, resources, 1)
+
+			problems := ValidateResources(resources, nil)
+
+			var messages, warnings []string
+			for _, problem := range problems {
+				assert.Equal(t, git.Target, problem.Branch)
+				assert.Equal(t, "apps/guestbook.yaml", problem.FileName)
+				assert.Equal(t, "guestbook", problem.Name)
+				if problem.Warning {
+					warnings = append(warnings, problem.Message)
+				} else {
+					messages = append(messages, problem.Message)
+				}
+			}
+			assert.Equal(t, tt.want, messages)
+			assert.Equal(t, tt.warnings, warnings)
+		})
+	}
+}
+
+func TestValidateResources_ParseProblems(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.FatalLevel)
+
+	problems := ValidateResources(nil, []fileparsing.ParseProblem{
+		{FileName: "apps/broken.yaml", Branch: git.Base, Message: "apiVersion is missing"},
+	})
+
+	assert.Equal(t, []ManifestProblem{
+		{Branch: git.Base, FileName: "apps/broken.yaml", Message: "apiVersion is missing"},
+	}, problems)
+}
@@ -414,6 +414,38 @@ func (a *ArgoCDInstallation) findValuesFiles() ([]string, error) {
 	return valuesFiles, nil
 }
 
+// ConfigDiffers returns true if the values files of two Argo CD config folders differ, so
+// Argo CD would be installed differently from them. Other files in the folders are ignored.
+// A missing folder is the same as a folder without values files.
+func ConfigDiffers(configPath, otherConfigPath string) (bool, error) {
+	for _, name := range []string{valuesFileName, valuesOverrideFileName} {
+		content, exists, err := readOptionalFile(filepath.Join(configPath, name))
+		if err != nil {
+			return false, err
+		}
+		otherContent, otherExists, err := readOptionalFile(filepath.Join(otherConfigPath, name))
+		if err != nil {
+			return false, err
+		}
+		if exists != otherExists || content != otherContent {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// readOptionalFile returns the content of a file and whether it exists
+func readOptionalFile(path string) (string, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(content), true, nil
+}
+
 func (a *ArgoCDInstallation) mergeValues(settings *cli.EnvSettings, valuesFiles []string) (map[string]any, error) {
 	mergedValues := map[string]any{}
 
@@ -541,6 +573,38 @@ func (a *ArgoCDInstallation) EnsureArgoCdIsReady() error {
 	return nil
 }
 
+// Uninstall removes the Argo CD Helm release and its namespace, so another version of Argo CD
+// can be installed in the same cluster. Secrets from the secrets folder are removed as well
+// and must be applied again by Install.
+func (a *ArgoCDInstallation) Uninstall() error {
+	timeout := 300 * time.Second
+	a.Cleanup()
+
+	log.Info().Msgf("🦑 Uninstalling Argo CD from namespace '%s'", a.Namespace)
+
+	settings := cli.New()
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(settings.RESTClientGetter(), a.Namespace, os.Getenv("HELM_DRIVER"), helmDebugLog); err != nil {
+		return fmt.Errorf("failed to initialize helm configuration: %w", err)
+	}
+
+	helmClient := action.NewUninstall(actionConfig)
+	helmClient.Wait = true
+	helmClient.Timeout = timeout
+	if _, err := helmClient.Run("argocd"); err != nil {
+		return fmt.Errorf("failed to uninstall chart: %w", err)
+	}
+
+	// The namespace also holds the Applications and the secrets created by Argo CD itself,
+	// like the initial admin password
+	if err := a.K8sClient.DeleteNamespace(a.Namespace, int(timeout.Seconds())); err != nil {
+		return fmt.Errorf("failed to delete namespace %s: %w", a.Namespace, err)
+	}
+
+	log.Info().Msg("🦑 Argo CD uninstalled successfully")
+	return nil
+}
+
 // Cleanup performs any necessary cleanup (e.g., stopping port forwards).
 // This delegates to the operations implementation.
 func (a *ArgoCDInstallation) Cleanup() {
@@ -166,6 +166,71 @@ func TestFindValuesFiles(t *testing.T) {
 	}
 }
 
+func TestConfigDiffers(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		otherFiles  map[string]string
+		wantDiffers bool
+	}{
+		{
+			name:        "same values",
+			files:       map[string]string{valuesFileName: "configs: {}\n"},
+			otherFiles:  map[string]string{valuesFileName: "configs: {}\n"},
+			wantDiffers: false,
+		},
+		{
+			name:        "changed values",
+			files:       map[string]string{valuesFileName: "configs: {}\n"},
+			otherFiles:  map[string]string{valuesFileName: "configs:\n  cm: {}\n"},
+			wantDiffers: true,
+		},
+		{
+			name:        "added override",
+			files:       map[string]string{},
+			otherFiles:  map[string]string{valuesOverrideFileName: ""},
+			wantDiffers: true,
+		},
+		{
+			name:        "other files are ignored",
+			files:       map[string]string{"README.md": "a"},
+			otherFiles:  map[string]string{"README.md": "b"},
+			wantDiffers: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath, otherConfigPath := t.TempDir(), t.TempDir()
+			for dir, files := range map[string]map[string]string{configPath: tt.files, otherConfigPath: tt.otherFiles} {
+				for fileName, contents := range files {
+					if err := os.WriteFile(filepath.Join(dir, fileName), []byte(contents), 0o644); err != nil {
+						t.Fatalf("failed to write %s: %v", fileName, err)
+					}
+				}
+			}
+
+			got, err := ConfigDiffers(configPath, otherConfigPath)
+			if err != nil {
+				t.Fatalf("ConfigDiffers returned error: %v", err)
+			}
+			if got != tt.wantDiffers {
+				t.Fatalf("ConfigDiffers = %v, want %v", got, tt.wantDiffers)
+			}
+		})
+	}
+}
+
+func TestConfigDiffers_MissingConfigDir(t *testing.T) {
+	got, err := ConfigDiffers(filepath.Join(t.TempDir(), "missing"), t.TempDir())
+	if err != nil {
+		t.Fatalf("ConfigDiffers returned error: %v", err)
+	}
+	if got {
+		t.Fatalf("ConfigDiffers = true, want false for a missing and an empty config dir")
+	}
+}
+
 func TestFindValuesFiles_MissingConfigDirUsesEmbeddedOverride(t *testing.T) {
 	a := &ArgoCDInstallation{ConfigPath: filepath.Join(t.TempDir(), "missing")}
 
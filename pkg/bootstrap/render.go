@@ -0,0 +1,128 @@
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/rs/zerolog/log"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// Render renders the bootstrap folders of a branch with Helm or Kustomize and returns the
+// resulting resources. Folders are relative to repoDir. A folder containing a Chart.yaml is
+// rendered with Helm using the default values of the chart, and a folder containing a
+// kustomization is rendered with Kustomize.
+//
+// The FileName of the resources is the folder followed by a '/', so the Applications found
+// in the output point at the folder they are rendered from. Folders missing in the branch
+// are skipped, since a pull request can add or remove them.
+func Render(repoDir string, folders []string, namespace string, branch git.BranchType) ([]fileparsing.Resource, []fileparsing.ParseProblem, error) {
+	var resources []fileparsing.Resource
+	var problems []fileparsing.ParseProblem
+
+	for _, folder := range folders {
+		folder = strings.Trim(path.Clean("/"+folder), "/")
+		dir := filepath.Join(repoDir, folder)
+
+		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+			log.Debug().Str("branch", string(branch)).Msgf("Bootstrap folder '%s' does not exist", folder)
+			continue
+		}
+
+		var output string
+		var err error
+		switch {
+		case fileExists(filepath.Join(dir, "Chart.yaml")):
+			output, err = renderHelm(dir, namespace)
+		case slices.ContainsFunc(kustomizationFileNames, func(name string) bool { return fileExists(filepath.Join(dir, name)) }):
+			output, err = renderKustomize(dir)
+		default:
+			err = errors.New("no Chart.yaml or kustomization found")
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to render bootstrap folder '%s' in %s branch: %w", folder, branch, err)
+		}
+
+		rendered, renderedProblems := fileparsing.ParseYamlString(folder+"/", output, branch)
+		log.Debug().Str("branch", string(branch)).Msgf("Rendered %d resources from bootstrap folder '%s'", len(rendered), folder)
+		resources = append(resources, rendered...)
+		problems = append(problems, renderedProblems...)
+	}
+
+	return resources, problems, nil
+}
+
+// renderHelm renders a local chart like 'helm template' with the default values of the chart
+func renderHelm(dir string, namespace string) (string, error) {
+	chart, err := loader.Load(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	options := chartutil.ReleaseOptions{
+		Name:      chart.Name(),
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}
+	values, err := chartutil.ToRenderValues(chart, map[string]any{}, options, chartutil.DefaultCapabilities)
+	if err != nil {
+		return "", fmt.Errorf("failed to build values: %w", err)
+	}
+
+	templates, err := engine.Render(chart, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	// Sort the templates so the output is stable
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		if strings.HasSuffix(name, "NOTES.txt") {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		if strings.TrimSpace(templates[name]) == "" {
+			continue
+		}
+		fmt.Fprintf(&sb, "---\n# Source: %s\n%s\n", name, templates[name])
+	}
+	return sb.String(), nil
+}
+
+// renderKustomize renders a kustomization like 'kustomize build'
+func renderKustomize(dir string) (string, error) {
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resMap, err := kustomizer.Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to build kustomization: %w", err)
+	}
+	output, err := resMap.AsYaml()
+	if err != nil {
+		return "", fmt.Errorf("failed to convert kustomization output to YAML: %w", err)
+	}
+	return string(output), nil
+}
+
+func fileExists(file string) bool {
+	info, err := os.Stat(file)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,83 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	}
+}
+
+func TestRender(t *testing.T) {
+	repoDir := t.TempDir()
+	writeFiles(t, repoDir, map[string]string{
+		"bootstrap/helm/Chart.yaml": "apiVersion: v2\nname: bootstrap\nversion: 0.1.0\n",
+		"bootstrap/helm/values.yaml": `
+apps:
+  - name: guestbook
+    path: apps/guestbook
+  - name: podinfo
+    path: apps/podinfo
+`,
+		"bootstrap/helm/templates/apps.yaml": `{{- range .Values.apps }}
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: {{ .name }}
+  namespace: {{ $.Release.Namespace }}
+spec:
+  source:
+    repoURL: https://github.com/example/gitops.git
+    path: {{ .path }}
+{{- end }}
+`,
+		"bootstrap/helm/templates/NOTES.txt": "Installed {{ .Chart.Name }}",
+		"bootstrap/kustomize/kustomization.yaml": `
+namespace: argocd
+resources:
+  - app.yaml
+`,
+		"bootstrap/kustomize/app.yaml": `
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cert-manager
+spec: {}
+`,
+	})
+
+	resources, problems, err := Render(repoDir, []string{"bootstrap/helm", "./bootstrap/kustomize/", "bootstrap/removed"}, "argocd", git.Target)
+	require.NoError(t, err)
+	require.Len(t, resources, 3)
+	assert.Empty(t, problems)
+
+	var names []string
+	for _, resource := range resources {
+		names = append(names, resource.Yaml.GetName())
+		assert.Equal(t, "argocd", resource.Yaml.GetNamespace())
+		assert.Equal(t, git.Target, resource.Branch)
+	}
+	assert.Equal(t, []string{"guestbook", "podinfo", "cert-manager"}, names)
+	assert.Equal(t, "bootstrap/helm/", resources[0].FileName)
+	assert.Equal(t, "bootstrap/kustomize/", resources[2].FileName)
+}
+
+func TestRender_FolderWithoutChartOrKustomization(t *testing.T) {
+	repoDir := t.TempDir()
+	writeFiles(t, repoDir, map[string]string{"bootstrap/app.yaml": "kind: Application"})
+
+	_, _, err := Render(repoDir, []string{"bootstrap"}, "argocd", git.Base)
+	assert.ErrorContains(t, err, "no Chart.yaml or kustomization found")
+}
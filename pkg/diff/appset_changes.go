@@ -0,0 +1,146 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/utils"
+)
+
+const appSetChangesJSONFile = "appset-changes.json"
+
+// GeneratedAppDiff is the diff of an Application generated in both branches
+type GeneratedAppDiff struct {
+	Name string `json:"name"`
+	Diff string `json:"diff"`
+}
+
+// AppSetChangeEntry lists the Applications an ApplicationSet generates differently in the target branch
+type AppSetChangeEntry struct {
+	Name     string             `json:"name"`
+	File     string             `json:"file"`
+	Added    []string           `json:"added"`
+	Removed  []string           `json:"removed"`
+	Modified []GeneratedAppDiff `json:"modified"`
+}
+
+// AppSetChanges are the ApplicationSets generating different Applications in the two branches
+type AppSetChanges []AppSetChangeEntry
+
+// BuildAppSetChanges diffs the modified Applications of each ApplicationSet
+func BuildAppSetChanges(changes []argoapplication.AppSetChange, contextLines uint) (AppSetChanges, error) {
+	entries := make(AppSetChanges, 0, len(changes))
+	for _, change := range changes {
+		entry := AppSetChangeEntry{
+			Name:     change.Name,
+			File:     change.FileName,
+			Added:    append([]string{}, change.Added...),
+			Removed:  append([]string{}, change.Removed...),
+			Modified: []GeneratedAppDiff{},
+		}
+		for _, modified := range change.Modified {
+			pair := matching.ResourcePair{Base: modified.Base, Target: modified.Target}
+			result, err := pair.Diff(contextLines)
+			if err != nil {
+				return nil, fmt.Errorf("failed to diff Application '%s' generated by ApplicationSet '%s': %w", modified.Name, change.Name, err)
+			}
+			entry.Modified = append(entry.Modified, GeneratedAppDiff{Name: modified.Name, Diff: result.Content})
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (e AppSetChangeEntry) title() string {
+	return fmt.Sprintf("AppSet: %s (%s) [+%d, -%d, ~%d]", e.Name, e.File, len(e.Added), len(e.Removed), len(e.Modified))
+}
+
+// Markdown returns a collapsible section per ApplicationSet, or an empty string if there are no changes
+func (c AppSetChanges) Markdown() string {
+	markdown, _ := c.markdownWithin(math.MaxInt)
+	return markdown
+}
+
+// markdownWithin returns the Markdown of the changes that fit in maxSize, and whether it
+// was truncated
+func (c AppSetChanges) markdownWithin(maxSize int) (string, bool) {
+	if len(c) == 0 {
+		return "", false
+	}
+
+	blocks := make([]markdownDetails, len(c))
+	for i, entry := range c {
+		var intro strings.Builder
+		for _, name := range entry.Added {
+			fmt.Fprintf(&intro, "- ➕ `%s` added\n", name)
+		}
+		for _, name := range entry.Removed {
+			fmt.Fprintf(&intro, "- ➖ `%s` removed\n", name)
+		}
+		var diffs []markdownDiff
+		for _, modified := range entry.Modified {
+			fmt.Fprintf(&intro, "- ✏️ `%s` modified\n", modified.Name)
+			diffs = append(diffs, markdownDiff{header: fmt.Sprintf("\n#### %s\n", modified.Name), content: modified.Diff})
+		}
+		blocks[i] = markdownDetails{summary: entry.title(), intro: intro.String(), diffs: diffs}
+	}
+	return buildMarkdownDetails("### ApplicationSet changes\n\n", blocks, maxSize)
+}
+
+// HTML returns a collapsible section per ApplicationSet, or an empty string if there are no changes
+func (c AppSetChanges) HTML() string {
+	if len(c) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>ApplicationSet changes</h3>\n")
+	for _, entry := range c {
+		fmt.Fprintf(&sb, "<details>\n<summary>\n%s\n</summary>\n<ul>\n", html.EscapeString(entry.title()))
+		for _, name := range entry.Added {
+			fmt.Fprintf(&sb, "<li>➕ <code>%s</code> added</li>\n", html.EscapeString(name))
+		}
+		for _, name := range entry.Removed {
+			fmt.Fprintf(&sb, "<li>➖ <code>%s</code> removed</li>\n", html.EscapeString(name))
+		}
+		for _, modified := range entry.Modified {
+			fmt.Fprintf(&sb, "<li>✏️ <code>%s</code> modified</li>\n", html.EscapeString(modified.Name))
+		}
+		sb.WriteString("</ul>\n")
+		for _, modified := range entry.Modified {
+			fmt.Fprintf(&sb, "<h4 class=\"resource_header\">%s</h4>\n", html.EscapeString(modified.Name))
+			writeHTMLDiffTable(&sb, modified.Diff)
+		}
+		sb.WriteString("</details>\n")
+	}
+	return sb.String()
+}
+
+// JSON returns the changes as indented JSON
+func (c AppSetChanges) JSON() (string, error) {
+	if c == nil {
+		c = AppSetChanges{}
+	}
+	bytes, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// WriteToFolder writes the changes to appset-changes.json in the output folder
+func (c AppSetChanges) WriteToFolder(outputFolder string) error {
+	content, err := c.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal ApplicationSet changes: %w", err)
+	}
+	if err := utils.WriteFile(fmt.Sprintf("%s/%s", outputFolder, appSetChangesJSONFile), content); err != nil {
+		return fmt.Errorf("failed to write ApplicationSet changes: %w", err)
+	}
+	return nil
+}
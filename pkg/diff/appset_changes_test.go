@@ -0,0 +1,81 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBuildAppSetChanges(t *testing.T) {
+	app := func(path string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"metadata": map[string]any{"name": "ingress"},
+			"spec":     map[string]any{"source": map[string]any{"path": path}},
+		}}
+	}
+
+	changes, err := BuildAppSetChanges([]argoapplication.AppSetChange{
+		{
+			Name:     "cluster-addons",
+			FileName: "appsets/cluster-addons.yaml",
+			Added:    []string{"new-addon"},
+			Modified: []argoapplication.GeneratedApplicationChange{
+				{Name: "ingress", Base: app("addons/ingress"), Target: app("addons/ingress-v2")},
+			},
+		},
+	}, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(changes) != 1 || len(changes[0].Modified) != 1 {
+		t.Fatalf("expected a single modified application, got %+v", changes)
+	}
+	if !strings.Contains(changes[0].Modified[0].Diff, "+    path: addons/ingress-v2") {
+		t.Errorf("expected diff of the source path, got:\n%s", changes[0].Modified[0].Diff)
+	}
+
+	markdown := changes.Markdown()
+	for _, expected := range []string{
+		"### ApplicationSet changes",
+		"<summary>AppSet: cluster-addons (appsets/cluster-addons.yaml) [+1, -0, ~1]</summary>",
+		"- ➕ `new-addon` added",
+		"- ✏️ `ingress` modified",
+		"#### ingress\n```diff\n",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	html := changes.HTML()
+	if !strings.Contains(html, "<li>➕ <code>new-addon</code> added</li>") {
+		t.Errorf("expected added application in HTML, got:\n%s", html)
+	}
+
+	json, err := changes.JSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{`"name": "cluster-addons"`, `"removed": []`, `"added": [`} {
+		if !strings.Contains(json, expected) {
+			t.Errorf("expected JSON to contain %q, got:\n%s", expected, json)
+		}
+	}
+}
+
+func TestAppSetChanges_Empty(t *testing.T) {
+	var changes AppSetChanges
+	if changes.Markdown() != "" || changes.HTML() != "" {
+		t.Errorf("expected no output without changes")
+	}
+	json, err := changes.JSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if json != "[]" {
+		t.Errorf("expected empty JSON list, got %s", json)
+	}
+}
@@ -0,0 +1,245 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
+)
+
+// DefaultGuardedKinds are the kinds whose deletion is always reported, since deleting them
+// loses data or takes other resources down with them
+var DefaultGuardedKinds = []string{
+	"CustomResourceDefinition",
+	"Namespace",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"StatefulSet",
+}
+
+// resourcesFinalizers make Argo CD delete the resources of an Application when the Application is deleted
+var resourcesFinalizers = map[string]bool{
+	"resources-finalizer.argocd.argoproj.io":            true,
+	"resources-finalizer.argocd.argoproj.io/background": true,
+	"resources-finalizer.argocd.argoproj.io/foreground": true,
+}
+
+// DeletionOutcome is what Argo CD does with a resource that is no longer rendered
+type DeletionOutcome int
+
+const (
+	// OutcomeOrphaned means the resource stays in the cluster and is not deleted by a sync
+	OutcomeOrphaned DeletionOutcome = iota
+	// OutcomePrunedOnManualSync means the resource stays tracked and OutOfSync until a manual sync with pruning deletes it
+	OutcomePrunedOnManualSync
+	// OutcomeDeleted means Argo CD deletes the resource
+	OutcomeDeleted
+)
+
+// DeletedResource is a resource of a guarded kind that is no longer rendered by any
+// Application in the target branch
+type DeletedResource struct {
+	App        string
+	Cluster    string
+	Group      string
+	Kind       string
+	Namespace  string
+	Name       string
+	AppDeleted bool            // the Application itself is removed in the target branch
+	Outcome    DeletionOutcome // what Argo CD does with the resource
+	Reason     string          // why the resource is deleted or kept
+}
+
+// DeletedResources are the removed resources of guarded kinds
+type DeletedResources []DeletedResource
+
+// FindDeletedResources finds the resources of the guarded kinds that are rendered in the base
+// branch but not by any Application in the target branch, so resources moved to another
+// Application are not reported. It decides whether Argo CD deletes or orphans each resource
+// from its sync options, the resources finalizer of a deleted Application and the automated
+// prune setting of a remaining Application. Resources are located like in FindSharedResources.
+func FindDeletedResources(
+	baseApps []argoapplication.ArgoResource,
+	targetApps []argoapplication.ArgoResource,
+	baseManifests []extract.ExtractedApp,
+	targetManifests []extract.ExtractedApp,
+	ignoreResourceRules []resource_filter.IgnoreResourceRule,
+	guardedKinds []string,
+	scopes ResourceScopes,
+) DeletedResources {
+	remaining := resourcesByApps(targetApps, targetManifests, ignoreResourceRules, scopes)
+
+	baseAppsById := make(map[string]*unstructured.Unstructured, len(baseApps))
+	for _, app := range baseApps {
+		baseAppsById[app.Id] = app.Unpatched()
+	}
+	targetAppsById := make(map[string]*unstructured.Unstructured, len(targetApps))
+	for _, app := range targetApps {
+		targetAppsById[app.Id] = app.Unpatched()
+	}
+
+	var deleted DeletedResources
+	for _, extracted := range baseManifests {
+		baseApp, ok := baseAppsById[extracted.Id]
+		if !ok {
+			continue
+		}
+		targetApp, appKept := targetAppsById[extracted.Id]
+		destination := destinationOf(baseApp)
+
+		for i := range extracted.Manifests {
+			manifest := &extracted.Manifests[i]
+			if !slices.Contains(guardedKinds, manifest.GetKind()) {
+				continue
+			}
+			if resource_filter.MatchesAnyIgnoreRule(manifest, ignoreResourceRules) {
+				continue
+			}
+			key := resourceKeyOf(manifest, destination, scopes)
+			if _, found := remaining[key]; found {
+				continue
+			}
+
+			resource := DeletedResource{
+				App:        extracted.Name,
+				Cluster:    key.cluster,
+				Group:      key.group,
+				Kind:       key.kind,
+				Namespace:  key.namespace,
+				Name:       key.name,
+				AppDeleted: !appKept,
+			}
+			if appKept {
+				resource.Outcome, resource.Reason = pruneOutcome(manifest, targetApp)
+			} else {
+				resource.Outcome, resource.Reason = cascadeOutcome(manifest, baseApp)
+			}
+			deleted = append(deleted, resource)
+		}
+	}
+
+	slices.SortFunc(deleted, func(a, b DeletedResource) int {
+		return cmp.Or(
+			cmp.Compare(b.Outcome, a.Outcome),
+			cmp.Compare(a.App, b.App),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return deleted
+}
+
+// pruneOutcome decides if Argo CD prunes a resource removed from an Application that still exists
+func pruneOutcome(manifest *unstructured.Unstructured, app *unstructured.Unstructured) (DeletionOutcome, string) {
+	if argoapplication.ParseSyncBehaviour(manifest, nil).NoPrune {
+		return OutcomeOrphaned, "the resource has the sync option Prune=false"
+	}
+	prune, _, _ := unstructured.NestedBool(app.Object, "spec", "syncPolicy", "automated", "prune")
+	if !prune {
+		return OutcomePrunedOnManualSync, "automated prune is disabled, so the Application stays OutOfSync until a manual sync with pruning"
+	}
+	return OutcomeDeleted, "automated prune is enabled"
+}
+
+// cascadeOutcome decides if Argo CD deletes a resource of a deleted Application
+func cascadeOutcome(manifest *unstructured.Unstructured, app *unstructured.Unstructured) (DeletionOutcome, string) {
+	if !slices.ContainsFunc(app.GetFinalizers(), func(f string) bool { return resourcesFinalizers[f] }) {
+		return OutcomeOrphaned, "the Application is deleted without the resources finalizer"
+	}
+	if argoapplication.ParseSyncBehaviour(manifest, nil).NoDelete {
+		return OutcomeOrphaned, "the resource has the sync option Delete=false"
+	}
+	return OutcomeDeleted, "the Application is deleted with the resources finalizer"
+}
+
+// DeletedCount returns the number of resources of the given kinds that Argo CD deletes,
+// including the resources deleted by the next manual sync with pruning
+func (d DeletedResources) DeletedCount(kinds []string) int {
+	count := 0
+	for _, r := range d {
+		if r.Outcome != OutcomeOrphaned && slices.Contains(kinds, r.Kind) {
+			count++
+		}
+	}
+	return count
+}
+
+func (r DeletedResource) resourceName() string {
+	kind := r.Kind
+	if r.Group != "" {
+		kind = fmt.Sprintf("%s.%s", r.Kind, r.Group)
+	}
+	return fmt.Sprintf("%s %s", kind, qualifiedResourceName(r.Namespace, r.Name))
+}
+
+func (r DeletedResource) appName() string {
+	if r.AppDeleted {
+		return r.App + " (deleted)"
+	}
+	return r.App
+}
+
+func (r DeletedResource) outcome() string {
+	switch r.Outcome {
+	case OutcomeDeleted:
+		return "🗑️ will be deleted by Argo CD"
+	case OutcomePrunedOnManualSync:
+		return "🗑️ deleted by a manual sync with pruning"
+	default:
+		return "orphaned"
+	}
+}
+
+const deletedResourcesDescription = "The following resources are no longer rendered by any Application. Resources that are orphaned stay in the cluster, but are no longer deleted by a sync."
+
+// Markdown returns the deleted resources as a markdown table, or an empty string if there are none
+func (d DeletedResources) Markdown() string {
+	if len(d) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### ⚠️ Deleted stateful resources\n\n")
+	sb.WriteString(deletedResourcesDescription + "\n\n")
+	sb.WriteString("| Resource | Application | Outcome | Reason |\n")
+	sb.WriteString("| -------- | ----------- | ------- | ------ |\n")
+	for _, r := range d {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n",
+			escapeMarkdownTableCell(r.resourceName()),
+			escapeMarkdownTableCell(r.appName()),
+			r.outcome(),
+			escapeMarkdownTableCell(r.Reason),
+		)
+	}
+	return sb.String() + "\n"
+}
+
+// HTML returns the deleted resources as an HTML table, or an empty string if there are none
+func (d DeletedResources) HTML() string {
+	if len(d) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>⚠️ Deleted stateful resources</h3>\n")
+	sb.WriteString("<p>" + html.EscapeString(deletedResourcesDescription) + "</p>\n")
+	sb.WriteString("<table>\n<tr><th>Resource</th><th>Application</th><th>Outcome</th><th>Reason</th></tr>\n")
+	for _, r := range d {
+		fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(r.resourceName()),
+			html.EscapeString(r.appName()),
+			r.outcome(),
+			html.EscapeString(r.Reason),
+		)
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
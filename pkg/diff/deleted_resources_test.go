@@ -0,0 +1,243 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/repository"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func deletedResourceTestApp(name string, prune bool, finalizer bool) argoapplication.ArgoResource {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": name},
+		"spec": map[string]any{
+			"destination": map[string]any{"server": inClusterServer, "namespace": "default"},
+			"syncPolicy":  map[string]any{"automated": map[string]any{"prune": prune}},
+		},
+	}}
+	if finalizer {
+		obj.SetFinalizers([]string{"resources-finalizer.argocd.argoproj.io"})
+	}
+	return *argoapplication.NewArgoResource(obj, argoapplication.Application, name, name, "apps/"+name+".yaml", git.Base)
+}
+
+const (
+	deletedPVC          = `{apiVersion: v1, kind: PersistentVolumeClaim, metadata: {name: data}}`
+	deletedKeptPVC      = `{apiVersion: v1, kind: PersistentVolumeClaim, metadata: {name: data, annotations: {argocd.argoproj.io/sync-options: "ServerSideApply=true,Prune=false"}}}`
+	deletedUndeletedPVC = `{apiVersion: v1, kind: PersistentVolumeClaim, metadata: {name: data, annotations: {argocd.argoproj.io/sync-options: Delete=false}}}`
+	deletedNamespace    = `{apiVersion: v1, kind: Namespace, metadata: {name: team}}`
+	deletedDeployment   = `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}}`
+)
+
+func findTestDeletedResources(t *testing.T, baseApp, targetApp *argoapplication.ArgoResource, baseManifests []string, targetManifests []string) DeletedResources {
+	t.Helper()
+	baseApps := []argoapplication.ArgoResource{*baseApp}
+	base := []extract.ExtractedApp{sharedResourceTestManifests(t, baseApp.Name, baseManifests...)}
+	var targetApps []argoapplication.ArgoResource
+	var target []extract.ExtractedApp
+	if targetApp != nil {
+		targetApps = append(targetApps, *targetApp)
+		target = append(target, sharedResourceTestManifests(t, targetApp.Name, targetManifests...))
+	}
+	return FindDeletedResources(baseApps, targetApps, base, target, nil, DefaultGuardedKinds, nil)
+}
+
+func TestFindDeletedResources_RemovedFromApp(t *testing.T) {
+	tests := []struct {
+		name     string
+		prune    bool
+		manifest string
+		outcome  DeletionOutcome
+		reason   string
+	}{
+		{name: "automated prune", prune: true, manifest: deletedPVC, outcome: OutcomeDeleted, reason: "automated prune is enabled"},
+		{name: "no automated prune", prune: false, manifest: deletedPVC, outcome: OutcomePrunedOnManualSync, reason: "automated prune is disabled, so the Application stays OutOfSync until a manual sync with pruning"},
+		{name: "Prune=false", prune: true, manifest: deletedKeptPVC, outcome: OutcomeOrphaned, reason: "the resource has the sync option Prune=false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := deletedResourceTestApp("storage", tt.prune, false)
+			deleted := findTestDeletedResources(t, &app, &app, []string{tt.manifest, deletedDeployment}, []string{deletedDeployment})
+
+			if len(deleted) != 1 {
+				t.Fatalf("expected 1 deleted resource, got %+v", deleted)
+			}
+			r := deleted[0]
+			if r.Kind != "PersistentVolumeClaim" || r.Namespace != "default" || r.Name != "data" || r.AppDeleted {
+				t.Errorf("unexpected resource: %+v", r)
+			}
+			if r.Outcome != tt.outcome || r.Reason != tt.reason {
+				t.Errorf("expected outcome %d (%s), got %d (%s)", tt.outcome, tt.reason, r.Outcome, r.Reason)
+			}
+		})
+	}
+}
+
+func TestFindDeletedResources_AppDeleted(t *testing.T) {
+	tests := []struct {
+		name      string
+		finalizer bool
+		manifest  string
+		outcome   DeletionOutcome
+		reason    string
+	}{
+		{name: "resources finalizer", finalizer: true, manifest: deletedPVC, outcome: OutcomeDeleted, reason: "the Application is deleted with the resources finalizer"},
+		{name: "no finalizer", finalizer: false, manifest: deletedPVC, outcome: OutcomeOrphaned, reason: "the Application is deleted without the resources finalizer"},
+		{name: "Delete=false", finalizer: true, manifest: deletedUndeletedPVC, outcome: OutcomeOrphaned, reason: "the resource has the sync option Delete=false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := deletedResourceTestApp("storage", false, tt.finalizer)
+			deleted := findTestDeletedResources(t, &app, nil, []string{tt.manifest}, nil)
+
+			if len(deleted) != 1 {
+				t.Fatalf("expected 1 deleted resource, got %+v", deleted)
+			}
+			if !deleted[0].AppDeleted {
+				t.Errorf("expected the Application to be deleted")
+			}
+			if deleted[0].Outcome != tt.outcome || deleted[0].Reason != tt.reason {
+				t.Errorf("expected outcome %d (%s), got %d (%s)", tt.outcome, tt.reason, deleted[0].Outcome, deleted[0].Reason)
+			}
+		})
+	}
+}
+
+func TestFindDeletedResources_GeneratedByApplicationSet(t *testing.T) {
+	var node unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: storage
+spec:
+  generators:
+    - list:
+        elements:
+          - name: storage
+  template:
+    metadata:
+      name: '{{.name}}'
+    spec:
+      project: storage
+      source:
+        repoURL: https://github.com/org/repo.git
+        path: storage
+      destination:
+        server: https://kubernetes.default.svc
+        namespace: default
+      syncPolicy:
+        automated:
+          prune: true`), &node); err != nil {
+		t.Fatal(err)
+	}
+	selector, err := repository.NewSelector("org/repo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := git.NewBranch("feature", git.Target)
+
+	// Patching removes the syncPolicy of the template, so it is not in the generated Application
+	appSet, err := argoapplication.PatchApplication("argocd", *argoapplication.NewArgoResource(&node, argoapplication.ApplicationSet, "storage", "storage", "appsets/storage.yaml", git.Target), branch, *selector, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, _, _ := unstructured.NestedMap(appSet.Yaml.Object, "spec", "template", "spec")
+	generated := argoapplication.NewArgoResource(&unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Application",
+		"metadata":   map[string]any{"name": "storage"},
+		"spec":       spec,
+	}}, argoapplication.Application, "storage", "storage", appSet.FileName, git.Target)
+	generated.SetOriginalFromAppSet(*appSet)
+	app, err := argoapplication.PatchApplication("argocd", *generated, branch, *selector, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := findTestDeletedResources(t, app, app, []string{deletedPVC, deletedDeployment}, []string{deletedDeployment})
+	if len(deleted) != 1 {
+		t.Fatalf("expected 1 deleted resource, got %+v", deleted)
+	}
+	if deleted[0].Outcome != OutcomeDeleted || deleted[0].Reason != "automated prune is enabled" {
+		t.Errorf("expected the automated prune of the ApplicationSet template to be used, got outcome %d (%s)", deleted[0].Outcome, deleted[0].Reason)
+	}
+}
+
+func TestFindDeletedResources_MovedToAnotherApp(t *testing.T) {
+	oldApp := deletedResourceTestApp("old", true, true)
+	newApp := deletedResourceTestApp("new", true, true)
+
+	deleted := findTestDeletedResources(t, &oldApp, &newApp, []string{deletedNamespace, deletedPVC}, []string{deletedNamespace, deletedPVC})
+	if len(deleted) != 0 {
+		t.Errorf("expected resources rendered by another Application not to be reported, got %+v", deleted)
+	}
+}
+
+func TestFindDeletedResources_IgnoredAndUnguardedKinds(t *testing.T) {
+	app := deletedResourceTestApp("storage", true, true)
+	base := []extract.ExtractedApp{sharedResourceTestManifests(t, "storage", deletedNamespace, deletedDeployment)}
+	rules := []resource_filter.IgnoreResourceRule{{Group: "", Kind: "Namespace", Name: "*"}}
+
+	deleted := FindDeletedResources([]argoapplication.ArgoResource{app}, nil, base, nil, rules, DefaultGuardedKinds, nil)
+	if len(deleted) != 0 {
+		t.Errorf("expected ignored and unguarded kinds to be skipped, got %+v", deleted)
+	}
+
+	deleted = FindDeletedResources([]argoapplication.ArgoResource{app}, nil, base, nil, nil, []string{"Deployment"}, nil)
+	if len(deleted) != 1 || deleted[0].Kind != "Deployment" {
+		t.Errorf("expected the Deployment to be reported with a custom kind list, got %+v", deleted)
+	}
+}
+
+func TestDeletedResources_DeletedCount(t *testing.T) {
+	deleted := DeletedResources{
+		{Kind: "Namespace", Name: "team", Outcome: OutcomeDeleted},
+		{Kind: "PersistentVolumeClaim", Name: "data", Outcome: OutcomeDeleted},
+		{Kind: "PersistentVolumeClaim", Name: "logs", Outcome: OutcomePrunedOnManualSync},
+		{Kind: "PersistentVolumeClaim", Name: "cache", Outcome: OutcomeOrphaned},
+	}
+
+	if count := deleted.DeletedCount([]string{"PersistentVolumeClaim"}); count != 2 {
+		t.Errorf("expected 2 PersistentVolumeClaims deleted by a sync, got %d", count)
+	}
+	if count := deleted.DeletedCount(nil); count != 0 {
+		t.Errorf("expected no count without kinds, got %d", count)
+	}
+}
+
+func TestDeletedResources_Output(t *testing.T) {
+	deleted := DeletedResources{
+		{App: "storage", Kind: "PersistentVolumeClaim", Namespace: "default", Name: "data", AppDeleted: true, Outcome: OutcomeDeleted, Reason: "the Application is deleted with the resources finalizer"},
+		{App: "storage", Kind: "PersistentVolumeClaim", Namespace: "default", Name: "logs", Outcome: OutcomePrunedOnManualSync, Reason: "automated prune is disabled"},
+		{App: "infra", Kind: "Namespace", Name: "team", Reason: "the resource has the sync option Prune=false"},
+	}
+
+	markdown := deleted.Markdown()
+	for _, expected := range []string{
+		"### ⚠️ Deleted stateful resources",
+		"| PersistentVolumeClaim default/data | storage (deleted) | 🗑️ will be deleted by Argo CD | the Application is deleted with the resources finalizer |",
+		"| PersistentVolumeClaim default/logs | storage | 🗑️ deleted by a manual sync with pruning | automated prune is disabled |",
+		"| Namespace team | infra | orphaned | the resource has the sync option Prune=false |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	if !strings.Contains(deleted.HTML(), "<tr><td>Namespace team</td><td>infra</td><td>orphaned</td><td>the resource has the sync option Prune=false</td></tr>") {
+		t.Errorf("unexpected HTML:\n%s", deleted.HTML())
+	}
+
+	if DeletedResources(nil).Markdown() != "" || DeletedResources(nil).HTML() != "" {
+		t.Errorf("expected no output without deleted resources")
+	}
+}
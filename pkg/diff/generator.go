@@ -7,52 +7,97 @@ import (
 
 	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
 	gitt "github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/immutable_fields"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/utils"
 	"github.com/rs/zerolog/log"
 )
 
+// PreviewInput is what the preview is generated from
+type PreviewInput struct {
+	Title           string
+	OutputFolder    string
+	BaseBranch      *gitt.Branch
+	TargetBranch    *gitt.Branch
+	BaseManifests   []extract.ExtractedApp
+	TargetManifests []extract.ExtractedApp
+
+	// Diff options
+	DiffIgnoreRegex         *string
+	LineCount               uint
+	MaxCharCount            uint
+	HideDeletedAppDiff      bool
+	IgnoreResourceRules     []resource_filter.IgnoreResourceRule
+	ImmutableFields         []immutable_fields.ImmutableField
+	ExcludeIgnoreExtraneous bool
+	ShowPlan                bool
+	ShowSyncPlan            bool
+	ArgocdUIURL             string
+
+	// Sections of the output
+	StatsInfo              StatsInfo
+	SelectionInfo          SelectionInfo
+	ManifestProblems       ManifestProblems
+	ProjectViolations      ProjectViolations
+	SharedResources        SharedResources
+	DeletedResources       DeletedResources
+	SpecChanges            SpecChanges
+	AppSetChanges          AppSetChanges
+	IgnoredFields          IgnoredFields
+	NonDeterministicFields NonDeterministicFields
+	RenderCrossCheck       RenderMethodCrossCheck
+}
+
 // GeneratePreview generates a diff using similarity-based matching instead of ID-based matching.
 // This correctly handles cases where apps or resources are renamed.
-func GeneratePreview(
-	title string,
-	outputFolder string,
-	baseBranch *gitt.Branch,
-	targetBranch *gitt.Branch,
-	baseManifests []extract.ExtractedApp,
-	targetManifests []extract.ExtractedApp,
-	diffIgnoreRegex *string,
-	lineCount uint,
-	maxCharCount uint,
-	hideDeletedAppDiff bool,
-	statsInfo StatsInfo,
-	selectionInfo SelectionInfo,
-	argocdUIURL string,
-	ignoreResourceRules []resource_filter.IgnoreResourceRule,
-) (time.Duration, error) {
+func GeneratePreview(input PreviewInput) (time.Duration, error) {
 	startTime := time.Now()
-	maxDiffMessageCharCount := maxCharCount
+	maxDiffMessageCharCount := input.MaxCharCount
 	if maxDiffMessageCharCount <= 0 {
 		maxDiffMessageCharCount = 65536
 	}
 
 	log.Info().Msgf("🔮 Generating diff between %s and %s",
-		baseBranch.Name, targetBranch.Name)
+		input.BaseBranch.Name, input.TargetBranch.Name)
 
 	// Set default context line count if not provided
+	lineCount := input.LineCount
 	if lineCount <= 0 {
 		lineCount = 3
 	}
 
 	// Generate diffs using the matching package
-	appDiffs, err := matching.GenerateAppDiffs(baseManifests, targetManifests, lineCount, diffIgnoreRegex, ignoreResourceRules)
+	appDiffs, err := matching.GenerateAppDiffs(input.BaseManifests, input.TargetManifests, matching.DiffOptions{
+		ContextLines:            lineCount,
+		IgnorePattern:           input.DiffIgnoreRegex,
+		IgnoreResourceRules:     input.IgnoreResourceRules,
+		ImmutableFields:         input.ImmutableFields,
+		ExcludeIgnoreExtraneous: input.ExcludeIgnoreExtraneous,
+		SyncSteps:               input.ShowSyncPlan,
+	})
 	if err != nil {
 		return time.Since(startTime), fmt.Errorf("failed to generate matching diffs: %w", err)
 	}
 
+	// The plan is built from the resource changes, which are kept when diffs are hidden
+	var plan Plan
+	if input.ShowPlan {
+		plan = BuildPlan(appDiffs)
+		log.Info().Msgf("📋 %s", plan.String())
+		if err := plan.WriteToFolder(input.OutputFolder); err != nil {
+			return time.Since(startTime), err
+		}
+	}
+
+	// Sync phases and waves of the changed apps with hooks or sync waves
+	var syncPlan SyncPlan
+	if input.ShowSyncPlan {
+		syncPlan = BuildSyncPlan(appDiffs)
+	}
+
 	// Handle hideDeletedAppDiff option
-	if hideDeletedAppDiff {
+	if input.HideDeletedAppDiff {
 		for i := range appDiffs {
 			if appDiffs[i].Action == matching.ActionDeleted {
 				appDiffs[i].Resources = nil
@@ -67,19 +112,30 @@ func GeneratePreview(
 	summary := buildSummary(appDiffs)
 
 	// Convert to markdown/HTML sections
-	markdownSections, htmlSections := buildMatchingSections(appDiffs, argocdUIURL)
+	markdownSections, htmlSections := buildMatchingSections(appDiffs, input.ArgocdUIURL)
 
 	// Markdown
 	log.Debug().Msg("Creating markdown output")
 	markdownOutput := MarkdownOutput{
-		title:         title,
-		summary:       summary,
-		sections:      markdownSections,
-		statsInfo:     statsInfo,
-		selectionInfo: selectionInfo,
+		title:             input.Title,
+		summary:           summary,
+		plan:              plan,
+		sections:          markdownSections,
+		statsInfo:         input.StatsInfo,
+		selectionInfo:     input.SelectionInfo,
+		manifestProblems:  input.ManifestProblems,
+		projectViolations: input.ProjectViolations,
+		sharedResources:   input.SharedResources,
+		deletedResources:  input.DeletedResources,
+		syncPlan:          syncPlan,
+		specChanges:       input.SpecChanges,
+		appSetChanges:     input.AppSetChanges,
+		ignoredFields:     input.IgnoredFields,
+		nonDeterministic:  input.NonDeterministicFields,
+		renderCrossCheck:  input.RenderCrossCheck,
 	}
 	markdown := markdownOutput.printDiff(maxDiffMessageCharCount)
-	markdownPath := fmt.Sprintf("%s/diff.md", outputFolder)
+	markdownPath := fmt.Sprintf("%s/diff.md", input.OutputFolder)
 	log.Debug().Msgf("Writing markdown output to %s", markdownPath)
 	if err := utils.WriteFile(markdownPath, markdown); err != nil {
 		return time.Since(startTime), fmt.Errorf("failed to write markdown: %w", err)
@@ -89,14 +145,25 @@ func GeneratePreview(
 	// HTML
 	log.Debug().Msg("Creating html output")
 	htmlOutput := HTMLOutput{
-		title:         title,
-		summary:       summary,
-		sections:      htmlSections,
-		statsInfo:     statsInfo,
-		selectionInfo: selectionInfo,
+		title:             input.Title,
+		summary:           summary,
+		plan:              plan,
+		sections:          htmlSections,
+		statsInfo:         input.StatsInfo,
+		selectionInfo:     input.SelectionInfo,
+		manifestProblems:  input.ManifestProblems,
+		projectViolations: input.ProjectViolations,
+		sharedResources:   input.SharedResources,
+		deletedResources:  input.DeletedResources,
+		syncPlan:          syncPlan,
+		specChanges:       input.SpecChanges,
+		appSetChanges:     input.AppSetChanges,
+		ignoredFields:     input.IgnoredFields,
+		nonDeterministic:  input.NonDeterministicFields,
+		renderCrossCheck:  input.RenderCrossCheck,
 	}
 	htmlDiff := htmlOutput.printDiff()
-	htmlPath := fmt.Sprintf("%s/diff.html", outputFolder)
+	htmlPath := fmt.Sprintf("%s/diff.html", input.OutputFolder)
 	log.Debug().Msgf("Writing html output to %s", htmlPath)
 	if err := utils.WriteFile(htmlPath, htmlDiff); err != nil {
 		return time.Since(startTime), fmt.Errorf("failed to write html: %w", err)
@@ -135,7 +202,7 @@ func buildSummary(diffs []matching.AppDiff) string {
 		fmt.Fprintf(&summaryBuilder, "Added (%d):\n", addedCount)
 		for _, d := range diffs {
 			if d.Action == matching.ActionAdded {
-				fmt.Fprintf(&summaryBuilder, "+ %s%s\n", d.PrettyName(), d.ChangeStats())
+				fmt.Fprintf(&summaryBuilder, "+ %s%s%s\n", d.PrettyName(), d.ChangeStats(), immutableFieldSummary(d))
 			}
 		}
 	}
@@ -148,7 +215,7 @@ func buildSummary(diffs []matching.AppDiff) string {
 		fmt.Fprintf(&summaryBuilder, "Deleted (%d):\n", deletedCount)
 		for _, d := range diffs {
 			if d.Action == matching.ActionDeleted {
-				fmt.Fprintf(&summaryBuilder, "- %s%s\n", d.PrettyName(), d.ChangeStats())
+				fmt.Fprintf(&summaryBuilder, "- %s%s%s\n", d.PrettyName(), d.ChangeStats(), immutableFieldSummary(d))
 			}
 		}
 	}
@@ -161,7 +228,7 @@ func buildSummary(diffs []matching.AppDiff) string {
 		fmt.Fprintf(&summaryBuilder, "Modified (%d):\n", modifiedCount)
 		for _, d := range diffs {
 			if d.Action == matching.ActionModified {
-				fmt.Fprintf(&summaryBuilder, "± %s%s\n", d.PrettyName(), d.ChangeStats())
+				fmt.Fprintf(&summaryBuilder, "± %s%s%s\n", d.PrettyName(), d.ChangeStats(), immutableFieldSummary(d))
 			}
 		}
 	}
@@ -169,6 +236,14 @@ func buildSummary(diffs []matching.AppDiff) string {
 	return summaryBuilder.String()
 }
 
+// immutableFieldSummary marks apps where a field that can not be updated in place changed
+func immutableFieldSummary(d matching.AppDiff) string {
+	if d.ImmutableFieldChanged() {
+		return " " + matching.ImmutableFieldMarker
+	}
+	return ""
+}
+
 // buildMatchingSections converts AppDiffs to markdown and HTML sections
 func buildMatchingSections(diffs []matching.AppDiff, argocdUIURL string) ([]MarkdownSection, []HTMLSection) {
 	markdownSections := make([]MarkdownSection, 0, len(diffs))
@@ -184,6 +259,8 @@ func buildMatchingSections(diffs []matching.AppDiff, argocdUIURL string) ([]Mark
 				Header:    r.Header(),
 				Content:   r.Content,
 				IsSkipped: r.IsSkipped,
+				Note:      resourceNote(r),
+				Badges:    r.SyncBehaviour.Badges(),
 			}
 		}
 
@@ -225,3 +302,22 @@ func buildAppURLFromDiff(d matching.AppDiff, argocdUIURL string) string {
 	baseURL := strings.TrimRight(argocdUIURL, "/")
 	return fmt.Sprintf("%s/applications/%s", baseURL, appName)
 }
+
+// resourceNote explains how Argo CD handles a resource moved between applications. The
+// resource is only kept if the new application adopts it before the old one prunes it.
+// A resource renamed along with the move is a new resource, so nothing is adopted.
+func resourceNote(r matching.ResourceDiff) string {
+	switch {
+	case r.MovedFrom != "" && r.Action == matching.ResourceReplaced:
+		return fmt.Sprintf("⚠️ Moved from '%s' and replaced (%s). This application creates the new resource and '%s' deletes the old one when it prunes.",
+			r.MovedFrom, r.ReplaceReason, r.MovedFrom)
+	case r.MovedFrom != "":
+		return fmt.Sprintf("⚠️ Moved from '%s'. If '%s' prunes the resource before this application adopts it, the resource is deleted and recreated. "+
+			"Consider setting 'argocd.argoproj.io/sync-options: Prune=false' on the resource in '%s' before the move, or syncing this application first.",
+			r.MovedFrom, r.MovedFrom, r.MovedFrom)
+	case r.MovedTo != "":
+		return fmt.Sprintf("Moved to '%s'. The changes are shown in '%s'.", r.MovedTo, r.MovedTo)
+	default:
+		return ""
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
 )
 
@@ -126,6 +127,28 @@ func TestBuildMatchingSummary_NoChangeStats(t *testing.T) {
 	}
 }
 
+func TestBuildMatchingSummary_ImmutableFieldChanged(t *testing.T) {
+	diffs := []matching.AppDiff{
+		{
+			OldName: "app-1", NewName: "app-1", Action: matching.ActionModified, AddedLines: 1, DeletedLines: 1,
+			Changes: []matching.ResourceChange{{Kind: "Deployment", Name: "web", Action: matching.ResourceReplaced, ImmutableFields: []string{"spec.selector"}}},
+		},
+		{
+			OldName: "app-2", NewName: "app-2", Action: matching.ActionModified, AddedLines: 1,
+			Changes: []matching.ResourceChange{{Kind: "Deployment", Name: "api", Action: matching.ResourceChanged}},
+		},
+	}
+
+	result := buildSummary(diffs)
+
+	if !strings.Contains(result, "± app-1 (+1|-1) ⚠️ immutable field changed\n") {
+		t.Errorf("expected app-1 to be marked, got:\n%s", result)
+	}
+	if !strings.Contains(result, "± app-2 (+1)\n") {
+		t.Errorf("expected app-2 without marker, got:\n%s", result)
+	}
+}
+
 // Tests for buildAppURLFromDiff
 
 func TestBuildAppURLFromDiff(t *testing.T) {
@@ -289,3 +312,101 @@ func TestBuildMatchingSections_NoURL(t *testing.T) {
 		t.Errorf("expected empty appURL when no argocdUIURL, got %q", md[0].appURL)
 	}
 }
+
+func TestBuildMatchingSections_MovedResource(t *testing.T) {
+	diffs := []matching.AppDiff{
+		{
+			OldName: "frontend",
+			NewName: "frontend",
+			Action:  matching.ActionModified,
+			Resources: []matching.ResourceDiff{
+				{Kind: "Deployment", Name: "web", Namespace: "default", MovedTo: "backend"},
+			},
+		},
+		{
+			OldName: "backend",
+			NewName: "backend",
+			Action:  matching.ActionModified,
+			Resources: []matching.ResourceDiff{
+				{Kind: "Deployment", Name: "web", Namespace: "default", MovedFrom: "frontend", Content: "-  replicas: 1\n+  replicas: 2\n"},
+			},
+		},
+	}
+
+	md, html := buildMatchingSections(diffs, "")
+
+	movedTo := md[0].resources[0]
+	if movedTo.Header != "Deployment: default/web (moved to backend)" {
+		t.Errorf("unexpected header: %q", movedTo.Header)
+	}
+	if !strings.Contains(movedTo.Note, "Moved to 'backend'") {
+		t.Errorf("expected a note about the move, got %q", movedTo.Note)
+	}
+
+	movedFrom := md[1].resources[0]
+	if !strings.Contains(movedFrom.Note, "Prune=false") {
+		t.Errorf("expected the adoption caveat, got %q", movedFrom.Note)
+	}
+
+	markdown, _ := md[0].build(10000)
+	if !strings.Contains(markdown, "#### Deployment: default/web (moved to backend)\n\n> Moved to 'backend'.") || strings.Contains(markdown, "```diff") {
+		t.Errorf("expected only the note for the moved resource, got:\n%s", markdown)
+	}
+	markdown, _ = md[1].build(10000)
+	if !strings.Contains(markdown, "> ⚠️ Moved from 'frontend'.") || !strings.Contains(markdown, "```diff\n-  replicas: 1\n+  replicas: 2\n```") {
+		t.Errorf("expected the note and the residual diff, got:\n%s", markdown)
+	}
+
+	htmlSection := html[1].printHTMLSection()
+	if !strings.Contains(htmlSection, "<p>⚠️ Moved from &#39;frontend&#39;.") {
+		t.Errorf("expected the note in the HTML output, got:\n%s", htmlSection)
+	}
+}
+
+func TestResourceNote_RenamedMove(t *testing.T) {
+	note := resourceNote(matching.ResourceDiff{
+		Kind: "Deployment", Name: "frontend-web", OldName: "web", Namespace: "default", MovedFrom: "frontend",
+		Action: matching.ResourceReplaced, ReplaceReason: "renamed from default/web",
+	})
+	if note != "⚠️ Moved from 'frontend' and replaced (renamed from default/web). This application creates the new resource and 'frontend' deletes the old one when it prunes." {
+		t.Errorf("unexpected note: %q", note)
+	}
+}
+
+func TestBuildMatchingSections_SyncBehaviourBadges(t *testing.T) {
+	diffs := []matching.AppDiff{
+		{
+			OldName: "web", NewName: "web", Action: matching.ActionModified,
+			Resources: []matching.ResourceDiff{
+				{
+					Kind: "PersistentVolumeClaim", Name: "data", Namespace: "default",
+					Content:       "-  storage: 1Gi\n+  storage: 2Gi\n",
+					SyncBehaviour: argoapplication.SyncBehaviour{NoPrune: true, SyncWave: "-1"},
+				},
+				{Kind: "ConfigMap", Name: "settings", Namespace: "default", Content: "+  mode: staging\n"},
+			},
+		},
+	}
+
+	md, html := buildMatchingSections(diffs, "")
+
+	if badges := md[0].resources[0].Badges; len(badges) != 2 || badges[0] != "won't be pruned" || badges[1] != "sync wave: -1" {
+		t.Errorf("unexpected badges: %v", badges)
+	}
+	if len(md[0].resources[1].Badges) != 0 {
+		t.Errorf("expected no badges for a plain resource, got %v", md[0].resources[1].Badges)
+	}
+
+	markdown, _ := md[0].build(10000)
+	if !strings.Contains(markdown, "#### PersistentVolumeClaim: default/data `won't be pruned` `sync wave: -1`\n```diff") {
+		t.Errorf("expected the badges after the header, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "#### ConfigMap: default/settings\n```diff") {
+		t.Errorf("expected a plain header without badges, got:\n%s", markdown)
+	}
+
+	htmlSection := html[0].printHTMLSection()
+	if !strings.Contains(htmlSection, `PersistentVolumeClaim: default/data <span class="badge">won&#39;t be pruned</span> <span class="badge">sync wave: -1</span></h4>`) {
+		t.Errorf("expected the badges in the HTML header, got:\n%s", htmlSection)
+	}
+}
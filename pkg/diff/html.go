@@ -9,11 +9,22 @@ import (
 )
 
 type HTMLOutput struct {
-	title         string
-	summary       string
-	sections      []HTMLSection
-	statsInfo     StatsInfo
-	selectionInfo SelectionInfo
+	title             string
+	summary           string
+	plan              Plan
+	sections          []HTMLSection
+	statsInfo         StatsInfo
+	selectionInfo     SelectionInfo
+	manifestProblems  ManifestProblems
+	projectViolations ProjectViolations
+	sharedResources   SharedResources
+	deletedResources  DeletedResources
+	syncPlan          SyncPlan
+	specChanges       SpecChanges
+	appSetChanges     AppSetChanges
+	ignoredFields     IgnoredFields
+	nonDeterministic  NonDeterministicFields
+	renderCrossCheck  RenderMethodCrossCheck
 }
 
 const htmlTemplate = `
@@ -66,6 +77,13 @@ tr.comment_line {
 .resource_header:first-of-type {
 	margin-top: 10px;
 }
+.badge {
+	font-family: arial;
+	font-size: 12px;
+	background: rgb(219, 219, 219);
+	border-radius: 8px;
+	padding: 1px 6px;
+}
 pre {
 	margin: 0;
 	padding-left: 15px;
@@ -77,10 +95,10 @@ pre {
 <div class="container">
 <h1>%title%</h1>
 
-<p>Summary:</p>
+%plan%<p>Summary:</p>
 <pre>%summary%</pre>
 
-<div class="diffs">
+%manifest_problems%%project_violations%%shared_resources%%deleted_resources%%sync_plan%%spec_changes%%appset_changes%%ignored_fields%%nondeterministic_fields%%render_cross_check%<div class="diffs">
 %app_diffs%
 </div>
 %selection_changes%
@@ -107,6 +125,8 @@ func emptyReasonHTML(reason matching.EmptyReason) string {
 		return "<p><em>Diff hidden because <code>--hide-deleted-app-diff</code> is enabled</em></p>"
 	case matching.EmptyReasonNameOnlyChange:
 		return "<p><em>Application name changed, but rendered resources are unchanged</em></p>"
+	case matching.EmptyReasonHiddenByAnnotation:
+		return "<p><em>Diff hidden because of the <code>argocd-diff-preview/hide-diff</code> annotation</em></p>"
 	default:
 		return "<p><em>Empty for unknown reason</em></p>"
 	}
@@ -147,28 +167,17 @@ func (h *HTMLSection) printHTMLSection() string {
 		fmt.Fprintf(&body, "\n%s\n", emptyReasonHTML(h.emptyReason))
 	} else {
 		for _, r := range h.resources {
-			fmt.Fprintf(&body, "\n<h4 class=\"resource_header\">%s</h4>\n", html.EscapeString(r.Header))
-			if r.IsSkipped {
+			fmt.Fprintf(&body, "\n<h4 class=\"resource_header\">%s%s</h4>\n", html.EscapeString(r.Header), htmlBadges(r.Badges))
+			if r.Note != "" {
+				fmt.Fprintf(&body, "<p>%s</p>\n", html.EscapeString(r.Note))
+			}
+			switch {
+			case r.IsSkipped:
 				body.WriteString("<p><em>Skipped</em></p>\n")
-			} else {
-				body.WriteString("<div class=\"diff_container\">\n<table>\n")
-				for line := range strings.Lines(r.Content) {
-					line = strings.TrimRight(line, " \t\r\n")
-					if len(line) == 0 {
-						continue
-					}
-					switch line[0] {
-					case '@':
-						fmt.Fprintf(&body, htmlLine, "comment_line", html.EscapeString(line))
-					case '-':
-						fmt.Fprintf(&body, htmlLine, "removed_line", html.EscapeString(line))
-					case '+':
-						fmt.Fprintf(&body, htmlLine, "added_line", html.EscapeString(line))
-					default:
-						fmt.Fprintf(&body, htmlLine, "normal_line", html.EscapeString(line))
-					}
-				}
-				body.WriteString("\n</table>\n</div>\n")
+			case r.Content == "" && r.Note != "":
+				// Nothing to show besides the note
+			default:
+				writeHTMLDiffTable(&body, r.Content)
 			}
 		}
 	}
@@ -178,6 +187,37 @@ func (h *HTMLSection) printHTMLSection() string {
 	return s
 }
 
+// htmlBadges returns the badges of a resource, or an empty string if there are none
+func htmlBadges(badges []string) string {
+	var sb strings.Builder
+	for _, badge := range badges {
+		fmt.Fprintf(&sb, ` <span class="badge">%s</span>`, html.EscapeString(badge))
+	}
+	return sb.String()
+}
+
+// writeHTMLDiffTable writes diff text (with +/-/space prefixes) as a colored table
+func writeHTMLDiffTable(body *strings.Builder, content string) {
+	body.WriteString("<div class=\"diff_container\">\n<table>\n")
+	for line := range strings.Lines(content) {
+		line = strings.TrimRight(line, " \t\r\n")
+		if len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case '@':
+			fmt.Fprintf(body, htmlLine, "comment_line", html.EscapeString(line))
+		case '-':
+			fmt.Fprintf(body, htmlLine, "removed_line", html.EscapeString(line))
+		case '+':
+			fmt.Fprintf(body, htmlLine, "added_line", html.EscapeString(line))
+		default:
+			fmt.Fprintf(body, htmlLine, "normal_line", html.EscapeString(line))
+		}
+	}
+	body.WriteString("\n</table>\n</div>\n")
+}
+
 func (h *HTMLOutput) printDiff() string {
 	var sectionsDiff strings.Builder
 
@@ -197,6 +237,17 @@ func (h *HTMLOutput) printDiff() string {
 		selection_changes = fmt.Sprintf("\n<pre>%s</pre>\n<br>\n", s)
 	}
 	output = strings.ReplaceAll(output, "%selection_changes%", selection_changes)
+	output = strings.ReplaceAll(output, "%plan%", h.plan.HTML())
+	output = strings.ReplaceAll(output, "%manifest_problems%", h.manifestProblems.HTML())
+	output = strings.ReplaceAll(output, "%project_violations%", h.projectViolations.HTML())
+	output = strings.ReplaceAll(output, "%shared_resources%", h.sharedResources.HTML())
+	output = strings.ReplaceAll(output, "%deleted_resources%", h.deletedResources.HTML())
+	output = strings.ReplaceAll(output, "%sync_plan%", h.syncPlan.HTML())
+	output = strings.ReplaceAll(output, "%spec_changes%", h.specChanges.HTML())
+	output = strings.ReplaceAll(output, "%appset_changes%", h.appSetChanges.HTML())
+	output = strings.ReplaceAll(output, "%ignored_fields%", h.ignoredFields.HTML())
+	output = strings.ReplaceAll(output, "%nondeterministic_fields%", h.nonDeterministic.HTML())
+	output = strings.ReplaceAll(output, "%render_cross_check%", h.renderCrossCheck.HTML())
 	output = strings.ReplaceAll(output, "%info_box%", h.statsInfo.String())
 	return strings.TrimSpace(output) + "\n"
 }
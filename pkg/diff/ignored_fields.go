@@ -0,0 +1,98 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+)
+
+// IgnoredFields are the fields masked by the global ignore rules (--ignore-differences)
+type IgnoredFields []extract.MaskedField
+
+const ignoredFieldsDescription = "The following fields are hidden by `--ignore-differences`, so changes to them are not shown in the diff."
+
+// ignoredFieldMatcher describes the resources a field is masked in, e.g. 'Deployment.apps (namespace default)'
+func ignoredFieldMatcher(f extract.MaskedField) string {
+	kind := f.Kind
+	if kind == "" {
+		kind = "all resources"
+	}
+	if f.Group != "" {
+		kind = fmt.Sprintf("%s.%s", kind, f.Group)
+	}
+	var parts []string
+	if f.Namespace != "" {
+		parts = append(parts, "namespace "+f.Namespace)
+	}
+	if f.Name != "" {
+		parts = append(parts, "name "+f.Name)
+	}
+	if len(parts) == 0 {
+		return kind
+	}
+	return fmt.Sprintf("%s (%s)", kind, strings.Join(parts, ", "))
+}
+
+// masked returns the fields that were masked in at least one resource
+func (f IgnoredFields) masked() IgnoredFields {
+	var masked IgnoredFields
+	for _, field := range f {
+		if field.Resources > 0 {
+			masked = append(masked, field)
+		}
+	}
+	return masked
+}
+
+func resourceCount(count int) string {
+	if count == 1 {
+		return "1 resource"
+	}
+	return fmt.Sprintf("%d resources", count)
+}
+
+// Markdown returns the masked fields as a markdown table, or an empty string if no field was masked
+func (f IgnoredFields) Markdown() string {
+	masked := f.masked()
+	if len(masked) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### Ignored fields\n\n")
+	sb.WriteString(ignoredFieldsDescription + "\n\n")
+	sb.WriteString("| Field | Applies to | Masked in |\n")
+	sb.WriteString("| ----- | ---------- | --------- |\n")
+	for _, field := range masked {
+		fmt.Fprintf(&sb, "| `%s` | %s | %s |\n",
+			escapeMarkdownTableCell(field.Field),
+			escapeMarkdownTableCell(ignoredFieldMatcher(field)),
+			resourceCount(field.Resources),
+		)
+	}
+	return sb.String() + "\n"
+}
+
+// HTML returns the masked fields as an HTML table, or an empty string if no field was masked
+func (f IgnoredFields) HTML() string {
+	masked := f.masked()
+	if len(masked) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>Ignored fields</h3>\n")
+	sb.WriteString("<p>" + html.EscapeString(strings.ReplaceAll(ignoredFieldsDescription, "`", "")) + "</p>\n")
+	sb.WriteString("<table>\n<tr><th>Field</th><th>Applies to</th><th>Masked in</th></tr>\n")
+	for _, field := range masked {
+		fmt.Fprintf(&sb, "<tr><td><code>%s</code></td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(field.Field),
+			html.EscapeString(ignoredFieldMatcher(field)),
+			resourceCount(field.Resources),
+		)
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
@@ -0,0 +1,39 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+)
+
+func TestIgnoredFields(t *testing.T) {
+	fields := IgnoredFields{
+		{Field: "/metadata/labels/helm.sh~1chart", Resources: 12},
+		{Group: "apps", Kind: "Deployment", Namespace: "default", Field: `.spec.template.metadata.annotations["checksum/config"]`, Resources: 1},
+		{Kind: "Service", Field: "/spec/clusterIP"},
+	}
+
+	markdown := fields.Markdown()
+	for _, expected := range []string{
+		"### Ignored fields",
+		"| `/metadata/labels/helm.sh~1chart` | all resources | 12 resources |",
+		"| `.spec.template.metadata.annotations[\"checksum/config\"]` | Deployment.apps (namespace default) | 1 resource |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "/spec/clusterIP") {
+		t.Errorf("expected fields that masked nothing to be left out, got:\n%s", markdown)
+	}
+
+	if !strings.Contains(fields.HTML(), "<tr><td><code>/metadata/labels/helm.sh~1chart</code></td><td>all resources</td><td>12 resources</td></tr>") {
+		t.Errorf("unexpected HTML:\n%s", fields.HTML())
+	}
+
+	nothingMasked := IgnoredFields{extract.MaskedField{Kind: "Service", Field: "/spec/clusterIP"}}
+	if nothingMasked.Markdown() != "" || nothingMasked.HTML() != "" || IgnoredFields(nil).Markdown() != "" {
+		t.Errorf("expected no output when no field was masked")
+	}
+}
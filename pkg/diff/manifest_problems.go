@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+)
+
+// warningsNote explains the warnings in the manifest problems section
+const warningsNote = "Warnings are fields unknown to the Argo CD version of this tool, which may be fields of a newer version. They do not fail the run with --fail-on-manifest-problems."
+
+// ManifestProblems are the Application[Set] manifests in both branches that could not be
+// parsed or are not valid
+type ManifestProblems []argoapplication.ManifestProblem
+
+// NewManifestProblems collects the manifest problems of both branches
+func NewManifestProblems(baseApps *argoapplication.ArgoSelection, targetApps *argoapplication.ArgoSelection) ManifestProblems {
+	var problems ManifestProblems
+	for _, selection := range []*argoapplication.ArgoSelection{baseApps, targetApps} {
+		if selection != nil {
+			problems = append(problems, selection.Problems...)
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b argoapplication.ManifestProblem) int {
+		return cmp.Or(
+			cmp.Compare(a.Branch, b.Branch),
+			cmp.Compare(a.FileName, b.FileName),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return problems
+}
+
+// resourceName returns the kind and name of the resource the problem was found in
+func resourceName(problem argoapplication.ManifestProblem) string {
+	switch {
+	case problem.Kind == "":
+		return "unknown"
+	case problem.Name == "":
+		return problem.Kind
+	default:
+		return fmt.Sprintf("%s %s", problem.Kind, problem.Name)
+	}
+}
+
+// ErrorCount returns the number of problems that are not warnings
+func (p ManifestProblems) ErrorCount() int {
+	count := 0
+	for _, problem := range p {
+		if !problem.Warning {
+			count++
+		}
+	}
+	return count
+}
+
+// severity returns whether the problem is an error or a warning
+func severity(problem argoapplication.ManifestProblem) string {
+	if problem.Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Markdown returns the problems as a markdown table, or an empty string if there are none
+func (p ManifestProblems) Markdown() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### ⚠️ Manifest problems\n\n")
+	sb.WriteString("The following Application[Set] manifests could not be parsed or are not valid. They may be missing from the diff or fail to sync. " + warningsNote + "\n\n")
+	sb.WriteString("| Branch | File | Resource | Severity | Problem |\n")
+	sb.WriteString("| ------ | ---- | -------- | -------- | ------- |\n")
+	for _, problem := range p {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s |\n",
+			problem.Branch,
+			escapeMarkdownTableCell(problem.FileName),
+			escapeMarkdownTableCell(resourceName(problem)),
+			severity(problem),
+			escapeMarkdownTableCell(problem.Message),
+		)
+	}
+	return sb.String() + "\n"
+}
+
+// HTML returns the problems as an HTML table, or an empty string if there are none
+func (p ManifestProblems) HTML() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>⚠️ Manifest problems</h3>\n")
+	sb.WriteString("<p>The following Application[Set] manifests could not be parsed or are not valid. They may be missing from the diff or fail to sync. " + html.EscapeString(warningsNote) + "</p>\n")
+	sb.WriteString("<table>\n<tr><th>Branch</th><th>File</th><th>Resource</th><th>Severity</th><th>Problem</th></tr>\n")
+	for _, problem := range p {
+		fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(string(problem.Branch)),
+			html.EscapeString(problem.FileName),
+			html.EscapeString(resourceName(problem)),
+			severity(problem),
+			html.EscapeString(problem.Message),
+		)
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
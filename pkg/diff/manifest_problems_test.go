@@ -0,0 +1,110 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+func TestNewManifestProblems_SortsBothBranches(t *testing.T) {
+	base := &argoapplication.ArgoSelection{
+		Problems: []argoapplication.ManifestProblem{
+			{Branch: git.Base, FileName: "apps/b.yaml", Kind: "Application", Name: "b", Message: "spec.destination is missing"},
+		},
+	}
+	target := &argoapplication.ArgoSelection{
+		Problems: []argoapplication.ManifestProblem{
+			{Branch: git.Target, FileName: "apps/b.yaml", Kind: "Application", Name: "b", Message: "spec.destination is missing"},
+			{Branch: git.Target, FileName: "apps/a.yaml", Message: "invalid YAML: mapping values are not allowed in this context"},
+		},
+	}
+
+	problems := NewManifestProblems(base, target)
+
+	if len(problems) != 3 {
+		t.Fatalf("expected 3 problems, got %d", len(problems))
+	}
+	if problems[0].Branch != git.Base {
+		t.Errorf("expected base problems first, got %s", problems[0].Branch)
+	}
+	if problems[1].FileName != "apps/a.yaml" {
+		t.Errorf("expected target problems sorted by file, got %s", problems[1].FileName)
+	}
+}
+
+func TestManifestProblems_Empty(t *testing.T) {
+	var problems ManifestProblems
+	if problems.Markdown() != "" {
+		t.Errorf("expected empty markdown, got %q", problems.Markdown())
+	}
+	if problems.HTML() != "" {
+		t.Errorf("expected empty HTML, got %q", problems.HTML())
+	}
+}
+
+func TestManifestProblems_Markdown(t *testing.T) {
+	problems := ManifestProblems{
+		{Branch: git.Target, FileName: "apps/a.yaml", Message: "invalid YAML: did not find expected key"},
+		{Branch: git.Target, FileName: "apps/b.yaml", Kind: "ApplicationSet", Name: "b", Message: "spec.generators is missing"},
+		{Branch: git.Target, FileName: "apps/c.yaml", Kind: "Application", Name: "c", Message: `has fields unknown to the Application schema of this Argo CD version: unknown field "spec.newField"`, Warning: true},
+	}
+
+	if problems.ErrorCount() != 2 {
+		t.Errorf("expected 2 errors, got %d", problems.ErrorCount())
+	}
+
+	markdown := problems.Markdown()
+
+	for _, expected := range []string{
+		"### ⚠️ Manifest problems",
+		"| target | apps/a.yaml | unknown | error | invalid YAML: did not find expected key |",
+		"| target | apps/b.yaml | ApplicationSet b | error | spec.generators is missing |",
+		"| target | apps/c.yaml | Application c | warning | has fields unknown to the Application schema of this Argo CD version: unknown field \"spec.newField\" |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+}
+
+func TestMarkdownOutput_WithManifestProblems(t *testing.T) {
+	output := MarkdownOutput{
+		title:   "Test",
+		summary: "No changes found",
+		manifestProblems: ManifestProblems{
+			{Branch: git.Target, FileName: "apps/a.yaml", Kind: "Application", Name: "a", Message: "spec.destination is missing"},
+		},
+	}
+
+	markdown := output.printDiff(65536)
+
+	// The summary and the empty diff both say "No changes found"
+	problemsIndex := strings.Index(markdown, "### ⚠️ Manifest problems")
+	if problemsIndex == -1 {
+		t.Fatalf("expected manifest problems section, got:\n%s", markdown)
+	}
+	if problemsIndex > strings.LastIndex(markdown, "No changes found") {
+		t.Errorf("expected manifest problems before the diffs, got:\n%s", markdown)
+	}
+}
+
+func TestHTMLOutput_WithManifestProblems(t *testing.T) {
+	output := HTMLOutput{
+		title:   "Test",
+		summary: "No changes found",
+		manifestProblems: ManifestProblems{
+			{Branch: git.Base, FileName: "apps/<a>.yaml", Kind: "Application", Name: "a", Message: "spec.destination is missing"},
+		},
+	}
+
+	html := output.printDiff()
+
+	if !strings.Contains(html, "<h3>⚠️ Manifest problems</h3>") {
+		t.Errorf("expected manifest problems section, got:\n%s", html)
+	}
+	if !strings.Contains(html, "<td>apps/&lt;a&gt;.yaml</td>") {
+		t.Errorf("expected escaped file name, got:\n%s", html)
+	}
+}
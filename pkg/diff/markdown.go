@@ -25,6 +25,8 @@ func emptyReasonMarkdown(reason matching.EmptyReason) string {
 		return "_Diff hidden because `--hide-deleted-app-diff` is enabled_"
 	case matching.EmptyReasonNameOnlyChange:
 		return "_Application name changed, but rendered resources are unchanged_"
+	case matching.EmptyReasonHiddenByAnnotation:
+		return "_Diff hidden because of the `argocd-diff-preview/hide-diff` annotation_"
 	default:
 		return "_Empty for unknown reason_"
 	}
@@ -56,6 +58,38 @@ const (
 	summaryTooLongNotice = "\n... Summary truncated to fit `--max-diff-length`"
 )
 
+// markdownResource returns the header, note and diff of a single resource
+func markdownResource(r ResourceSection) string {
+	switch {
+	case r.IsSkipped:
+		return fmt.Sprintf("%s%s\n_Skipped_\n\n", markdownResourceHeader(r), markdownNote(r.Note))
+	case r.Content == "" && r.Note != "":
+		return fmt.Sprintf("%s%s", markdownResourceHeader(r), markdownNote(r.Note))
+	default:
+		content := strings.TrimRight(r.Content, "\n")
+		return fmt.Sprintf("%s%s```diff\n%s\n```\n", markdownResourceHeader(r), markdownNote(r.Note), content)
+	}
+}
+
+// markdownResourceHeader returns the header of a resource followed by its badges
+func markdownResourceHeader(r ResourceSection) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#### %s", r.Header)
+	for _, badge := range r.Badges {
+		fmt.Fprintf(&sb, " `%s`", badge)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// markdownNote returns the note of a resource as a quote, or an empty string if there is none
+func markdownNote(note string) string {
+	if note == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n> %s\n\n", note)
+}
+
 // build returns the section content and a boolean indicating if the section was truncated
 func (m *MarkdownSection) build(maxSize int) (string, bool) {
 	header := markdownSectionHeader(m.appName, m.filePath, m.appURL)
@@ -68,12 +102,7 @@ func (m *MarkdownSection) build(maxSize int) (string, bool) {
 		body.WriteString("\n\n")
 	} else {
 		for _, r := range m.resources {
-			if r.IsSkipped {
-				fmt.Fprintf(&body, "#### %s\n\n_Skipped_\n\n", r.Header)
-			} else {
-				content := strings.TrimRight(r.Content, "\n")
-				fmt.Fprintf(&body, "#### %s\n```diff\n%s\n```\n", r.Header, content)
-			}
+			body.WriteString(markdownResource(r))
 		}
 	}
 
@@ -96,13 +125,7 @@ func (m *MarkdownSection) build(maxSize int) (string, bool) {
 
 	var truncatedBody strings.Builder
 	for _, r := range m.resources {
-		var part string
-		if r.IsSkipped {
-			part = fmt.Sprintf("#### %s\n\n_Skipped_\n\n", r.Header)
-		} else {
-			content := strings.TrimRight(r.Content, "\n")
-			part = fmt.Sprintf("#### %s\n```diff\n%s\n```\n", r.Header, content)
-		}
+		part := markdownResource(r)
 
 		// If the full resource fits, include it and continue
 		if truncatedBody.Len()+len(part) <= spaceForBody {
@@ -111,14 +134,14 @@ func (m *MarkdownSection) build(maxSize int) (string, bool) {
 		}
 
 		// Resource doesn't fit - try to include a truncated version.
-		// Skipped sections are small and not worth partially including.
-		if r.IsSkipped {
+		// Skipped sections and sections without a diff are small and not worth partially including.
+		if r.IsSkipped || r.Content == "" {
 			break
 		}
 
 		// Split into header/content/footer so we can truncate only the content
 		// while structurally guaranteeing the code fence closes
-		resHeader := fmt.Sprintf("#### %s\n```diff\n", r.Header)
+		resHeader := fmt.Sprintf("%s%s```diff\n", markdownResourceHeader(r), markdownNote(r.Note))
 		resFooter := "\n```\n"
 		remaining := spaceForBody - truncatedBody.Len() - len(resHeader) - len(resFooter)
 		if remaining > minSizeForSectionContent {
@@ -137,22 +160,33 @@ func (m *MarkdownSection) build(maxSize int) (string, bool) {
 }
 
 type MarkdownOutput struct {
-	title         string
-	summary       string
-	sections      []MarkdownSection
-	statsInfo     StatsInfo
-	selectionInfo SelectionInfo
+	title             string
+	summary           string
+	plan              Plan
+	sections          []MarkdownSection
+	statsInfo         StatsInfo
+	selectionInfo     SelectionInfo
+	manifestProblems  ManifestProblems
+	projectViolations ProjectViolations
+	sharedResources   SharedResources
+	deletedResources  DeletedResources
+	syncPlan          SyncPlan
+	specChanges       SpecChanges
+	appSetChanges     AppSetChanges
+	ignoredFields     IgnoredFields
+	nonDeterministic  NonDeterministicFields
+	renderCrossCheck  RenderMethodCrossCheck
 }
 
 const markdownTemplate = `
 ## %title%
 
-Summary:
+%plan%Summary:
 ` + "```yaml" + `
 %summary%
 ` + "```" + `
 
-%app_diffs%
+%manifest_problems%%project_violations%%shared_resources%%deleted_resources%%sync_plan%%spec_changes%%appset_changes%%ignored_fields%%nondeterministic_fields%%render_cross_check%%app_diffs%
 %selection_changes%
 %info_box%
 `
@@ -190,6 +224,38 @@ func (m *MarkdownOutput) printDiff(maxDiffMessageCharCount uint) string {
 
 	output := strings.ReplaceAll(markdownTemplate, "%title%", m.title)
 	output = strings.ReplaceAll(output, "%selection_changes%", selection_changes)
+	output = strings.ReplaceAll(output, "%plan%", m.plan.Markdown())
+	output = strings.ReplaceAll(output, "%manifest_problems%", m.manifestProblems.Markdown())
+	output = strings.ReplaceAll(output, "%project_violations%", m.projectViolations.Markdown())
+	output = strings.ReplaceAll(output, "%shared_resources%", m.sharedResources.Markdown())
+	output = strings.ReplaceAll(output, "%deleted_resources%", m.deletedResources.Markdown())
+	output = strings.ReplaceAll(output, "%sync_plan%", m.syncPlan.Markdown())
+	output = strings.ReplaceAll(output, "%ignored_fields%", m.ignoredFields.Markdown())
+	output = strings.ReplaceAll(output, "%nondeterministic_fields%", m.nonDeterministic.Markdown())
+
+	// Sections with diffs before the app diffs share at most half of the space, so a large
+	// section does not push the app diffs out of the comment
+	limitedSections := []struct {
+		placeholder string
+		build       func(maxSize int) (string, bool)
+	}{
+		{"%spec_changes%", m.specChanges.markdownWithin},
+		{"%appset_changes%", m.appSetChanges.markdownWithin},
+		{"%render_cross_check%", m.renderCrossCheck.markdownWithin},
+	}
+	outputWithoutLimitedSections := strings.ReplaceAll(output, "%summary%", "")
+	for _, section := range limitedSections {
+		outputWithoutLimitedSections = strings.ReplaceAll(outputWithoutLimitedSections, section.placeholder, "")
+	}
+	limitedSectionsBudget := (int(maxDiffMessageCharCount) - len(outputWithoutLimitedSections) - len(warningMessage) - infoBoxBufferSize) / 2
+	for i, section := range limitedSections {
+		content, truncated := section.build(limitedSectionsBudget / (len(limitedSections) - i))
+		if truncated {
+			log.Warn().Msgf("🚨 Markdown section %s is too long, truncating to fit --max-diff-length (%d)", strings.Trim(section.placeholder, "%"), maxDiffMessageCharCount)
+		}
+		limitedSectionsBudget -= len(content)
+		output = strings.ReplaceAll(output, section.placeholder, content)
+	}
 
 	// temp value to check if summary was truncated, to decide whether to log a warning about it
 	var summary string
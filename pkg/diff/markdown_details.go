@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sectionTooLongWarning ends a section printed before the app diffs when it does not fit in
+// the space given to it
+const sectionTooLongWarning = "🚨 Section is too long. See the HTML output for the rest\n\n"
+
+// markdownDetails is a collapsible block of a section printed before the app diffs
+type markdownDetails struct {
+	summary string
+	intro   string // printed before the diffs, e.g. a list of risks
+	diffs   []markdownDiff
+}
+
+// markdownDiff is a diff in a collapsible block, with an optional header above it
+type markdownDiff struct {
+	header  string
+	content string
+}
+
+func (d markdownDetails) header() string {
+	return fmt.Sprintf("<details>\n<summary>%s</summary>\n<br>\n\n%s", d.summary, d.intro)
+}
+
+func (d markdownDetails) footer() string {
+	return "</details>\n\n"
+}
+
+func (d markdownDiff) String() string {
+	return fmt.Sprintf("%s```diff\n%s\n```\n", d.header, strings.TrimRight(d.content, "\n"))
+}
+
+func (d markdownDetails) String() string {
+	var sb strings.Builder
+	sb.WriteString(d.header())
+	for _, diff := range d.diffs {
+		sb.WriteString(diff.String())
+	}
+	sb.WriteString(d.footer())
+	return sb.String()
+}
+
+// truncated returns the block with the diffs that fit in maxSize. The diff that does not fit
+// is cut off, and the diffs after it are left out. It returns an empty string if not even
+// the summary fits.
+func (d markdownDetails) truncated(maxSize int) string {
+	header, footer := d.header(), d.footer()
+	space := maxSize - len(header) - len(footer)
+	if space < 0 {
+		return ""
+	}
+
+	var body strings.Builder
+	for _, diff := range d.diffs {
+		part := diff.String()
+		if body.Len()+len(part) <= space {
+			body.WriteString(part)
+			continue
+		}
+
+		// Cut off the content, so the code fence still closes
+		fence := fmt.Sprintf("%s```diff\n", diff.header)
+		remaining := space - body.Len() - len(fence) - len("\n```\n")
+		if remaining > minSizeForSectionContent {
+			content := strings.TrimRight(diff.content, "\n")
+			content = content[:min(remaining, len(content))]
+			// Only keep whole lines
+			if i := strings.LastIndex(content, "\n"); i > 0 {
+				content = content[:i]
+			}
+			content = strings.TrimRight(content, " \t\n\r")
+			fmt.Fprintf(&body, "%s%s\n```\n", fence, content)
+		}
+		break
+	}
+	return header + body.String() + footer
+}
+
+// buildMarkdownDetails returns the heading and the blocks of a section that fit in maxSize,
+// and whether blocks were truncated. The block that does not fit is truncated and the
+// blocks after it are left out. A warning points to the HTML output for the rest.
+func buildMarkdownDetails(heading string, blocks []markdownDetails, maxSize int) (string, bool) {
+	var full strings.Builder
+	full.WriteString(heading)
+	for _, block := range blocks {
+		full.WriteString(block.String())
+	}
+	if full.Len() <= maxSize {
+		return full.String(), false
+	}
+
+	space := maxSize - len(sectionTooLongWarning)
+	if len(heading) > space {
+		return "", true
+	}
+
+	var sb strings.Builder
+	sb.WriteString(heading)
+	for _, block := range blocks {
+		part := block.String()
+		if sb.Len()+len(part) <= space {
+			sb.WriteString(part)
+			continue
+		}
+		sb.WriteString(block.truncated(space - sb.Len()))
+		break
+	}
+	sb.WriteString(sectionTooLongWarning)
+	return sb.String(), true
+}
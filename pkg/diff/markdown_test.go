@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
 )
 
@@ -785,3 +786,132 @@ func TestMarkdownOutput_PrintDiff_TruncatesLargeSummary(t *testing.T) {
 		t.Fatalf("expected output to mention --max-diff-length, got:\n%s", got)
 	}
 }
+
+func TestMarkdownOutput_PrintDiff_LongSpecChanges(t *testing.T) {
+	const maxDiffLength = 3000
+	output := MarkdownOutput{
+		title:   "Spec changes",
+		summary: "Modified (1):\n± web",
+		sections: []MarkdownSection{
+			{
+				appName:  "web",
+				filePath: "apps/web.yaml",
+				resources: []ResourceSection{
+					{Header: "Deployment: default/web", Content: "-  replicas: 1\n+  replicas: 2\n"},
+				},
+			},
+		},
+		specChanges: SpecChanges{
+			{Name: "web", FileName: "apps/web.yaml", Content: strings.Repeat("+  very long spec change\n", 200)},
+			{Name: "api", FileName: "apps/api.yaml", Content: "+  project: team-a\n"},
+		},
+	}
+	if len(output.specChanges.Markdown()) <= maxDiffLength {
+		t.Fatalf("expected the spec changes alone to be longer than %d characters", maxDiffLength)
+	}
+
+	got := output.printDiff(maxDiffLength)
+
+	if len(got) > maxDiffLength {
+		t.Errorf("expected the output to fit in %d characters, got %d", maxDiffLength, len(got))
+	}
+	for _, expected := range []string{
+		"### Application spec changes",
+		"+  very long spec change\n```\n</details>",
+		sectionTooLongWarning,
+		"#### Deployment: default/web",
+		"+  replicas: 2",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "apps/api.yaml") {
+		t.Errorf("expected the spec changes after the truncated one to be left out, got:\n%s", got)
+	}
+}
+
+func TestMarkdownOutput_PrintDiff_LongAppSetChanges(t *testing.T) {
+	const maxDiffLength = 3000
+	output := MarkdownOutput{
+		title:   "AppSet changes",
+		summary: "Modified (1):\n± web",
+		sections: []MarkdownSection{
+			{
+				appName:   "web",
+				filePath:  "apps/web.yaml",
+				resources: []ResourceSection{{Header: "Deployment: default/web", Content: "+  replicas: 2\n"}},
+			},
+		},
+		appSetChanges: AppSetChanges{
+			{
+				Name: "cluster-addons", File: "appsets/cluster-addons.yaml",
+				Modified: []GeneratedAppDiff{
+					{Name: "ingress", Diff: strings.Repeat("+  very long generated application change\n", 200)},
+					{Name: "monitoring", Diff: "+  path: monitoring-v2\n"},
+				},
+			},
+		},
+	}
+
+	got := output.printDiff(maxDiffLength)
+
+	if len(got) > maxDiffLength {
+		t.Errorf("expected the output to fit in %d characters, got %d", maxDiffLength, len(got))
+	}
+	for _, expected := range []string{
+		"- ✏️ `monitoring` modified",
+		"#### ingress\n```diff\n+  very long generated application change\n",
+		sectionTooLongWarning,
+		"#### Deployment: default/web",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "#### monitoring") {
+		t.Errorf("expected the diffs after the truncated one to be left out, got:\n%s", got)
+	}
+}
+
+func TestMarkdownOutput_PrintDiff_LongRenderCrossCheck(t *testing.T) {
+	const maxDiffLength = 3000
+	output := MarkdownOutput{
+		title:   "Render cross-check",
+		summary: "Modified (1):\n± web",
+		sections: []MarkdownSection{
+			{
+				appName:   "web",
+				filePath:  "apps/web.yaml",
+				resources: []ResourceSection{{Header: "Deployment: default/web", Content: "+  replicas: 2\n"}},
+			},
+		},
+		renderCrossCheck: RenderMethodCrossCheck{
+			Method:           "server-api",
+			CrossCheckMethod: "repo-server-api",
+			Divergences: []RenderDivergence{
+				{Branch: git.Target, App: "ingress", Content: strings.Repeat("+  very long divergence\n", 200), AddedLines: 200},
+				{Branch: git.Target, App: "monitoring", Content: "+  path: monitoring\n", AddedLines: 1},
+			},
+		},
+	}
+
+	got := output.printDiff(maxDiffLength)
+
+	if len(got) > maxDiffLength {
+		t.Errorf("expected the output to fit in %d characters, got %d", maxDiffLength, len(got))
+	}
+	for _, expected := range []string{
+		"### Render method cross-check",
+		"<summary>ingress (target-branch) (+200|-0)</summary>\n<br>\n\n```diff\n+  very long divergence\n",
+		sectionTooLongWarning,
+		"#### Deployment: default/web",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "monitoring (target-branch)") {
+		t.Errorf("expected the divergences after the truncated one to be left out, got:\n%s", got)
+	}
+}
@@ -0,0 +1,63 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+)
+
+// NonDeterministicFields are the fields that differed between two renders of the same
+// Applications (--check-determinism). They are masked in the diff.
+type NonDeterministicFields []extract.NonDeterministicField
+
+const nonDeterministicFieldsDescription = "The following fields changed between two renders of the same base branch, so they are hidden in the diff. They are usually generated by functions like `randAlphaNum`, `now` or `genCA` in a Helm chart."
+
+func nonDeterministicResource(f extract.NonDeterministicField) string {
+	return fmt.Sprintf("%s %s", f.Kind, qualifiedResourceName(f.Namespace, f.Name))
+}
+
+// Markdown returns the non-deterministic fields as a markdown table, or an empty string if
+// there are none
+func (f NonDeterministicFields) Markdown() string {
+	if len(f) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### Non-deterministic fields\n\n")
+	sb.WriteString(nonDeterministicFieldsDescription + "\n\n")
+	sb.WriteString("| Application | Resource | Field |\n")
+	sb.WriteString("| ----------- | -------- | ----- |\n")
+	for _, field := range f {
+		fmt.Fprintf(&sb, "| %s | `%s` | `%s` |\n",
+			escapeMarkdownTableCell(field.App),
+			escapeMarkdownTableCell(nonDeterministicResource(field)),
+			escapeMarkdownTableCell(field.Path),
+		)
+	}
+	return sb.String() + "\n"
+}
+
+// HTML returns the non-deterministic fields as an HTML table, or an empty string if there
+// are none
+func (f NonDeterministicFields) HTML() string {
+	if len(f) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>Non-deterministic fields</h3>\n")
+	sb.WriteString("<p>" + html.EscapeString(strings.ReplaceAll(nonDeterministicFieldsDescription, "`", "")) + "</p>\n")
+	sb.WriteString("<table>\n<tr><th>Application</th><th>Resource</th><th>Field</th></tr>\n")
+	for _, field := range f {
+		fmt.Fprintf(&sb, "<tr><td>%s</td><td><code>%s</code></td><td><code>%s</code></td></tr>\n",
+			html.EscapeString(field.App),
+			html.EscapeString(nonDeterministicResource(field)),
+			html.EscapeString(field.Path),
+		)
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
@@ -0,0 +1,32 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNonDeterministicFields(t *testing.T) {
+	fields := NonDeterministicFields{
+		{App: "db", APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "db", Path: "/data/password"},
+		{App: "cert-manager", APIVersion: "admissionregistration.k8s.io/v1", Kind: "ValidatingWebhookConfiguration", Name: "webhook", Path: "/webhooks/0/clientConfig/caBundle"},
+	}
+
+	markdown := fields.Markdown()
+	for _, expected := range []string{
+		"### Non-deterministic fields",
+		"| db | `Secret default/db` | `/data/password` |",
+		"| cert-manager | `ValidatingWebhookConfiguration webhook` | `/webhooks/0/clientConfig/caBundle` |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	if !strings.Contains(fields.HTML(), "<tr><td>db</td><td><code>Secret default/db</code></td><td><code>/data/password</code></td></tr>") {
+		t.Errorf("unexpected HTML:\n%s", fields.HTML())
+	}
+
+	if NonDeterministicFields(nil).Markdown() != "" || NonDeterministicFields(nil).HTML() != "" {
+		t.Errorf("expected no output without non-deterministic fields")
+	}
+}
@@ -0,0 +1,236 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/utils"
+)
+
+const planJSONFile = "plan.json"
+
+// PlanCounts is the number of resources per action
+type PlanCounts struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+	Replace int `json:"replace"`
+	Move    int `json:"move"`
+}
+
+// PlanKind is the planned actions for the resources of a kind in an Application
+type PlanKind struct {
+	Kind string `json:"kind"`
+	PlanCounts
+}
+
+// PlanApp is the planned actions for the resources of an Application
+type PlanApp struct {
+	Name  string     `json:"name"`
+	Kinds []PlanKind `json:"kinds"`
+}
+
+// PlanReplacement is a resource that is deleted and created instead of updated
+type PlanReplacement struct {
+	App       string `json:"app"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason"`
+}
+
+// Plan is what syncing the target branch does to the resources of the changed Applications
+type Plan struct {
+	PlanCounts
+	Apps         []PlanApp         `json:"apps"`
+	Replacements []PlanReplacement `json:"replacements"`
+}
+
+// BuildPlan counts the changed resources of the app diffs by Application and kind
+func BuildPlan(diffs []matching.AppDiff) Plan {
+	plan := Plan{Apps: []PlanApp{}, Replacements: []PlanReplacement{}}
+
+	for _, d := range diffs {
+		if len(d.Changes) == 0 {
+			continue
+		}
+
+		kinds := map[string]*PlanCounts{}
+		for _, change := range d.Changes {
+			if kinds[change.Kind] == nil {
+				kinds[change.Kind] = &PlanCounts{}
+			}
+			kinds[change.Kind].add(change.Action)
+			plan.add(change.Action)
+
+			if change.Action == matching.ResourceReplaced {
+				plan.Replacements = append(plan.Replacements, PlanReplacement{
+					App:       d.PrettyName(),
+					Kind:      change.Kind,
+					Namespace: change.Namespace,
+					Name:      change.Name,
+					Reason:    change.ReplaceReason,
+				})
+			}
+		}
+
+		app := PlanApp{Name: d.PrettyName()}
+		for kind, counts := range kinds {
+			app.Kinds = append(app.Kinds, PlanKind{Kind: kind, PlanCounts: *counts})
+		}
+		sort.Slice(app.Kinds, func(i, j int) bool { return app.Kinds[i].Kind < app.Kinds[j].Kind })
+		plan.Apps = append(plan.Apps, app)
+	}
+
+	sort.SliceStable(plan.Apps, func(i, j int) bool { return plan.Apps[i].Name < plan.Apps[j].Name })
+	sort.SliceStable(plan.Replacements, func(i, j int) bool { return plan.Replacements[i].App < plan.Replacements[j].App })
+
+	return plan
+}
+
+func (c *PlanCounts) add(action matching.ResourceAction) {
+	switch action {
+	case matching.ResourceAdded:
+		c.Add++
+	case matching.ResourceChanged:
+		c.Change++
+	case matching.ResourceDeleted:
+		c.Destroy++
+	case matching.ResourceReplaced:
+		c.Replace++
+	case matching.ResourceMoved:
+		c.Move++
+	}
+}
+
+// String returns the counts like 'Plan: 3 to add, 7 to change, 1 to destroy, 2 to replace'.
+// Moves are only mentioned if there are any.
+func (c PlanCounts) String() string {
+	s := fmt.Sprintf("Plan: %d to add, %d to change, %d to destroy, %d to replace", c.Add, c.Change, c.Destroy, c.Replace)
+	if c.Move > 0 {
+		s += fmt.Sprintf(", %d to move", c.Move)
+	}
+	return s
+}
+
+func (p Plan) isEmpty() bool {
+	return len(p.Apps) == 0
+}
+
+// columns returns the table header and the counts of a row. The move column is only
+// included if resources are moved.
+func (p Plan) columns() []string {
+	columns := []string{"Add", "Change", "Destroy", "Replace"}
+	if p.Move > 0 {
+		columns = append(columns, "Move")
+	}
+	return columns
+}
+
+func (p Plan) row(c PlanCounts) []string {
+	row := []string{fmt.Sprint(c.Add), fmt.Sprint(c.Change), fmt.Sprint(c.Destroy), fmt.Sprint(c.Replace)}
+	if p.Move > 0 {
+		row = append(row, fmt.Sprint(c.Move))
+	}
+	return row
+}
+
+func (r PlanReplacement) resource() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// Markdown returns the plan with a collapsible table per Application and kind, or an empty
+// string if no resources change
+func (p Plan) Markdown() string {
+	if p.isEmpty() {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s**\n\n", p.String())
+
+	columns := p.columns()
+	sb.WriteString("<details>\n<summary>Resources per Application</summary>\n<br>\n\n")
+	fmt.Fprintf(&sb, "| Application | Kind | %s |\n", strings.Join(columns, " | "))
+	fmt.Fprintf(&sb, "|---|---|%s\n", strings.Repeat("---|", len(columns)))
+	for _, app := range p.Apps {
+		for _, kind := range app.Kinds {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", app.Name, kind.Kind, strings.Join(p.row(kind.PlanCounts), " | "))
+		}
+	}
+	sb.WriteString("</details>\n\n")
+
+	if len(p.Replacements) > 0 {
+		sb.WriteString("Replaced resources:\n")
+		for _, r := range p.Replacements {
+			fmt.Fprintf(&sb, "- `%s` in %s (%s)\n", r.resource(), r.App, r.Reason)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// HTML returns the plan with a collapsible table per Application and kind, or an empty
+// string if no resources change
+func (p Plan) HTML() string {
+	if p.isEmpty() {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<p><strong>%s</strong></p>\n", html.EscapeString(p.String()))
+
+	sb.WriteString("<details>\n<summary>Resources per Application</summary>\n<table>\n<tr><th>Application</th><th>Kind</th>")
+	for _, column := range p.columns() {
+		fmt.Fprintf(&sb, "<th>%s</th>", column)
+	}
+	sb.WriteString("</tr>\n")
+	for _, app := range p.Apps {
+		for _, kind := range app.Kinds {
+			fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td>", html.EscapeString(app.Name), html.EscapeString(kind.Kind))
+			for _, count := range p.row(kind.PlanCounts) {
+				fmt.Fprintf(&sb, "<td>%s</td>", count)
+			}
+			sb.WriteString("</tr>\n")
+		}
+	}
+	sb.WriteString("</table>\n</details>\n")
+
+	if len(p.Replacements) > 0 {
+		sb.WriteString("<p>Replaced resources:</p>\n<ul>\n")
+		for _, r := range p.Replacements {
+			fmt.Fprintf(&sb, "<li><code>%s</code> in %s (%s)</li>\n",
+				html.EscapeString(r.resource()), html.EscapeString(r.App), html.EscapeString(r.Reason))
+		}
+		sb.WriteString("</ul>\n")
+	}
+	return sb.String()
+}
+
+// JSON returns the plan as indented JSON
+func (p Plan) JSON() (string, error) {
+	bytes, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// WriteToFolder writes the plan to plan.json in the output folder
+func (p Plan) WriteToFolder(outputFolder string) error {
+	content, err := p.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	if err := utils.WriteFile(fmt.Sprintf("%s/%s", outputFolder, planJSONFile), content); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
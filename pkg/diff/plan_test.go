@@ -0,0 +1,165 @@
+package diff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+)
+
+func planTestDiffs() []matching.AppDiff {
+	return []matching.AppDiff{
+		{
+			NewName: "web",
+			Action:  matching.ActionModified,
+			Changes: []matching.ResourceChange{
+				{Kind: "Deployment", Namespace: "default", Name: "web", Action: matching.ResourceChanged},
+				{Kind: "Deployment", Namespace: "default", Name: "worker", Action: matching.ResourceAdded},
+				{Kind: "Service", Namespace: "default", Name: "web", Action: matching.ResourceReplaced, ReplaceReason: "renamed from default/frontend"},
+			},
+		},
+		{
+			OldName: "api",
+			Action:  matching.ActionDeleted,
+			Changes: []matching.ResourceChange{
+				{Kind: "ConfigMap", Namespace: "default", Name: "api", Action: matching.ResourceDeleted},
+			},
+		},
+		{
+			OldName: "renamed",
+			NewName: "renamed-app",
+			Action:  matching.ActionModified,
+		},
+	}
+}
+
+func TestBuildPlan(t *testing.T) {
+	plan := BuildPlan(planTestDiffs())
+
+	expected := PlanCounts{Add: 1, Change: 1, Destroy: 1, Replace: 1}
+	if plan.PlanCounts != expected {
+		t.Errorf("expected counts %+v, got %+v", expected, plan.PlanCounts)
+	}
+
+	if len(plan.Apps) != 2 {
+		t.Fatalf("expected 2 apps with changes, got %d", len(plan.Apps))
+	}
+	if plan.Apps[0].Name != "api" || plan.Apps[1].Name != "web" {
+		t.Errorf("expected apps to be sorted by name, got %s and %s", plan.Apps[0].Name, plan.Apps[1].Name)
+	}
+
+	web := plan.Apps[1]
+	if len(web.Kinds) != 2 || web.Kinds[0].Kind != "Deployment" || web.Kinds[1].Kind != "Service" {
+		t.Fatalf("expected Deployment and Service, got %+v", web.Kinds)
+	}
+	if web.Kinds[0].PlanCounts != (PlanCounts{Add: 1, Change: 1}) {
+		t.Errorf("unexpected Deployment counts: %+v", web.Kinds[0].PlanCounts)
+	}
+
+	if len(plan.Replacements) != 1 {
+		t.Fatalf("expected 1 replacement, got %d", len(plan.Replacements))
+	}
+	replacement := plan.Replacements[0]
+	if replacement.App != "web" || replacement.resource() != "Service default/web" || replacement.Reason != "renamed from default/frontend" {
+		t.Errorf("unexpected replacement: %+v", replacement)
+	}
+}
+
+func TestPlanString(t *testing.T) {
+	counts := PlanCounts{Add: 3, Change: 7, Destroy: 1, Replace: 2}
+	if s := counts.String(); s != "Plan: 3 to add, 7 to change, 1 to destroy, 2 to replace" {
+		t.Errorf("unexpected plan: %s", s)
+	}
+
+	counts.Move = 1
+	if s := counts.String(); s != "Plan: 3 to add, 7 to change, 1 to destroy, 2 to replace, 1 to move" {
+		t.Errorf("unexpected plan with moves: %s", s)
+	}
+}
+
+func TestPlanMarkdown(t *testing.T) {
+	markdown := BuildPlan(planTestDiffs()).Markdown()
+
+	for _, expected := range []string{
+		"**Plan: 1 to add, 1 to change, 1 to destroy, 1 to replace**",
+		"| Application | Kind | Add | Change | Destroy | Replace |",
+		"| web | Deployment | 1 | 1 | 0 | 0 |",
+		"| api | ConfigMap | 0 | 0 | 1 | 0 |",
+		"- `Service default/web` in web (renamed from default/frontend)",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Contains(markdown, "Move") {
+		t.Errorf("expected no move column without moves, got:\n%s", markdown)
+	}
+}
+
+func TestPlanMarkdown_Moves(t *testing.T) {
+	plan := BuildPlan([]matching.AppDiff{{
+		NewName: "backend",
+		Action:  matching.ActionModified,
+		Changes: []matching.ResourceChange{{Kind: "Deployment", Name: "web", Action: matching.ResourceMoved}},
+	}})
+
+	markdown := plan.Markdown()
+	if !strings.Contains(markdown, "| Application | Kind | Add | Change | Destroy | Replace | Move |") {
+		t.Errorf("expected a move column, got:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, "| backend | Deployment | 0 | 0 | 0 | 0 | 1 |") {
+		t.Errorf("expected the move to be counted, got:\n%s", markdown)
+	}
+}
+
+func TestPlanHTML(t *testing.T) {
+	output := BuildPlan(planTestDiffs()).HTML()
+
+	for _, expected := range []string{
+		"<p><strong>Plan: 1 to add, 1 to change, 1 to destroy, 1 to replace</strong></p>",
+		"<tr><td>web</td><td>Service</td><td>0</td><td>0</td><td>0</td><td>1</td></tr>",
+		"<li><code>Service default/web</code> in web (renamed from default/frontend)</li>",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestPlan_Empty(t *testing.T) {
+	plan := BuildPlan(nil)
+	if plan.Markdown() != "" || plan.HTML() != "" {
+		t.Errorf("expected no output for an empty plan")
+	}
+
+	content, err := plan.JSON()
+	if err != nil {
+		t.Fatalf("failed to marshal plan: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(content), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal plan: %v", err)
+	}
+	if apps, ok := decoded["apps"].([]any); !ok || len(apps) != 0 {
+		t.Errorf("expected an empty list of apps, got %v", decoded["apps"])
+	}
+}
+
+func TestPlanJSON(t *testing.T) {
+	content, err := BuildPlan(planTestDiffs()).JSON()
+	if err != nil {
+		t.Fatalf("failed to marshal plan: %v", err)
+	}
+
+	var decoded Plan
+	if err := json.Unmarshal([]byte(content), &decoded); err != nil {
+		t.Fatalf("failed to unmarshal plan: %v", err)
+	}
+	if decoded.Replace != 1 || len(decoded.Apps) != 2 || decoded.Apps[1].Kinds[1].Replace != 1 {
+		t.Errorf("unexpected plan: %+v", decoded)
+	}
+	if !strings.Contains(content, `"add": 1`) {
+		t.Errorf("expected the counts at the top level, got:\n%s", content)
+	}
+}
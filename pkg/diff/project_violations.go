@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/appproject"
+)
+
+// ProjectViolations are the things the AppProjects do not permit the Applications in the
+// target branch to do
+type ProjectViolations []appproject.Violation
+
+// NewProjectViolations sorts the violations by file and Application
+func NewProjectViolations(violations []appproject.Violation) ProjectViolations {
+	sorted := slices.Clone(violations)
+	slices.SortStableFunc(sorted, func(a, b appproject.Violation) int {
+		return cmp.Or(
+			cmp.Compare(a.FileName, b.FileName),
+			cmp.Compare(a.App, b.App),
+		)
+	})
+	return sorted
+}
+
+const projectViolationsDescription = "The AppProjects in the target branch do not permit the following. The diff renders these Applications anyway, but they will fail to sync."
+
+// Markdown returns the violations as a markdown table, or an empty string if there are none
+func (v ProjectViolations) Markdown() string {
+	if len(v) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### ⚠️ AppProject violations\n\n")
+	sb.WriteString(projectViolationsDescription + "\n\n")
+	sb.WriteString("| Application | File | Project | Violation |\n")
+	sb.WriteString("| ----------- | ---- | ------- | --------- |\n")
+	for _, violation := range v {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n",
+			escapeMarkdownTableCell(violation.App),
+			escapeMarkdownTableCell(violation.FileName),
+			escapeMarkdownTableCell(violation.Project),
+			escapeMarkdownTableCell(violation.Message),
+		)
+	}
+	return sb.String() + "\n"
+}
+
+// HTML returns the violations as an HTML table, or an empty string if there are none
+func (v ProjectViolations) HTML() string {
+	if len(v) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>⚠️ AppProject violations</h3>\n")
+	sb.WriteString("<p>" + projectViolationsDescription + "</p>\n")
+	sb.WriteString("<table>\n<tr><th>Application</th><th>File</th><th>Project</th><th>Violation</th></tr>\n")
+	for _, violation := range v {
+		fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(violation.App),
+			html.EscapeString(violation.FileName),
+			html.EscapeString(violation.Project),
+			html.EscapeString(violation.Message),
+		)
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
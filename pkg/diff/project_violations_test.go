@@ -0,0 +1,58 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/appproject"
+)
+
+func TestNewProjectViolations_SortsByFile(t *testing.T) {
+	violations := NewProjectViolations([]appproject.Violation{
+		{App: "b", FileName: "apps/b.yaml", Project: "team", Message: "destination server 'x' and namespace 'y' is not in destinations"},
+		{App: "a", FileName: "apps/a.yaml", Project: "team", Message: "source repository 'z' is not in sourceRepos"},
+	})
+
+	if len(violations) != 2 {
+		t.Fatalf("expected 2 violations, got %d", len(violations))
+	}
+	if violations[0].FileName != "apps/a.yaml" {
+		t.Errorf("expected violations sorted by file, got %s", violations[0].FileName)
+	}
+}
+
+func TestProjectViolations_Empty(t *testing.T) {
+	var violations ProjectViolations
+	if violations.Markdown() != "" {
+		t.Errorf("expected empty markdown, got %q", violations.Markdown())
+	}
+	if violations.HTML() != "" {
+		t.Errorf("expected empty HTML, got %q", violations.HTML())
+	}
+}
+
+func TestProjectViolations_Output(t *testing.T) {
+	violations := ProjectViolations{
+		{App: "web", FileName: "apps/web.yaml", Project: "team-a", Message: "Namespace 'web' is not permitted because it is not in clusterResourceWhitelist"},
+	}
+
+	markdownOutput := MarkdownOutput{title: "Test", summary: "No changes found", projectViolations: violations}
+	markdown := markdownOutput.printDiff(65536)
+	for _, expected := range []string{
+		"### ⚠️ AppProject violations",
+		"| web | apps/web.yaml | team-a | Namespace 'web' is not permitted because it is not in clusterResourceWhitelist |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	htmlOutput := HTMLOutput{title: "Test", summary: "No changes found", projectViolations: violations}
+	html := htmlOutput.printDiff()
+	if !strings.Contains(html, "<h3>⚠️ AppProject violations</h3>") {
+		t.Errorf("expected AppProject violations section, got:\n%s", html)
+	}
+	if !strings.Contains(html, "<td>Namespace &#39;web&#39; is not permitted because it is not in clusterResourceWhitelist</td>") {
+		t.Errorf("expected escaped violation, got:\n%s", html)
+	}
+}
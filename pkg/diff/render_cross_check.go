@@ -0,0 +1,154 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	gitt "github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
+)
+
+// RenderDivergence is the diff between the manifests of an Application rendered with two
+// render methods
+type RenderDivergence struct {
+	Branch       gitt.BranchType
+	App          string
+	Content      string // diff text (with +/-/space prefixes) of the resources that differ
+	AddedLines   int
+	DeletedLines int
+}
+
+// RenderMethodCrossCheck is the result of rendering the same Applications with a second
+// render method (--cross-check-render-method). It is empty if the check did not run.
+type RenderMethodCrossCheck struct {
+	Method           string
+	CrossCheckMethod string
+	Divergences      []RenderDivergence
+	Error            string // set if rendering with the cross-check method failed
+}
+
+// BuildRenderDivergences diffs the manifests of the Applications of a branch rendered with
+// two render methods, using the same matching as the preview. Only Applications rendered by
+// both methods are compared, since only one of the methods discovers child Applications.
+func BuildRenderDivergences(
+	branch gitt.BranchType,
+	apps []extract.ExtractedApp,
+	crossCheckApps []extract.ExtractedApp,
+	contextLines uint,
+	ignoreResourceRules []resource_filter.IgnoreResourceRule,
+) ([]RenderDivergence, error) {
+	crossCheckIDs := make(map[string]bool, len(crossCheckApps))
+	for _, app := range crossCheckApps {
+		crossCheckIDs[app.Id] = true
+	}
+	appIDs := make(map[string]bool, len(apps))
+	var renderedByBoth []extract.ExtractedApp
+	for _, app := range apps {
+		appIDs[app.Id] = true
+		if crossCheckIDs[app.Id] {
+			renderedByBoth = append(renderedByBoth, app)
+		}
+	}
+	var crossCheckRenderedByBoth []extract.ExtractedApp
+	for _, app := range crossCheckApps {
+		if appIDs[app.Id] {
+			crossCheckRenderedByBoth = append(crossCheckRenderedByBoth, app)
+		}
+	}
+
+	appDiffs, err := matching.GenerateAppDiffs(renderedByBoth, crossCheckRenderedByBoth, matching.DiffOptions{ContextLines: contextLines, IgnoreResourceRules: ignoreResourceRules})
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff the renders of the %s-branch: %w", branch, err)
+	}
+
+	var divergences []RenderDivergence
+	for _, d := range appDiffs {
+		if d.AddedLines == 0 && d.DeletedLines == 0 {
+			continue
+		}
+		var content strings.Builder
+		for _, r := range d.Resources {
+			if r.IsSkipped || r.Content == "" {
+				continue
+			}
+			fmt.Fprintf(&content, "@@ %s @@\n%s\n", r.Header(), strings.TrimRight(r.Content, "\n"))
+		}
+		divergences = append(divergences, RenderDivergence{
+			Branch:       branch,
+			App:          d.PrettyName(),
+			Content:      content.String(),
+			AddedLines:   d.AddedLines,
+			DeletedLines: d.DeletedLines,
+		})
+	}
+	return divergences, nil
+}
+
+func (c RenderMethodCrossCheck) description() string {
+	return fmt.Sprintf("The Applications were rendered with both `%s` and `%s`. Removed lines are from `%s`, added lines from `%s`.",
+		c.Method, c.CrossCheckMethod, c.Method, c.CrossCheckMethod)
+}
+
+func (d RenderDivergence) title() string {
+	return fmt.Sprintf("%s (%s-branch) (+%d|-%d)", d.App, d.Branch, d.AddedLines, d.DeletedLines)
+}
+
+// Markdown returns the divergences as collapsible diffs per Application, or an empty string
+// if the check did not run
+func (c RenderMethodCrossCheck) Markdown() string {
+	markdown, _ := c.markdownWithin(math.MaxInt)
+	return markdown
+}
+
+// markdownWithin returns the Markdown of the divergences that fit in maxSize, and whether
+// it was truncated
+func (c RenderMethodCrossCheck) markdownWithin(maxSize int) (string, bool) {
+	if c.CrossCheckMethod == "" {
+		return "", false
+	}
+
+	heading := "### Render method cross-check\n\n"
+	switch {
+	case c.Error != "":
+		return buildMarkdownDetails(heading+fmt.Sprintf("⚠️ Rendering with `%s` failed: %s\n\n", c.CrossCheckMethod, c.Error), nil, maxSize)
+	case len(c.Divergences) == 0:
+		return buildMarkdownDetails(heading+fmt.Sprintf("✅ All Applications render the same with `%s` and `%s`.\n\n", c.Method, c.CrossCheckMethod), nil, maxSize)
+	}
+
+	blocks := make([]markdownDetails, len(c.Divergences))
+	for i, d := range c.Divergences {
+		blocks[i] = markdownDetails{summary: d.title(), diffs: []markdownDiff{{content: d.Content}}}
+	}
+	return buildMarkdownDetails(heading+c.description()+"\n\n", blocks, maxSize)
+}
+
+// HTML returns the divergences as collapsible diffs per Application, or an empty string if
+// the check did not run
+func (c RenderMethodCrossCheck) HTML() string {
+	if c.CrossCheckMethod == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>Render method cross-check</h3>\n")
+	switch {
+	case c.Error != "":
+		fmt.Fprintf(&sb, "<p>⚠️ Rendering with %s failed: %s</p>\n", html.EscapeString(c.CrossCheckMethod), html.EscapeString(c.Error))
+		return sb.String()
+	case len(c.Divergences) == 0:
+		fmt.Fprintf(&sb, "<p>✅ All Applications render the same with %s and %s.</p>\n", html.EscapeString(c.Method), html.EscapeString(c.CrossCheckMethod))
+		return sb.String()
+	}
+
+	sb.WriteString("<p>" + html.EscapeString(strings.ReplaceAll(c.description(), "`", "")) + "</p>\n")
+	for _, d := range c.Divergences {
+		fmt.Fprintf(&sb, "<details>\n<summary>\n%s\n</summary>\n", html.EscapeString(d.title()))
+		writeHTMLDiffTable(&sb, d.Content)
+		sb.WriteString("</details>\n")
+	}
+	return sb.String()
+}
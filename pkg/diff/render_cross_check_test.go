@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+func TestBuildRenderDivergences(t *testing.T) {
+	const (
+		config     = `{apiVersion: v1, kind: ConfigMap, metadata: {name: config, namespace: default}, data: {key: value}}`
+		service    = `{apiVersion: v1, kind: Service, metadata: {name: web, namespace: default}, spec: {ports: [{port: 80}]}}`
+		newService = `{apiVersion: v1, kind: Service, metadata: {name: web, namespace: default}, spec: {ports: [{port: 8080}]}}`
+	)
+
+	apps := []extract.ExtractedApp{
+		sharedResourceTestManifests(t, "web", config, service),
+		sharedResourceTestManifests(t, "same", config),
+		sharedResourceTestManifests(t, "parent", config),
+	}
+	crossCheckApps := []extract.ExtractedApp{
+		sharedResourceTestManifests(t, "web", config, newService),
+		sharedResourceTestManifests(t, "same", config),
+		sharedResourceTestManifests(t, "child", service),
+	}
+
+	divergences, err := BuildRenderDivergences(git.Target, apps, crossCheckApps, 1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(divergences) != 1 {
+		t.Fatalf("expected only the app that renders differently, got %+v", divergences)
+	}
+	d := divergences[0]
+	if d.App != "web" || d.Branch != git.Target || d.AddedLines != 1 || d.DeletedLines != 1 {
+		t.Errorf("unexpected divergence: %+v", d)
+	}
+	if !strings.Contains(d.Content, "@@ Service: default/web @@") || !strings.Contains(d.Content, "-  - port: 80") || !strings.Contains(d.Content, "+  - port: 8080") {
+		t.Errorf("unexpected content:\n%s", d.Content)
+	}
+
+	crossCheck := RenderMethodCrossCheck{Method: "server-api", CrossCheckMethod: "repo-server-api", Divergences: divergences}
+	markdown := crossCheck.Markdown()
+	for _, expected := range []string{
+		"### Render method cross-check",
+		"Removed lines are from `server-api`, added lines from `repo-server-api`.",
+		"<summary>web (target-branch) (+1|-1)</summary>",
+		"```diff\n@@ Service: default/web @@",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+	if !strings.Contains(crossCheck.HTML(), "<h3>Render method cross-check</h3>") {
+		t.Errorf("unexpected HTML:\n%s", crossCheck.HTML())
+	}
+}
+
+func TestRenderMethodCrossCheck_Markdown(t *testing.T) {
+	if (RenderMethodCrossCheck{}).Markdown() != "" || (RenderMethodCrossCheck{}).HTML() != "" {
+		t.Errorf("expected no output when the check did not run")
+	}
+
+	same := RenderMethodCrossCheck{Method: "server-api", CrossCheckMethod: "repo-server-api"}
+	if !strings.Contains(same.Markdown(), "✅ All Applications render the same with `server-api` and `repo-server-api`.") {
+		t.Errorf("unexpected markdown:\n%s", same.Markdown())
+	}
+
+	failed := RenderMethodCrossCheck{Method: "server-api", CrossCheckMethod: "repo-server-api", Error: "failed to render app web"}
+	if !strings.Contains(failed.Markdown(), "⚠️ Rendering with `repo-server-api` failed: failed to render app web") {
+		t.Errorf("unexpected markdown:\n%s", failed.Markdown())
+	}
+}
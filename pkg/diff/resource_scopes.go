@@ -0,0 +1,54 @@
+package diff
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/appproject"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+)
+
+// ResourceScopes tells whether each known kind is namespaced. Kinds it does not know are
+// looked up with appproject.IsClusterScoped.
+type ResourceScopes map[schema.GroupKind]bool
+
+// NewResourceScopes combines the scopes discovered in the cluster with the scopes of the
+// CustomResourceDefinitions rendered by the Applications. A rendered CustomResourceDefinition
+// wins over the cluster, because the cluster does not have to run the same version of it.
+func NewResourceScopes(discovered map[schema.GroupKind]bool, manifests ...[]extract.ExtractedApp) ResourceScopes {
+	scopes := make(ResourceScopes, len(discovered))
+	for groupKind, namespaced := range discovered {
+		scopes[groupKind] = namespaced
+	}
+	for _, apps := range manifests {
+		for _, app := range apps {
+			for i := range app.Manifests {
+				addCRDScope(scopes, &app.Manifests[i])
+			}
+		}
+	}
+	return scopes
+}
+
+func addCRDScope(scopes ResourceScopes, manifest *unstructured.Unstructured) {
+	gvk := manifest.GroupVersionKind()
+	if gvk.Group != "apiextensions.k8s.io" || gvk.Kind != "CustomResourceDefinition" {
+		return
+	}
+	group, _, _ := unstructured.NestedString(manifest.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(manifest.Object, "spec", "names", "kind")
+	scope, _, _ := unstructured.NestedString(manifest.Object, "spec", "scope")
+	if kind == "" || (scope != "Namespaced" && scope != "Cluster") {
+		return
+	}
+	scopes[schema.GroupKind{Group: group, Kind: kind}] = scope == "Namespaced"
+}
+
+// IsClusterScoped tells whether a resource is cluster-scoped. Kinds without a known scope
+// are guessed with appproject.IsClusterScoped.
+func (s ResourceScopes) IsClusterScoped(manifest *unstructured.Unstructured) bool {
+	if namespaced, found := s[manifest.GroupVersionKind().GroupKind()]; found {
+		return !namespaced
+	}
+	return appproject.IsClusterScoped(manifest)
+}
@@ -0,0 +1,242 @@
+package diff
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/utils"
+)
+
+const (
+	selectionReportJSONFile     = "selection-report.json"
+	selectionReportMarkdownFile = "selection-report.md"
+)
+
+// SelectionReportEntry explains why a single Application[Set] was selected or skipped
+type SelectionReportEntry struct {
+	Branch   git.BranchType                `json:"branch"`
+	Kind     string                        `json:"kind"`
+	Name     string                        `json:"name"`
+	File     string                        `json:"file"`
+	Selected bool                          `json:"selected"`
+	Rule     argoapplication.SelectionRule `json:"rule"`
+	Reason   string                        `json:"reason"`
+}
+
+// ExcludedPathEntry is a file or folder that was not searched for Application[Sets]
+type ExcludedPathEntry struct {
+	Branch git.BranchType            `json:"branch"`
+	Path   string                    `json:"path"`
+	Rule   fileparsing.ExclusionRule `json:"rule"`
+	Reason string                    `json:"reason"`
+}
+
+// SelectionReport lists every Application[Set] found in both branches and why it was selected or skipped
+type SelectionReport struct {
+	Applications  []SelectionReportEntry `json:"applications"`
+	ExcludedPaths []ExcludedPathEntry    `json:"excludedPaths"`
+
+	index         map[string]int  // position of each entry in Applications
+	excludedIndex map[string]bool // excluded paths already in ExcludedPaths
+}
+
+// NewSelectionReport creates an empty SelectionReport
+func NewSelectionReport() *SelectionReport {
+	return &SelectionReport{index: map[string]int{}, excludedIndex: map[string]bool{}}
+}
+
+// Add adds the selected and skipped Application[Sets] of a branch to the report.
+// Entries already in the report are updated, so Add can be called again after
+// ApplicationSets have been converted to Applications.
+func (r *SelectionReport) Add(branch git.BranchType, selection *argoapplication.ArgoSelection) {
+	if selection == nil {
+		return
+	}
+	for _, app := range selection.SelectedApps {
+		r.upsert(newSelectionReportEntry(branch, app, true))
+	}
+	for _, app := range selection.SkippedApps {
+		r.upsert(newSelectionReportEntry(branch, app, false))
+	}
+	for _, excluded := range selection.ExcludedFiles {
+		key := string(branch) + "|" + excluded.Path
+		if r.excludedIndex[key] {
+			continue
+		}
+		r.excludedIndex[key] = true
+		r.ExcludedPaths = append(r.ExcludedPaths, ExcludedPathEntry{
+			Branch: branch,
+			Path:   excluded.Path,
+			Rule:   excluded.Rule,
+			Reason: excluded.Reason,
+		})
+	}
+}
+
+func newSelectionReportEntry(branch git.BranchType, app argoapplication.ArgoResource, selected bool) SelectionReportEntry {
+	rule := app.Selection.Rule
+	reason := app.Selection.Reason
+	if rule == "" {
+		rule = argoapplication.RuleNoFilter
+		reason = "no selection rules limit the application"
+	}
+	return SelectionReportEntry{
+		Branch:   branch,
+		Kind:     app.Kind.ShortName(),
+		Name:     app.Name,
+		File:     app.FileName,
+		Selected: selected,
+		Rule:     rule,
+		Reason:   reason,
+	}
+}
+
+func (r *SelectionReport) upsert(entry SelectionReportEntry) {
+	key := strings.Join([]string{string(entry.Branch), entry.Kind, entry.Name, entry.File}, "|")
+	if index, exists := r.index[key]; exists {
+		r.Applications[index] = entry
+		return
+	}
+	r.index[key] = len(r.Applications)
+	r.Applications = append(r.Applications, entry)
+}
+
+// sorted returns the entries sorted by branch, file, kind and name
+func (r *SelectionReport) sorted() []SelectionReportEntry {
+	entries := slices.Clone(r.Applications)
+	slices.SortStableFunc(entries, func(a, b SelectionReportEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.Branch, b.Branch),
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return entries
+}
+
+// sortedExcludedPaths returns the excluded paths sorted by branch and path
+func (r *SelectionReport) sortedExcludedPaths() []ExcludedPathEntry {
+	entries := slices.Clone(r.ExcludedPaths)
+	slices.SortStableFunc(entries, func(a, b ExcludedPathEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.Branch, b.Branch),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+	return entries
+}
+
+// count returns the number of selected and skipped entries for a branch
+func (r *SelectionReport) count(branch git.BranchType) (selected int, skipped int) {
+	for _, e := range r.Applications {
+		if e.Branch != branch {
+			continue
+		}
+		if e.Selected {
+			selected++
+		} else {
+			skipped++
+		}
+	}
+	return selected, skipped
+}
+
+// JSON returns the report as indented JSON
+func (r *SelectionReport) JSON() (string, error) {
+	report := SelectionReport{Applications: r.sorted(), ExcludedPaths: r.sortedExcludedPaths()}
+	if report.Applications == nil {
+		report.Applications = []SelectionReportEntry{}
+	}
+	if report.ExcludedPaths == nil {
+		report.ExcludedPaths = []ExcludedPathEntry{}
+	}
+	bytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+// Markdown returns the report as a markdown table
+func (r *SelectionReport) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("## Application Selection\n\n")
+
+	for _, branch := range []git.BranchType{git.Base, git.Target} {
+		selected, skipped := r.count(branch)
+		fmt.Fprintf(&sb, "- %s: `%d` selected, `%d` skipped\n", branch, selected, skipped)
+	}
+
+	if len(r.Applications) == 0 {
+		sb.WriteString("\n_No Application[Sets] found_\n")
+		r.writeExcludedPathsMarkdown(&sb)
+		return sb.String()
+	}
+
+	sb.WriteString("\n| Branch | Kind | Name | File | Selected | Rule | Reason |\n")
+	sb.WriteString("| ------ | ---- | ---- | ---- | -------- | ---- | ------ |\n")
+	for _, e := range r.sorted() {
+		selected := "no"
+		if e.Selected {
+			selected = "yes"
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | `%s` | %s |\n",
+			e.Branch,
+			e.Kind,
+			escapeMarkdownTableCell(e.Name),
+			escapeMarkdownTableCell(e.File),
+			selected,
+			e.Rule,
+			escapeMarkdownTableCell(e.Reason),
+		)
+	}
+
+	r.writeExcludedPathsMarkdown(&sb)
+	return sb.String()
+}
+
+// writeExcludedPathsMarkdown writes the paths that were not searched for Application[Sets]
+func (r *SelectionReport) writeExcludedPathsMarkdown(sb *strings.Builder) {
+	if len(r.ExcludedPaths) == 0 {
+		return
+	}
+
+	sb.WriteString("\n### Excluded paths\n")
+	sb.WriteString("\n| Branch | Path | Rule | Reason |\n")
+	sb.WriteString("| ------ | ---- | ---- | ------ |\n")
+	for _, e := range r.sortedExcludedPaths() {
+		fmt.Fprintf(sb, "| %s | %s | `%s` | %s |\n",
+			e.Branch,
+			escapeMarkdownTableCell(e.Path),
+			e.Rule,
+			escapeMarkdownTableCell(e.Reason),
+		)
+	}
+}
+
+// WriteToFolder writes the report to selection-report.json and selection-report.md in the output folder
+func (r *SelectionReport) WriteToFolder(outputFolder string) error {
+	jsonReport, err := r.JSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal selection report: %w", err)
+	}
+	if err := utils.WriteFile(fmt.Sprintf("%s/%s", outputFolder, selectionReportJSONFile), jsonReport); err != nil {
+		return fmt.Errorf("failed to write selection report: %w", err)
+	}
+	if err := utils.WriteFile(fmt.Sprintf("%s/%s", outputFolder, selectionReportMarkdownFile), r.Markdown()); err != nil {
+		return fmt.Errorf("failed to write selection report: %w", err)
+	}
+	return nil
+}
+
+func escapeMarkdownTableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,168 @@
+package diff
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/fileparsing"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+func reportApp(kind argoapplication.ApplicationKind, name, fileName string, rule argoapplication.SelectionRule, reason string) argoapplication.ArgoResource {
+	return argoapplication.ArgoResource{
+		Kind:      kind,
+		Id:        name,
+		Name:      name,
+		FileName:  fileName,
+		Selection: argoapplication.SelectionDecision{Rule: rule, Reason: reason},
+	}
+}
+
+func TestSelectionReport_Add(t *testing.T) {
+	report := NewSelectionReport()
+
+	report.Add(git.Base, &argoapplication.ArgoSelection{
+		SelectedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.ApplicationSet, "my-appset", "appset.yaml", argoapplication.RuleNoFilter, "no selection rules limit the application"),
+		},
+		SkippedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.Application, "ignored", "ignored.yaml", argoapplication.RuleIgnoreAnnotation, "application is ignored"),
+		},
+	})
+	report.Add(git.Target, &argoapplication.ArgoSelection{
+		SkippedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.Application, "unchanged", "unchanged.yaml", argoapplication.RuleIdenticalCopy, "application is identical in the base and target branch"),
+		},
+	})
+
+	if len(report.Applications) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(report.Applications))
+	}
+
+	// Adding the same application again updates the entry
+	report.Add(git.Base, &argoapplication.ArgoSelection{
+		SkippedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.ApplicationSet, "my-appset", "appset.yaml", argoapplication.RuleIdenticalCopy, "application is identical in the base and target branch"),
+		},
+	})
+
+	if len(report.Applications) != 3 {
+		t.Fatalf("expected 3 entries after update, got %d", len(report.Applications))
+	}
+	entry := report.Applications[0]
+	if entry.Selected || entry.Rule != argoapplication.RuleIdenticalCopy {
+		t.Errorf("expected updated entry to be skipped by %s, got selected=%t rule=%s", argoapplication.RuleIdenticalCopy, entry.Selected, entry.Rule)
+	}
+
+	selected, skipped := report.count(git.Base)
+	if selected != 0 || skipped != 2 {
+		t.Errorf("expected 0 selected and 2 skipped in base, got %d and %d", selected, skipped)
+	}
+}
+
+func TestSelectionReport_MissingDecisionDefaultsToNoFilter(t *testing.T) {
+	report := NewSelectionReport()
+	report.Add(git.Target, &argoapplication.ArgoSelection{
+		SelectedApps: []argoapplication.ArgoResource{{Kind: argoapplication.Application, Name: "app", FileName: "app.yaml"}},
+	})
+
+	if report.Applications[0].Rule != argoapplication.RuleNoFilter {
+		t.Errorf("expected rule %s, got %s", argoapplication.RuleNoFilter, report.Applications[0].Rule)
+	}
+}
+
+func TestSelectionReport_Markdown(t *testing.T) {
+	report := NewSelectionReport()
+	report.Add(git.Target, &argoapplication.ArgoSelection{
+		SelectedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.Application, "b-app", "b.yaml", argoapplication.RuleWatchPattern, "files changed matches watch-pattern 'a|b'"),
+		},
+		SkippedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.Application, "a-app", "a.yaml", argoapplication.RuleSelector, "label not found"),
+		},
+	})
+
+	markdown := report.Markdown()
+
+	for _, want := range []string{
+		"- base: `0` selected, `0` skipped",
+		"- target: `1` selected, `1` skipped",
+		"| target | App | a-app | a.yaml | no | `selector` | label not found |",
+		"| target | App | b-app | b.yaml | yes | `watch-pattern` | files changed matches watch-pattern 'a\\|b' |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", want, markdown)
+		}
+	}
+
+	if strings.Index(markdown, "a-app") > strings.Index(markdown, "b-app") {
+		t.Errorf("expected entries to be sorted by file")
+	}
+}
+
+func TestSelectionReport_ExcludedPaths(t *testing.T) {
+	report := NewSelectionReport()
+	selection := &argoapplication.ArgoSelection{
+		ExcludedFiles: []fileparsing.ExcludedFile{
+			{Path: "test/", Rule: fileparsing.ExcludedByIgnoreFile, Reason: "folder matches 'test/' in .argocd-diff-preview-ignore"},
+			{Path: "charts/app.yaml", Rule: fileparsing.ExcludedByIncludeGlob, Reason: "file does not match any of 'apps/**'"},
+		},
+	}
+	report.Add(git.Base, selection)
+	report.Add(git.Base, selection)
+	report.Add(git.Target, selection)
+
+	if len(report.ExcludedPaths) != 4 {
+		t.Fatalf("expected 4 excluded paths, got %d", len(report.ExcludedPaths))
+	}
+
+	markdown := report.Markdown()
+	for _, want := range []string{
+		"_No Application[Sets] found_",
+		"### Excluded paths",
+		"| base | test/ | `ignore-file` | folder matches 'test/' in .argocd-diff-preview-ignore |",
+		"| target | charts/app.yaml | `include-files` | file does not match any of 'apps/**' |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestSelectionReport_WriteToFolder(t *testing.T) {
+	folder := t.TempDir()
+
+	report := NewSelectionReport()
+	report.Add(git.Base, &argoapplication.ArgoSelection{
+		SkippedApps: []argoapplication.ArgoResource{
+			reportApp(argoapplication.Application, "app", "apps/app.yaml", argoapplication.RuleSelector, "labels do not match selector 'team=a'"),
+		},
+	})
+
+	if err := report.WriteToFolder(folder); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(folder + "/selection-report.json")
+	if err != nil {
+		t.Fatalf("failed to read json report: %v", err)
+	}
+
+	var parsed SelectionReport
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("failed to parse json report: %v", err)
+	}
+	if len(parsed.Applications) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(parsed.Applications))
+	}
+	if parsed.Applications[0].Branch != git.Base || parsed.Applications[0].Rule != argoapplication.RuleSelector || parsed.Applications[0].Selected {
+		t.Errorf("unexpected entry: %+v", parsed.Applications[0])
+	}
+
+	if _, err := os.Stat(folder + "/selection-report.md"); err != nil {
+		t.Errorf("expected markdown report to be written: %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
+)
+
+const inClusterServer = "https://kubernetes.default.svc"
+
+// SharedResource is a resource rendered by more than one Application in the target branch.
+// Argo CD reports it with a SharedResourceWarning and the Applications keep overwriting it.
+type SharedResource struct {
+	Cluster   string
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+	Apps      []string // names of the Applications rendering the resource
+	New       bool     // the resource is not rendered by more than one Application in the base branch
+}
+
+// SharedResources are the resources rendered by more than one Application in the target branch
+type SharedResources []SharedResource
+
+type resourceKey struct {
+	cluster   string
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// appDestination is the cluster and namespace an Application deploys to
+type appDestination struct {
+	cluster   string
+	namespace string
+}
+
+// FindSharedResources finds the resources rendered by more than one Application in the target
+// branch. Resources without a namespace are placed in the destination namespace of their
+// Application, unless scopes tells they are cluster-scoped. Resources matching the ignore rules
+// are skipped.
+func FindSharedResources(
+	baseApps []argoapplication.ArgoResource,
+	targetApps []argoapplication.ArgoResource,
+	baseManifests []extract.ExtractedApp,
+	targetManifests []extract.ExtractedApp,
+	ignoreResourceRules []resource_filter.IgnoreResourceRule,
+	scopes ResourceScopes,
+) SharedResources {
+	sharedInBase := resourcesByApps(baseApps, baseManifests, ignoreResourceRules, scopes)
+
+	var shared SharedResources
+	for key, apps := range resourcesByApps(targetApps, targetManifests, ignoreResourceRules, scopes) {
+		if len(apps) < 2 {
+			continue
+		}
+		shared = append(shared, SharedResource{
+			Cluster:   key.cluster,
+			Group:     key.group,
+			Kind:      key.kind,
+			Namespace: key.namespace,
+			Name:      key.name,
+			Apps:      apps,
+			New:       len(sharedInBase[key]) < 2,
+		})
+	}
+
+	slices.SortFunc(shared, func(a, b SharedResource) int {
+		if a.New != b.New {
+			if a.New {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(
+			cmp.Compare(a.Cluster, b.Cluster),
+			cmp.Compare(a.Namespace, b.Namespace),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Group, b.Group),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	return shared
+}
+
+// resourcesByApps returns the sorted names of the Applications rendering each resource
+func resourcesByApps(
+	apps []argoapplication.ArgoResource,
+	manifests []extract.ExtractedApp,
+	ignoreResourceRules []resource_filter.IgnoreResourceRule,
+	scopes ResourceScopes,
+) map[resourceKey][]string {
+	destinations := make(map[string]appDestination, len(apps))
+	for _, app := range apps {
+		destinations[app.Id] = destinationOf(app.Unpatched())
+	}
+
+	result := map[resourceKey][]string{}
+	for _, extracted := range manifests {
+		destination := destinations[extracted.Id]
+		for i := range extracted.Manifests {
+			manifest := &extracted.Manifests[i]
+			if resource_filter.MatchesAnyIgnoreRule(manifest, ignoreResourceRules) {
+				continue
+			}
+			key := resourceKeyOf(manifest, destination, scopes)
+			if !slices.Contains(result[key], extracted.Name) {
+				result[key] = append(result[key], extracted.Name)
+			}
+		}
+	}
+	for _, names := range result {
+		slices.Sort(names)
+	}
+	return result
+}
+
+// resourceKeyOf returns where a resource of an Application is deployed
+func resourceKeyOf(manifest *unstructured.Unstructured, destination appDestination, scopes ResourceScopes) resourceKey {
+	namespace := manifest.GetNamespace()
+	if namespace == "" && !scopes.IsClusterScoped(manifest) {
+		namespace = destination.namespace
+	}
+	gvk := manifest.GroupVersionKind()
+	return resourceKey{
+		cluster:   destination.cluster,
+		group:     gvk.Group,
+		kind:      gvk.Kind,
+		namespace: namespace,
+		name:      manifest.GetName(),
+	}
+}
+
+func destinationOf(app *unstructured.Unstructured) appDestination {
+	server, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "server")
+	name, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "name")
+	namespace, _, _ := unstructured.NestedString(app.Object, "spec", "destination", "namespace")
+
+	cluster := server
+	if cluster == "" && name == "in-cluster" {
+		cluster = inClusterServer
+	} else if cluster == "" {
+		cluster = name
+	}
+	return appDestination{cluster: cluster, namespace: namespace}
+}
+
+// NewCount returns the number of resources that are only shared in the target branch
+func (s SharedResources) NewCount() int {
+	count := 0
+	for _, r := range s {
+		if r.New {
+			count++
+		}
+	}
+	return count
+}
+
+func (r SharedResource) resourceName() string {
+	kind := r.Kind
+	if r.Group != "" {
+		kind = fmt.Sprintf("%s.%s", r.Kind, r.Group)
+	}
+	return fmt.Sprintf("%s %s", kind, qualifiedResourceName(r.Namespace, r.Name))
+}
+
+func qualifiedResourceName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+func (r SharedResource) status() string {
+	if r.New {
+		return "🆕 new in this change"
+	}
+	return "also in base branch"
+}
+
+const sharedResourcesDescription = "The following resources are rendered by more than one Application. Argo CD reports a SharedResourceWarning and the Applications keep overwriting each other's changes."
+
+// Markdown returns the shared resources as a markdown table, or an empty string if there are none
+func (s SharedResources) Markdown() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### ⚠️ Resources rendered by multiple Applications\n\n")
+	sb.WriteString(sharedResourcesDescription + "\n\n")
+	sb.WriteString("| Resource | Cluster | Applications | Status |\n")
+	sb.WriteString("| -------- | ------- | ------------ | ------ |\n")
+	for _, r := range s {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n",
+			escapeMarkdownTableCell(r.resourceName()),
+			escapeMarkdownTableCell(r.Cluster),
+			escapeMarkdownTableCell(strings.Join(r.Apps, ", ")),
+			r.status(),
+		)
+	}
+	return sb.String() + "\n"
+}
+
+// HTML returns the shared resources as an HTML table, or an empty string if there are none
+func (s SharedResources) HTML() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>⚠️ Resources rendered by multiple Applications</h3>\n")
+	sb.WriteString("<p>" + html.EscapeString(sharedResourcesDescription) + "</p>\n")
+	sb.WriteString("<table>\n<tr><th>Resource</th><th>Cluster</th><th>Applications</th><th>Status</th></tr>\n")
+	for _, r := range s {
+		fmt.Fprintf(&sb, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(r.resourceName()),
+			html.EscapeString(r.Cluster),
+			html.EscapeString(strings.Join(r.Apps, ", ")),
+			r.status(),
+		)
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
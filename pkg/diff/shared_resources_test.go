@@ -0,0 +1,150 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+func sharedResourceTestApp(name, server, namespace string) argoapplication.ArgoResource {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": name},
+		"spec": map[string]any{
+			"destination": map[string]any{"server": server, "namespace": namespace},
+		},
+	}}
+	return *argoapplication.NewArgoResource(obj, argoapplication.Application, name, name, "apps/"+name+".yaml", git.Target)
+}
+
+func sharedResourceTestManifests(t *testing.T, name string, manifests ...string) extract.ExtractedApp {
+	t.Helper()
+	var resources []unstructured.Unstructured
+	for _, manifest := range manifests {
+		var obj unstructured.Unstructured
+		if err := yaml.Unmarshal([]byte(manifest), &obj.Object); err != nil {
+			t.Fatalf("failed to unmarshal manifest: %v", err)
+		}
+		resources = append(resources, obj)
+	}
+	return extract.CreateExtractedApp(name, name, "apps/"+name+".yaml", resources, git.Target, extract.ExtractedAppOptions{})
+}
+
+const (
+	sharedCRD       = `{apiVersion: apiextensions.k8s.io/v1, kind: CustomResourceDefinition, metadata: {name: widgets.example.com}}`
+	sharedConfigMap = `{apiVersion: v1, kind: ConfigMap, metadata: {name: config}}`
+	sharedService   = `{apiVersion: v1, kind: Service, metadata: {name: web, namespace: shared}}`
+)
+
+func TestFindSharedResources(t *testing.T) {
+	apps := []argoapplication.ArgoResource{
+		sharedResourceTestApp("a", "https://kubernetes.default.svc", "team-a"),
+		sharedResourceTestApp("b", "https://kubernetes.default.svc", "team-b"),
+		sharedResourceTestApp("c", "https://other-cluster", "team-a"),
+	}
+	base := []extract.ExtractedApp{
+		sharedResourceTestManifests(t, "a", sharedCRD, sharedConfigMap),
+		sharedResourceTestManifests(t, "b", sharedCRD),
+	}
+	target := []extract.ExtractedApp{
+		sharedResourceTestManifests(t, "a", sharedCRD, sharedConfigMap, sharedService),
+		sharedResourceTestManifests(t, "b", sharedCRD, sharedConfigMap, sharedService),
+		sharedResourceTestManifests(t, "c", sharedCRD, sharedConfigMap, sharedService),
+	}
+
+	shared := FindSharedResources(apps, apps, base, target, nil, nil)
+
+	// The ConfigMap has no namespace, so it is placed in the destination namespace of
+	// each Application and is not shared
+	if len(shared) != 2 {
+		t.Fatalf("expected 2 shared resources, got %d: %+v", len(shared), shared)
+	}
+	if shared[0].Kind != "Service" || !shared[0].New || strings.Join(shared[0].Apps, ",") != "a,b" {
+		t.Errorf("expected the new Service shared by a and b first, got %+v", shared[0])
+	}
+	if shared[0].Namespace != "shared" {
+		t.Errorf("expected the Service in namespace 'shared', got %q", shared[0].Namespace)
+	}
+	if shared[1].Kind != "CustomResourceDefinition" || shared[1].New || strings.Join(shared[1].Apps, ",") != "a,b" {
+		t.Errorf("expected the CRD shared by a and b in both branches, got %+v", shared[1])
+	}
+	if shared.NewCount() != 1 {
+		t.Errorf("expected 1 new shared resource, got %d", shared.NewCount())
+	}
+}
+
+func TestSharedResources_Output(t *testing.T) {
+	var empty SharedResources
+	if empty.Markdown() != "" || empty.HTML() != "" {
+		t.Errorf("expected empty output for no shared resources")
+	}
+
+	shared := SharedResources{
+		{Cluster: "https://kubernetes.default.svc", Group: "apps", Kind: "Deployment", Namespace: "web", Name: "web", Apps: []string{"a", "b"}, New: true},
+		{Cluster: "https://kubernetes.default.svc", Kind: "Namespace", Name: "web", Apps: []string{"a", "c"}},
+	}
+
+	markdown := shared.Markdown()
+	for _, expected := range []string{
+		"### ⚠️ Resources rendered by multiple Applications",
+		"| Deployment.apps web/web | https://kubernetes.default.svc | a, b | 🆕 new in this change |",
+		"| Namespace web | https://kubernetes.default.svc | a, c | also in base branch |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	html := shared.HTML()
+	if !strings.Contains(html, "<tr><td>Deployment.apps web/web</td><td>https://kubernetes.default.svc</td><td>a, b</td><td>🆕 new in this change</td></tr>") {
+		t.Errorf("expected HTML row for the Deployment, got:\n%s", html)
+	}
+}
+
+func TestFindSharedResources_ResourceScopes(t *testing.T) {
+	apps := []argoapplication.ArgoResource{
+		sharedResourceTestApp("a", "https://kubernetes.default.svc", "team-a"),
+		sharedResourceTestApp("b", "https://kubernetes.default.svc", "team-b"),
+	}
+	const (
+		gatewayCRD   = `{apiVersion: apiextensions.k8s.io/v1, kind: CustomResourceDefinition, metadata: {name: gatewayclasses.example.com}, spec: {group: example.com, names: {kind: GatewayClass}, scope: Cluster}}`
+		gatewayClass = `{apiVersion: example.com/v1, kind: GatewayClass, metadata: {name: default}}`
+		clusterCache = `{apiVersion: cache.example.com/v1, kind: ClusterCache, metadata: {name: cache}}`
+		storageClass = `{apiVersion: storage.k8s.io/v1, kind: StorageClass, metadata: {name: fast}}`
+	)
+	target := []extract.ExtractedApp{
+		sharedResourceTestManifests(t, "a", gatewayCRD, gatewayClass, clusterCache, storageClass),
+		sharedResourceTestManifests(t, "b", gatewayClass, clusterCache, storageClass),
+	}
+
+	discovered := map[schema.GroupKind]bool{
+		{Group: "cache.example.com", Kind: "ClusterCache"}: true,
+		{Group: "storage.k8s.io", Kind: "StorageClass"}:    false,
+	}
+	shared := FindSharedResources(apps, apps, nil, target, nil, NewResourceScopes(discovered, target))
+
+	// The GatewayClass is cluster-scoped by its rendered CRD and the ClusterCache is namespaced
+	// in the cluster, so only the GatewayClass and the StorageClass are shared
+	var names []string
+	for _, r := range shared {
+		names = append(names, r.resourceName())
+	}
+	if got := strings.Join(names, ","); got != "GatewayClass.example.com default,StorageClass.storage.k8s.io fast" {
+		t.Errorf("unexpected shared resources: %s", got)
+	}
+
+	// Without any known scopes the kinds are guessed
+	shared = FindSharedResources(apps, apps, nil, target, nil, nil)
+	names = nil
+	for _, r := range shared {
+		names = append(names, r.resourceName())
+	}
+	if got := strings.Join(names, ","); got != "ClusterCache.cache.example.com cache,StorageClass.storage.k8s.io fast" {
+		t.Errorf("unexpected shared resources without scopes: %s", got)
+	}
+}
@@ -0,0 +1,229 @@
+package diff
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SpecChange is the diff of an Application[Set] manifest, as written in the repository,
+// between the base and target branch
+type SpecChange struct {
+	Kind     argoapplication.ApplicationKind
+	Name     string
+	FileName string
+	Content  string   // diff text (with +/-/space prefixes)
+	Risks    []string // changes that affect how Argo CD syncs the application
+}
+
+// SpecChanges are the Application[Set] manifests that changed between the branches
+type SpecChanges []SpecChange
+
+// BuildSpecChanges diffs the unpatched manifests of the Application[Sets] found in both
+// branches. Applications are paired by kind, namespace, name and file. An Application moved
+// to another file is paired by kind, namespace and name, if that is unique in both branches.
+// Added and removed Application[Sets] are not included, since the rendered diff already
+// shows them.
+func BuildSpecChanges(baseApps, targetApps []argoapplication.ArgoResource, contextLines uint) (SpecChanges, error) {
+	var changes SpecChanges
+	for _, specs := range pairSpecs(baseApps, targetApps) {
+		app := specs.target
+		baseManifest := specs.base
+		targetManifest := app.Unpatched()
+
+		pair := matching.ResourcePair{Base: baseManifest, Target: targetManifest}
+		result, err := pair.Diff(contextLines)
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s '%s': %w", app.Kind.ShortName(), app.Name, err)
+		}
+		if result.Content == "" {
+			continue
+		}
+
+		changes = append(changes, SpecChange{
+			Kind:     app.Kind,
+			Name:     app.Name,
+			FileName: app.FileName,
+			Content:  result.Content,
+			Risks:    specRisks(app.Kind, baseManifest, targetManifest),
+		})
+	}
+
+	slices.SortFunc(changes, func(a, b SpecChange) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name), cmp.Compare(a.FileName, b.FileName))
+	})
+	return changes, nil
+}
+
+// specPair is an Application[Set] of the target branch and its unpatched manifest in the base branch
+type specPair struct {
+	base   *unstructured.Unstructured
+	target *argoapplication.ArgoResource
+}
+
+type specIdentity struct {
+	kind      argoapplication.ApplicationKind
+	namespace string
+	name      string
+}
+
+// pairSpecs pairs the Application[Sets] of the branches. The same name is often reused for
+// different environments in different files, so the file is part of the first match.
+func pairSpecs(baseApps, targetApps []argoapplication.ArgoResource) []specPair {
+	identity := func(app *argoapplication.ArgoResource) specIdentity {
+		return specIdentity{app.Kind, app.Unpatched().GetNamespace(), app.Name}
+	}
+	type fileKey struct {
+		identity specIdentity
+		fileName string
+	}
+
+	base := map[fileKey]int{}
+	for i := range baseApps {
+		base[fileKey{identity(&baseApps[i]), baseApps[i].FileName}] = i
+	}
+
+	var pairs []specPair
+	pairedBase := map[int]bool{}
+	var unpaired []int
+	for i := range targetApps {
+		b, found := base[fileKey{identity(&targetApps[i]), targetApps[i].FileName}]
+		if !found {
+			unpaired = append(unpaired, i)
+			continue
+		}
+		pairedBase[b] = true
+		pairs = append(pairs, specPair{base: baseApps[b].Unpatched(), target: &targetApps[i]})
+	}
+
+	// Pair the remaining Application[Sets] whose identity is unique in both branches
+	remainingBase := map[specIdentity][]int{}
+	for i := range baseApps {
+		if !pairedBase[i] {
+			remainingBase[identity(&baseApps[i])] = append(remainingBase[identity(&baseApps[i])], i)
+		}
+	}
+	remainingTarget := map[specIdentity][]int{}
+	for _, i := range unpaired {
+		remainingTarget[identity(&targetApps[i])] = append(remainingTarget[identity(&targetApps[i])], i)
+	}
+	for _, i := range unpaired {
+		id := identity(&targetApps[i])
+		if len(remainingBase[id]) == 1 && len(remainingTarget[id]) == 1 {
+			pairs = append(pairs, specPair{base: baseApps[remainingBase[id][0]].Unpatched(), target: &targetApps[i]})
+		}
+	}
+	return pairs
+}
+
+// specRisks returns the changes between the manifests that change what Argo CD deletes
+// or where it deploys to. For ApplicationSets the template is compared.
+func specRisks(kind argoapplication.ApplicationKind, base, target *unstructured.Unstructured) []string {
+	specPath := []string{"spec"}
+	if kind == argoapplication.ApplicationSet {
+		specPath = []string{"spec", "template", "spec"}
+	}
+	field := func(obj *unstructured.Unstructured, fields ...string) (any, bool) {
+		value, found, _ := unstructured.NestedFieldNoCopy(obj.Object, append(slices.Clone(specPath), fields...)...)
+		return value, found
+	}
+	str := func(obj *unstructured.Unstructured, fields ...string) string {
+		value, _ := field(obj, fields...)
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+
+	var risks []string
+
+	_, baseAutomated := field(base, "syncPolicy", "automated")
+	_, targetAutomated := field(target, "syncPolicy", "automated")
+	if !baseAutomated && targetAutomated {
+		risks = append(risks, "automated sync enabled")
+	}
+	if str(base, "syncPolicy", "automated", "prune") != "true" && str(target, "syncPolicy", "automated", "prune") == "true" {
+		risks = append(risks, "automated prune enabled")
+	}
+
+	for _, destinationField := range []string{"server", "name", "namespace"} {
+		if before, after := str(base, "destination", destinationField), str(target, "destination", destinationField); before != after {
+			risks = append(risks, fmt.Sprintf("destination %s changed: '%s' → '%s'", destinationField, before, after))
+		}
+	}
+
+	if before, after := str(base, "project"), str(target, "project"); before != after {
+		risks = append(risks, fmt.Sprintf("project changed: '%s' → '%s'", before, after))
+	}
+
+	return risks
+}
+
+// title returns the summary line of the change. Risky changes are marked so they stand
+// out while collapsed.
+func (c SpecChange) title() string {
+	title := fmt.Sprintf("%s: %s (%s)", c.Kind.ShortName(), c.Name, c.FileName)
+	if len(c.Risks) > 0 {
+		title += " ⚠️"
+	}
+	return title
+}
+
+// Markdown returns a collapsible section per changed manifest, or an empty string if
+// there are no changes
+func (s SpecChanges) Markdown() string {
+	markdown, _ := s.markdownWithin(math.MaxInt)
+	return markdown
+}
+
+// markdownWithin returns the Markdown of the changes that fit in maxSize, and whether it
+// was truncated
+func (s SpecChanges) markdownWithin(maxSize int) (string, bool) {
+	if len(s) == 0 {
+		return "", false
+	}
+
+	blocks := make([]markdownDetails, len(s))
+	for i, change := range s {
+		var intro strings.Builder
+		for _, risk := range change.Risks {
+			fmt.Fprintf(&intro, "- ⚠️ %s\n", risk)
+		}
+		if len(change.Risks) > 0 {
+			intro.WriteString("\n")
+		}
+		blocks[i] = markdownDetails{
+			summary: change.title(),
+			intro:   intro.String(),
+			diffs:   []markdownDiff{{content: change.Content}},
+		}
+	}
+	return buildMarkdownDetails("### Application spec changes\n\n", blocks, maxSize)
+}
+
+// HTML returns a collapsible section per changed manifest, or an empty string if there
+// are no changes
+func (s SpecChanges) HTML() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>Application spec changes</h3>\n")
+	for _, change := range s {
+		fmt.Fprintf(&sb, "<details>\n<summary>\n%s\n</summary>\n", html.EscapeString(change.title()))
+		for _, risk := range change.Risks {
+			fmt.Fprintf(&sb, "<p>⚠️ %s</p>\n", html.EscapeString(risk))
+		}
+		writeHTMLDiffTable(&sb, change.Content)
+		sb.WriteString("</details>\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,214 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func specChangeTestApp(t *testing.T, kind argoapplication.ApplicationKind, branch git.BranchType, manifest string) argoapplication.ArgoResource {
+	t.Helper()
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(manifest), &obj.Object); err != nil {
+		t.Fatalf("failed to unmarshal manifest: %v", err)
+	}
+	return specChangeTestAppInFile(t, kind, branch, "apps/"+obj.GetName()+".yaml", manifest)
+}
+
+func specChangeTestAppInFile(t *testing.T, kind argoapplication.ApplicationKind, branch git.BranchType, fileName, manifest string) argoapplication.ArgoResource {
+	t.Helper()
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(manifest), &obj.Object); err != nil {
+		t.Fatalf("failed to unmarshal manifest: %v", err)
+	}
+	app := argoapplication.NewArgoResource(obj.DeepCopy(), kind, obj.GetName(), obj.GetName(), fileName, branch)
+	app.Original = &obj
+	return *app
+}
+
+func TestBuildSpecChanges(t *testing.T) {
+	base := []argoapplication.ArgoResource{
+		specChangeTestApp(t, argoapplication.Application, git.Base, `
+metadata:
+  name: guestbook
+spec:
+  project: default
+  destination:
+    server: https://kubernetes.default.svc
+    namespace: guestbook
+  syncPolicy:
+    automated:
+      prune: false`),
+		specChangeTestApp(t, argoapplication.Application, git.Base, `
+metadata:
+  name: unchanged
+spec:
+  project: default`),
+		specChangeTestApp(t, argoapplication.ApplicationSet, git.Base, `
+metadata:
+  name: cluster-addons
+spec:
+  template:
+    spec:
+      project: default`),
+	}
+	target := []argoapplication.ArgoResource{
+		specChangeTestApp(t, argoapplication.Application, git.Target, `
+metadata:
+  name: guestbook
+spec:
+  project: team-a
+  destination:
+    server: https://prod.example.com
+    namespace: guestbook
+  syncPolicy:
+    automated:
+      prune: true`),
+		specChangeTestApp(t, argoapplication.Application, git.Target, `
+metadata:
+  name: unchanged
+spec:
+  project: default`),
+		specChangeTestApp(t, argoapplication.ApplicationSet, git.Target, `
+metadata:
+  name: cluster-addons
+spec:
+  template:
+    spec:
+      project: default
+      syncPolicy:
+        automated: {}`),
+		specChangeTestApp(t, argoapplication.Application, git.Target, `
+metadata:
+  name: added
+spec:
+  project: default`),
+	}
+
+	changes, err := BuildSpecChanges(base, target, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+
+	guestbook := changes[0]
+	if guestbook.Name != "guestbook" {
+		t.Fatalf("expected applications before application sets, got %s", guestbook.Name)
+	}
+	expectedRisks := []string{
+		"automated prune enabled",
+		"destination server changed: 'https://kubernetes.default.svc' → 'https://prod.example.com'",
+		"project changed: 'default' → 'team-a'",
+	}
+	if strings.Join(guestbook.Risks, "\n") != strings.Join(expectedRisks, "\n") {
+		t.Errorf("expected risks %v, got %v", expectedRisks, guestbook.Risks)
+	}
+	if !strings.Contains(guestbook.Content, "+  project: team-a") {
+		t.Errorf("expected diff of the project, got:\n%s", guestbook.Content)
+	}
+
+	addons := changes[1]
+	if addons.Kind != argoapplication.ApplicationSet {
+		t.Errorf("expected ApplicationSet, got %s", addons.Kind.ShortName())
+	}
+	if len(addons.Risks) != 1 || addons.Risks[0] != "automated sync enabled" {
+		t.Errorf("expected the template to enable automated sync, got %v", addons.Risks)
+	}
+}
+
+func TestBuildSpecChanges_SameNameInDifferentFiles(t *testing.T) {
+	app := func(branch git.BranchType, fileName, server string) argoapplication.ArgoResource {
+		return specChangeTestAppInFile(t, argoapplication.Application, branch, fileName, `
+metadata:
+  name: web
+spec:
+  destination:
+    server: `+server)
+	}
+	base := []argoapplication.ArgoResource{
+		app(git.Base, "envs/prod/web.yaml", "https://prod.example.com"),
+		app(git.Base, "envs/staging/web.yaml", "https://staging.example.com"),
+	}
+	target := []argoapplication.ArgoResource{
+		app(git.Target, "envs/staging/web.yaml", "https://staging.example.com"),
+		app(git.Target, "envs/prod/web.yaml", "https://prod-2.example.com"),
+	}
+
+	changes, err := BuildSpecChanges(base, target, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].FileName != "envs/prod/web.yaml" {
+		t.Fatalf("expected only the prod Application to change, got %+v", changes)
+	}
+	if strings.Join(changes[0].Risks, "\n") != "destination server changed: 'https://prod.example.com' → 'https://prod-2.example.com'" {
+		t.Errorf("expected the prod Application to be compared with itself, got %v", changes[0].Risks)
+	}
+
+	// An Application moved to another file is paired if its name is unique
+	moved := []argoapplication.ArgoResource{
+		specChangeTestAppInFile(t, argoapplication.Application, git.Target, "apps/team-a/guestbook.yaml", `
+metadata:
+  name: guestbook
+spec:
+  project: team-a`),
+	}
+	changes, err = BuildSpecChanges([]argoapplication.ArgoResource{specChangeTestApp(t, argoapplication.Application, git.Base, `
+metadata:
+  name: guestbook
+spec:
+  project: default`)}, moved, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 1 || changes[0].FileName != "apps/team-a/guestbook.yaml" {
+		t.Errorf("expected the moved Application to be paired, got %+v", changes)
+	}
+}
+
+func TestSpecChanges_Markdown(t *testing.T) {
+	var empty SpecChanges
+	if empty.Markdown() != "" || empty.HTML() != "" {
+		t.Errorf("expected no output without changes")
+	}
+
+	changes := SpecChanges{
+		{
+			Kind:     argoapplication.Application,
+			Name:     "guestbook",
+			FileName: "apps/guestbook.yaml",
+			Content:  "-  project: default\n+  project: team-a\n",
+			Risks:    []string{"project changed: 'default' → 'team-a'"},
+		},
+	}
+
+	markdown := changes.Markdown()
+	for _, expected := range []string{
+		"### Application spec changes",
+		"<summary>App: guestbook (apps/guestbook.yaml) ⚠️</summary>",
+		"- ⚠️ project changed: 'default' → 'team-a'",
+		"```diff\n-  project: default\n+  project: team-a\n```",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	html := changes.HTML()
+	for _, expected := range []string{
+		"<h3>Application spec changes</h3>",
+		"<p>⚠️ project changed: &#39;default&#39; → &#39;team-a&#39;</p>",
+		`<tr class="added_line"><td><pre>+  project: team-a</pre></td></tr>`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected HTML to contain %q, got:\n%s", expected, html)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+)
+
+// SyncPlanApp is the order Argo CD syncs the resources of a changed Application in
+type SyncPlanApp struct {
+	Name  string
+	Steps []matching.SyncStep
+}
+
+// SyncPlan is the sync order of the changed Applications with hooks or sync waves
+type SyncPlan []SyncPlanApp
+
+// BuildSyncPlan collects the sync steps of the app diffs
+func BuildSyncPlan(diffs []matching.AppDiff) SyncPlan {
+	var plan SyncPlan
+	for _, d := range diffs {
+		if len(d.SyncSteps) == 0 {
+			continue
+		}
+		plan = append(plan, SyncPlanApp{Name: d.PrettyName(), Steps: d.SyncSteps})
+	}
+	sort.SliceStable(plan, func(i, j int) bool { return plan[i].Name < plan[j].Name })
+	return plan
+}
+
+const syncPlanDescription = "The order Argo CD applies the resources of the changed Applications in, by sync phase and wave."
+
+// stepResources describes the resources of a step. Unchanged resources that are not hooks
+// are only counted.
+func stepResources(step matching.SyncStep, format func(string) string) []string {
+	var lines []string
+	unchanged := 0
+	for _, r := range step.Resources {
+		if r.Change == matching.SyncUnchanged && !r.Hook {
+			unchanged++
+			continue
+		}
+		line := fmt.Sprintf("%s: %s", r.Change, format(fmt.Sprintf("%s %s", r.Kind, qualifiedResourceName(r.Namespace, r.Name))))
+		if r.Hook {
+			line += " (hook)"
+		}
+		if r.MovedFrom != "" {
+			line += fmt.Sprintf(" (from %s)", r.MovedFrom)
+		}
+		lines = append(lines, line)
+	}
+	if unchanged > 0 {
+		lines = append(lines, fmt.Sprintf("%d unchanged", unchanged))
+	}
+	return lines
+}
+
+// Markdown returns a collapsible table of the sync steps per Application, or an empty
+// string if there are none
+func (p SyncPlan) Markdown() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### Sync plan\n\n")
+	sb.WriteString(syncPlanDescription + "\n\n")
+	for _, app := range p {
+		fmt.Fprintf(&sb, "<details>\n<summary>%s</summary>\n<br>\n\n", app.Name)
+		sb.WriteString("| Phase | Wave | Resources |\n")
+		sb.WriteString("|---|---|---|\n")
+		for _, step := range app.Steps {
+			resources := stepResources(step, func(s string) string { return "`" + escapeMarkdownTableCell(s) + "`" })
+			fmt.Fprintf(&sb, "| %s | %d | %s |\n", step.Phase, step.Wave, strings.Join(resources, "<br>"))
+		}
+		sb.WriteString("</details>\n\n")
+	}
+	return sb.String()
+}
+
+// HTML returns a collapsible table of the sync steps per Application, or an empty string
+// if there are none
+func (p SyncPlan) HTML() string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<h3>Sync plan</h3>\n")
+	sb.WriteString("<p>" + html.EscapeString(syncPlanDescription) + "</p>\n")
+	for _, app := range p {
+		fmt.Fprintf(&sb, "<details>\n<summary>%s</summary>\n", html.EscapeString(app.Name))
+		sb.WriteString("<table>\n<tr><th>Phase</th><th>Wave</th><th>Resources</th></tr>\n")
+		for _, step := range app.Steps {
+			resources := stepResources(step, func(s string) string { return "<code>" + html.EscapeString(s) + "</code>" })
+			fmt.Fprintf(&sb, "<tr><td>%s</td><td>%d</td><td>%s</td></tr>\n",
+				html.EscapeString(step.Phase), step.Wave, strings.Join(resources, "<br>"))
+		}
+		sb.WriteString("</table>\n</details>\n")
+	}
+	return sb.String()
+}
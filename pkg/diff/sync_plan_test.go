@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/matching"
+)
+
+func TestBuildSyncPlan(t *testing.T) {
+	diffs := []matching.AppDiff{
+		{NewName: "web", Action: matching.ActionModified, SyncSteps: []matching.SyncStep{
+			{Phase: "PreSync", Wave: 0, Resources: []matching.SyncStepResource{
+				{Kind: "Job", Namespace: "default", Name: "migrate", Hook: true, Change: matching.SyncChanged},
+			}},
+			{Phase: "Sync", Wave: 0, Resources: []matching.SyncStepResource{
+				{Kind: "Deployment", Namespace: "default", Name: "web", Change: matching.SyncChanged},
+				{Kind: "Service", Namespace: "default", Name: "web", Change: matching.SyncUnchanged},
+				{Kind: "ConfigMap", Namespace: "default", Name: "settings", Change: matching.SyncUnchanged},
+			}},
+			{Phase: "Sync", Wave: 1, Resources: []matching.SyncStepResource{
+				{Kind: "CustomResourceDefinition", Name: "widgets.example.com", Change: matching.SyncMoved, MovedFrom: "Sync wave -1"},
+			}},
+		}},
+		{NewName: "api", Action: matching.ActionModified},
+	}
+
+	plan := BuildSyncPlan(diffs)
+	if len(plan) != 1 || plan[0].Name != "web" {
+		t.Fatalf("expected only the app with sync steps, got %+v", plan)
+	}
+
+	markdown := plan.Markdown()
+	for _, expected := range []string{
+		"### Sync plan",
+		"<summary>web</summary>",
+		"| PreSync | 0 | changed: `Job default/migrate` (hook) |",
+		"| Sync | 0 | changed: `Deployment default/web`<br>2 unchanged |",
+		"| Sync | 1 | moved: `CustomResourceDefinition widgets.example.com` (from Sync wave -1) |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	if !strings.Contains(plan.HTML(), "<tr><td>PreSync</td><td>0</td><td>changed: <code>Job default/migrate</code> (hook)</td></tr>") {
+		t.Errorf("unexpected HTML:\n%s", plan.HTML())
+	}
+
+	if SyncPlan(nil).Markdown() != "" || SyncPlan(nil).HTML() != "" {
+		t.Errorf("expected no output without sync steps")
+	}
+}
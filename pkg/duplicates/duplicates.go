@@ -97,13 +97,20 @@ func filterDuplicates(apps *argoapplication.ArgoSelection, duplicates []*unstruc
 		if !duplicateSet[string(appStr)] {
 			selectedApps = append(selectedApps, app)
 		} else {
+			app.Selection = argoapplication.SelectionDecision{
+				Rule:   argoapplication.RuleIdenticalCopy,
+				Reason: "application is identical in the base and target branch",
+			}
 			skippedApps = append(skippedApps, app)
 		}
 	}
 
 	log.Debug().Msgf("removed %d duplicates", len(apps.SelectedApps)-len(selectedApps))
 	return &argoapplication.ArgoSelection{
-		SelectedApps: selectedApps,
-		SkippedApps:  skippedApps,
+		SelectedApps:  selectedApps,
+		SkippedApps:   skippedApps,
+		ExcludedFiles: apps.ExcludedFiles,
+		Problems:      apps.Problems,
+		AppProjects:   apps.AppProjects,
 	}
 }
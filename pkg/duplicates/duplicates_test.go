@@ -112,6 +112,9 @@ func TestFilterDuplicates_BasicFunctionality(t *testing.T) {
 	assert.Equal(t, "app2", result.SelectedApps[0].Name)
 	// Duplicates should be moved to SkippedApps
 	assert.Len(t, result.SkippedApps, 2)
+	for _, skipped := range result.SkippedApps {
+		assert.Equal(t, argoapplication.RuleIdenticalCopy, skipped.Selection.Rule)
+	}
 }
 
 func TestFilterDuplicates_NoDuplicates(t *testing.T) {
@@ -0,0 +1,284 @@
+package extract
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	argocdPkg "github.com/dag-andersen/argocd-diff-preview/pkg/argocd"
+)
+
+const (
+	argocdConfigMapName              = "argocd-cm"
+	resourceCustomizationsKey        = "resource.customizations"
+	ignoreDifferencesKeyPrefix       = "resource.customizations.ignoreDifferences."
+	ignoreDifferencesAllKey          = "all"
+	resourceExclusionsKey            = "resource.exclusions"
+	resourceInclusionsKey            = "resource.inclusions"
+	resourceCompareOptionsKey        = "resource.compareoptions"
+	ignoreResourceStatusFieldCRD     = "crd"
+	ignoreResourceStatusFieldAll     = "all"
+	defaultIgnoreResourceStatusField = ignoreResourceStatusFieldAll // the default since Argo CD 3.0
+	inClusterServer                  = "https://kubernetes.default.svc"
+)
+
+// resourceFilter is an entry of resource.exclusions or resource.inclusions in argocd-cm.
+// An empty list matches everything.
+type resourceFilter struct {
+	APIGroups []string `json:"apiGroups,omitempty"`
+	Kinds     []string `json:"kinds,omitempty"`
+	Clusters  []string `json:"clusters,omitempty"`
+}
+
+// compareOptions is resource.compareoptions in argocd-cm
+type compareOptions struct {
+	IgnoreAggregatedRoles     bool   `json:"ignoreAggregatedRoles,omitempty"`
+	IgnoreResourceStatusField string `json:"ignoreResourceStatusField,omitempty"`
+}
+
+// ArgoCDSettings are the global settings in argocd-cm that change which resources and fields
+// Argo CD compares. A nil *ArgoCDSettings changes nothing.
+type ArgoCDSettings struct {
+	ignoreDifferences []IgnoreDifferenceRule
+	exclusions        []resourceFilter
+	inclusions        []resourceFilter
+	compareOptions    compareOptions
+}
+
+// LoadArgoCDSettings reads the global settings from the argocd-cm ConfigMap of the installation.
+// Missing ConfigMap access must not prevent rendering, so it returns nil if the ConfigMap can
+// not be read.
+func LoadArgoCDSettings(argocd *argocdPkg.ArgoCDInstallation) *ArgoCDSettings {
+	data, err := argocd.K8sClient.GetConfigMapData(argocd.Namespace, argocdConfigMapName)
+	if err != nil {
+		log.Warn().Err(err).Msgf("⚠️ Unable to read %s. Continuing without global ignoreDifferences and resource exclusions.", argocdConfigMapName)
+		return nil
+	}
+	settings := ParseArgoCDSettings(data)
+	if len(settings.ignoreDifferences) > 0 || len(settings.exclusions) > 0 || len(settings.inclusions) > 0 {
+		log.Info().Msgf("🔧 Using settings from %s: %d ignoreDifferences, %d resource exclusions, %d resource inclusions",
+			argocdConfigMapName, len(settings.ignoreDifferences), len(settings.exclusions), len(settings.inclusions))
+	}
+	return settings
+}
+
+// ParseArgoCDSettings parses the global settings from the data of argocd-cm. Invalid settings
+// are logged and skipped, like Argo CD does.
+func ParseArgoCDSettings(data map[string]string) *ArgoCDSettings {
+	settings := &ArgoCDSettings{
+		compareOptions: compareOptions{IgnoreResourceStatusField: defaultIgnoreResourceStatusField},
+	}
+
+	// Sort the keys so the rules are applied in the same order on every run
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		groupKind, ok := strings.CutPrefix(key, ignoreDifferencesKeyPrefix)
+		if !ok {
+			continue
+		}
+		var item map[string]any
+		if err := yaml.Unmarshal([]byte(data[key]), &item); err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Invalid %s in %s. Skipping it.", key, argocdConfigMapName)
+			continue
+		}
+		rule, ok := parseIgnoreDifferencesCustomization(groupKind, item)
+		if ok {
+			settings.ignoreDifferences = append(settings.ignoreDifferences, rule)
+		}
+	}
+	if value := data[resourceCustomizationsKey]; value != "" {
+		settings.ignoreDifferences = append(settings.ignoreDifferences, parseLegacyCustomizations(value)...)
+	}
+
+	if value := data[resourceExclusionsKey]; value != "" {
+		if err := yaml.Unmarshal([]byte(value), &settings.exclusions); err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Invalid %s in %s. Skipping it.", resourceExclusionsKey, argocdConfigMapName)
+			settings.exclusions = nil
+		}
+	}
+	if value := data[resourceInclusionsKey]; value != "" {
+		if err := yaml.Unmarshal([]byte(value), &settings.inclusions); err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Invalid %s in %s. Skipping it.", resourceInclusionsKey, argocdConfigMapName)
+			settings.inclusions = nil
+		}
+	}
+	if value := data[resourceCompareOptionsKey]; value != "" {
+		if err := yaml.Unmarshal([]byte(value), &settings.compareOptions); err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Invalid %s in %s. Skipping it.", resourceCompareOptionsKey, argocdConfigMapName)
+		}
+		if settings.compareOptions.IgnoreResourceStatusField == "" {
+			settings.compareOptions.IgnoreResourceStatusField = defaultIgnoreResourceStatusField
+		}
+	}
+
+	return settings
+}
+
+// parseIgnoreDifferencesCustomization parses resource.customizations.ignoreDifferences.<group_kind>.
+// The key is 'all' for all resources, '<kind>' for the core group or '<group>_<kind>'.
+func parseIgnoreDifferencesCustomization(groupKind string, item map[string]any) (IgnoreDifferenceRule, bool) {
+	rule := IgnoreDifferenceRule{
+		JSONPointers:      stringList(item["jsonPointers"]),
+		JQPathExpressions: stringList(item["jqPathExpressions"]),
+	}
+	if len(rule.JSONPointers) == 0 && len(rule.JQPathExpressions) == 0 {
+		return IgnoreDifferenceRule{}, false
+	}
+
+	if groupKind != ignoreDifferencesAllKey {
+		if group, kind, found := strings.Cut(groupKind, "_"); found {
+			rule.Group, rule.Kind = group, kind
+		} else {
+			rule.Kind = groupKind
+		}
+	}
+	return rule, true
+}
+
+// parseLegacyCustomizations parses the ignoreDifferences of the resource.customizations key,
+// which holds the customizations of all kinds keyed by '<group>/<kind>', '<kind>' for the
+// core group or '*/*' for all resources
+func parseLegacyCustomizations(value string) []IgnoreDifferenceRule {
+	var customizations map[string]map[string]any
+	if err := yaml.Unmarshal([]byte(value), &customizations); err != nil {
+		log.Warn().Err(err).Msgf("⚠️ Invalid %s in %s. Skipping it.", resourceCustomizationsKey, argocdConfigMapName)
+		return nil
+	}
+
+	keys := make([]string, 0, len(customizations))
+	for key := range customizations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var rules []IgnoreDifferenceRule
+	for _, key := range keys {
+		// The ignoreDifferences are a YAML string, like in the split keys
+		var item map[string]any
+		switch ignoreDifferences := customizations[key]["ignoreDifferences"].(type) {
+		case string:
+			if err := yaml.Unmarshal([]byte(ignoreDifferences), &item); err != nil {
+				log.Warn().Err(err).Msgf("⚠️ Invalid ignoreDifferences of %s in %s. Skipping it.", key, resourceCustomizationsKey)
+				continue
+			}
+		case map[string]any:
+			item = ignoreDifferences
+		default:
+			continue
+		}
+
+		groupKind := strings.Replace(key, "/", "_", 1)
+		if key == "*/*" {
+			groupKind = ignoreDifferencesAllKey
+		}
+		if rule, ok := parseIgnoreDifferencesCustomization(groupKind, item); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Apply removes the resources Argo CD excludes and masks the fields it ignores, in place like
+// ApplyIgnoreDifferencesToManifests. It returns the remaining manifests.
+func (s *ArgoCDSettings) Apply(manifests []unstructured.Unstructured, app argoapplication.ArgoResource) []unstructured.Unstructured {
+	if s == nil {
+		return manifests
+	}
+
+	// The destination is patched to the local cluster, so the original one is used
+	cluster := ""
+	if original := app.Unpatched(); original != nil {
+		server, _, _ := unstructured.NestedString(original.Object, "spec", "destination", "server")
+		name, _, _ := unstructured.NestedString(original.Object, "spec", "destination", "name")
+		switch {
+		case server != "":
+			cluster = server
+		case name == "in-cluster":
+			cluster = inClusterServer
+		default:
+			// The server of other clusters referenced by name is unknown
+			cluster = name
+		}
+	}
+
+	result := make([]unstructured.Unstructured, 0, len(manifests))
+	for _, manifest := range manifests {
+		group := groupFromAPIVersion(manifest.GetAPIVersion())
+		if !s.isIncluded(group, manifest.GetKind(), cluster) {
+			log.Debug().Str("App", app.GetLongName()).Msgf("Excluded resource by %s: %s/%s", argocdConfigMapName, manifest.GetKind(), manifest.GetName())
+			continue
+		}
+		s.applyCompareOptions(&manifest, group)
+		result = append(result, manifest)
+	}
+
+	ApplyIgnoreDifferencesToManifests(result, s.ignoreDifferences)
+	return result
+}
+
+// isIncluded returns false for resources matching resource.exclusions, and for resources not
+// matching resource.inclusions if there are any
+func (s *ArgoCDSettings) isIncluded(group, kind, cluster string) bool {
+	for _, filter := range s.exclusions {
+		if filter.matches(group, kind, cluster) {
+			return false
+		}
+	}
+	if len(s.inclusions) == 0 {
+		return true
+	}
+	for _, filter := range s.inclusions {
+		if filter.matches(group, kind, cluster) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ArgoCDSettings) applyCompareOptions(manifest *unstructured.Unstructured, group string) {
+	switch s.compareOptions.IgnoreResourceStatusField {
+	case ignoreResourceStatusFieldAll:
+		unstructured.RemoveNestedField(manifest.Object, "status")
+	case ignoreResourceStatusFieldCRD:
+		if group == "apiextensions.k8s.io" && manifest.GetKind() == "CustomResourceDefinition" {
+			unstructured.RemoveNestedField(manifest.Object, "status")
+		}
+	}
+
+	// Aggregated ClusterRoles get their rules from the ClusterRoles they select
+	if s.compareOptions.IgnoreAggregatedRoles && group == "rbac.authorization.k8s.io" && manifest.GetKind() == "ClusterRole" {
+		if _, found := manifest.Object["aggregationRule"]; found {
+			unstructured.RemoveNestedField(manifest.Object, "rules")
+		}
+	}
+}
+
+func (f resourceFilter) matches(group, kind, cluster string) bool {
+	return matchesAnyGlob(f.APIGroups, group) && matchesAnyGlob(f.Kinds, kind) && matchesAnyGlob(f.Clusters, cluster)
+}
+
+// matchesAnyGlob returns true if the value matches one of the patterns, or if there are no patterns
+func matchesAnyGlob(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			return true
+		}
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+)
+
+func settingsTestManifests(t *testing.T, manifests ...string) []unstructured.Unstructured {
+	t.Helper()
+	result := make([]unstructured.Unstructured, len(manifests))
+	for i, manifest := range manifests {
+		require.NoError(t, yaml.Unmarshal([]byte(manifest), &result[i].Object))
+	}
+	return result
+}
+
+func settingsTestApp(server string) argoapplication.ArgoResource {
+	return argoapplication.ArgoResource{
+		Kind: argoapplication.Application,
+		Name: "app",
+		Yaml: &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"destination": map[string]any{"server": server}},
+		}},
+	}
+}
+
+func TestParseArgoCDSettings(t *testing.T) {
+	settings := ParseArgoCDSettings(map[string]string{
+		"resource.customizations.ignoreDifferences.all":             "jsonPointers:\n- /metadata/labels/version\n",
+		"resource.customizations.ignoreDifferences.apps_Deployment": "jqPathExpressions:\n- .spec.replicas\n",
+		"resource.customizations.ignoreDifferences.Service":         "jsonPointers:\n- /spec/clusterIP\n",
+		"resource.customizations.ignoreDifferences.ConfigMap":       "managedFieldsManagers:\n- kube-controller-manager\n",
+		"resource.customizations.ignoreDifferences.Secret":          "not: [valid",
+		"resource.exclusions":                                       "- apiGroups: ['*']\n  kinds: [Lease]\n",
+		"resource.compareoptions":                                   "ignoreAggregatedRoles: true\n",
+		"resource.customizations": `
+admissionregistration.k8s.io/MutatingWebhookConfiguration:
+  ignoreDifferences: |
+    jsonPointers:
+    - /webhooks/0/clientConfig/caBundle
+ConfigMap:
+  health.lua: return {}
+"*/*":
+  ignoreDifferences: |
+    jqPathExpressions:
+    - .metadata.annotations
+`,
+	})
+
+	assert.Equal(t, []IgnoreDifferenceRule{
+		{Kind: "Service", JSONPointers: []string{"/spec/clusterIP"}},
+		{JSONPointers: []string{"/metadata/labels/version"}},
+		{Group: "apps", Kind: "Deployment", JQPathExpressions: []string{".spec.replicas"}},
+		{JQPathExpressions: []string{".metadata.annotations"}},
+		{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration", JSONPointers: []string{"/webhooks/0/clientConfig/caBundle"}},
+	}, settings.ignoreDifferences)
+	assert.Equal(t, []resourceFilter{{APIGroups: []string{"*"}, Kinds: []string{"Lease"}}}, settings.exclusions)
+	assert.Empty(t, settings.inclusions)
+	assert.Equal(t, compareOptions{IgnoreAggregatedRoles: true, IgnoreResourceStatusField: "all"}, settings.compareOptions)
+}
+
+func TestArgoCDSettingsApply(t *testing.T) {
+	manifests := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels: {version: "1"}
+spec:
+  replicas: 3`
+	lease := `{apiVersion: coordination.k8s.io/v1, kind: Lease, metadata: {name: lock}}`
+	crd := `{apiVersion: apiextensions.k8s.io/v1, kind: CustomResourceDefinition, metadata: {name: widgets.example.com}, status: {acceptedNames: {kind: Widget}}}`
+	widget := `{apiVersion: example.com/v1, kind: Widget, metadata: {name: w}, status: {ready: true}}`
+	aggregated := `{apiVersion: rbac.authorization.k8s.io/v1, kind: ClusterRole, metadata: {name: view}, aggregationRule: {clusterRoleSelectors: []}, rules: [{verbs: [get]}]}`
+
+	t.Run("ignoreDifferences, exclusions and compare options", func(t *testing.T) {
+		settings := ParseArgoCDSettings(map[string]string{
+			"resource.customizations.ignoreDifferences.all":             "jsonPointers:\n- /metadata/labels/version\n",
+			"resource.customizations.ignoreDifferences.apps_Deployment": "jqPathExpressions:\n- .spec.replicas\n",
+			"resource.exclusions":     "- apiGroups: ['coordination.k8s.io']\n  kinds: ['*']\n  clusters: ['https://kubernetes.default.svc']\n",
+			"resource.compareoptions": "ignoreAggregatedRoles: true\n",
+		})
+
+		result := settings.Apply(settingsTestManifests(t, manifests, lease, crd, widget, aggregated), settingsTestApp("https://kubernetes.default.svc"))
+		require.Len(t, result, 4)
+
+		assert.Equal(t, map[string]any{}, result[0].Object["metadata"].(map[string]any)["labels"])
+		assert.NotContains(t, result[0].Object["spec"], "replicas")
+		assert.NotContains(t, result[1].Object, "status", "the status of CRDs is ignored by default")
+		assert.NotContains(t, result[2].Object, "status", "the status of other resources is ignored by default")
+		assert.NotContains(t, result[3].Object, "rules")
+	})
+
+	t.Run("exclusions for another cluster", func(t *testing.T) {
+		settings := ParseArgoCDSettings(map[string]string{
+			"resource.exclusions": "- kinds: [Lease]\n  clusters: ['https://prod.example.com']\n",
+		})
+		result := settings.Apply(settingsTestManifests(t, lease), settingsTestApp("https://kubernetes.default.svc"))
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("exclusions for the original destination", func(t *testing.T) {
+		settings := ParseArgoCDSettings(map[string]string{
+			"resource.exclusions": "- kinds: [Lease]\n  clusters: ['https://kubernetes.default.svc']\n",
+		})
+
+		// Patching points every Application at the local cluster
+		app := settingsTestApp("https://kubernetes.default.svc")
+		app.Original = settingsTestApp("https://prod.example.com").Yaml
+		assert.Len(t, settings.Apply(settingsTestManifests(t, lease), app), 1)
+
+		app.Original = &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{"destination": map[string]any{"name": "in-cluster"}},
+		}}
+		assert.Empty(t, settings.Apply(settingsTestManifests(t, lease), app), "in-cluster is resolved to its server")
+	})
+
+	t.Run("inclusions and status of CRDs", func(t *testing.T) {
+		settings := ParseArgoCDSettings(map[string]string{
+			"resource.inclusions":     "- apiGroups: ['*.com', 'apiextensions.k8s.io']\n",
+			"resource.compareoptions": "ignoreResourceStatusField: crd\n",
+		})
+		result := settings.Apply(settingsTestManifests(t, manifests, crd, widget), settingsTestApp("https://kubernetes.default.svc"))
+		require.Len(t, result, 2)
+		assert.Equal(t, "CustomResourceDefinition", result[0].GetKind())
+		assert.NotContains(t, result[0].Object, "status")
+		assert.Equal(t, "Widget", result[1].GetKind())
+		assert.Contains(t, result[1].Object, "status")
+	})
+
+	t.Run("no settings", func(t *testing.T) {
+		var settings *ArgoCDSettings
+		result := settings.Apply(settingsTestManifests(t, lease, widget), settingsTestApp(""))
+		assert.Len(t, result, 2)
+	})
+}
@@ -0,0 +1,213 @@
+package extract
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NonDeterministicField is a field with a different value in two renders of the same
+// Application, e.g. a value generated by randAlphaNum, now or genCA in a Helm chart
+type NonDeterministicField struct {
+	App        string
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	Path       string // JSON pointer of the field
+}
+
+// resourceKey identifies a resource within an Application
+func resourceKey(m *unstructured.Unstructured) string {
+	return strings.Join([]string{m.GetAPIVersion(), m.GetKind(), m.GetNamespace(), m.GetName()}, "/")
+}
+
+// FindNonDeterministicFields compares two renders of the same Applications and returns the
+// fields that differ between them. Applications and resources are paired by ID and by
+// apiVersion, kind, namespace and name. Resources that are only in one of the renders can
+// not be masked, so they are only logged.
+func FindNonDeterministicFields(first, second []ExtractedApp) []NonDeterministicField {
+	secondByID := make(map[string]*ExtractedApp, len(second))
+	for i := range second {
+		secondByID[second[i].Id] = &second[i]
+	}
+
+	var fields []NonDeterministicField
+	for i := range first {
+		app := &first[i]
+		other, ok := secondByID[app.Id]
+		if !ok {
+			continue
+		}
+
+		otherResources := make(map[string]*unstructured.Unstructured, len(other.Manifests))
+		for j := range other.Manifests {
+			otherResources[resourceKey(&other.Manifests[j])] = &other.Manifests[j]
+		}
+
+		for j := range app.Manifests {
+			m := &app.Manifests[j]
+			key := resourceKey(m)
+			otherResource, ok := otherResources[key]
+			if !ok {
+				log.Warn().Str("App", app.Name).Msgf("⚠️ Resource %s/%s was only rendered once. Its name is probably not deterministic", m.GetKind(), m.GetName())
+				continue
+			}
+			delete(otherResources, key)
+
+			for _, path := range differingPaths(m.Object, otherResource.Object, "") {
+				fields = append(fields, NonDeterministicField{
+					App:        app.Name,
+					APIVersion: m.GetAPIVersion(),
+					Kind:       m.GetKind(),
+					Namespace:  m.GetNamespace(),
+					Name:       m.GetName(),
+					Path:       path,
+				})
+			}
+		}
+
+		for _, m := range otherResources {
+			log.Warn().Str("App", app.Name).Msgf("⚠️ Resource %s/%s was only rendered once. Its name is probably not deterministic", m.GetKind(), m.GetName())
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.App != b.App {
+			return a.App < b.App
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Path < b.Path
+	})
+	return fields
+}
+
+// differingPaths returns the JSON pointers of the values that differ between a and b.
+// Maps are compared key by key and lists of the same length item by item. Any other
+// difference is reported at the path of the value itself.
+func differingPaths(a, b any, path string) []string {
+	switch aValue := a.(type) {
+	case map[string]any:
+		bValue, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(aValue)+len(bValue))
+		for key := range aValue {
+			keys[key] = true
+		}
+		for key := range bValue {
+			keys[key] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		var paths []string
+		for _, key := range sortedKeys {
+			paths = append(paths, differingPaths(aValue[key], bValue[key], path+"/"+encodeJSONPointerToken(key))...)
+		}
+		return paths
+	case []any:
+		bValue, ok := b.([]any)
+		if !ok || len(aValue) != len(bValue) {
+			break
+		}
+		var paths []string
+		for i := range aValue {
+			paths = append(paths, differingPaths(aValue[i], bValue[i], fmt.Sprintf("%s/%d", path, i))...)
+		}
+		return paths
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	return []string{path}
+}
+
+func encodeJSONPointerToken(s string) string {
+	// RFC 6901: '~' => ~0, '/' => ~1
+	s = strings.ReplaceAll(s, "~", "~0")
+	s = strings.ReplaceAll(s, "/", "~1")
+	return s
+}
+
+// MaskNonDeterministicFields replaces the non-deterministic fields with a placeholder in the
+// matching resources of the Applications, so they do not show up in the diff. Applications
+// are matched by name, so the fields found in the base branch are masked in the target
+// branch as well.
+func MaskNonDeterministicFields(apps []ExtractedApp, fields []NonDeterministicField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	fieldsByResource := map[string][]string{}
+	for _, f := range fields {
+		key := f.App + "\x00" + strings.Join([]string{f.APIVersion, f.Kind, f.Namespace, f.Name}, "/")
+		fieldsByResource[key] = append(fieldsByResource[key], f.Path)
+	}
+
+	for i := range apps {
+		for j := range apps[i].Manifests {
+			m := &apps[i].Manifests[j]
+			for _, path := range fieldsByResource[apps[i].Name+"\x00"+resourceKey(m)] {
+				maskAtJSONPointer(m.Object, path)
+			}
+		}
+	}
+}
+
+// maskAtJSONPointer replaces the value at the pointer with maskedValue if it exists
+func maskAtJSONPointer(obj map[string]any, pointer string) {
+	if pointer == "" || pointer[0] != '/' {
+		return
+	}
+
+	tokens := strings.Split(pointer, "/")[1:]
+	var parent any = obj
+	for i, rawTok := range tokens {
+		tok := decodeJSONPointerToken(rawTok)
+		last := i == len(tokens)-1
+
+		switch cur := parent.(type) {
+		case map[string]any:
+			if _, ok := cur[tok]; !ok {
+				return
+			}
+			if last {
+				cur[tok] = maskedValue
+				return
+			}
+			parent = cur[tok]
+		case []any:
+			idx, err := strconv.Atoi(tok)
+			if err != nil || idx < 0 || idx >= len(cur) {
+				return
+			}
+			if last {
+				cur[idx] = maskedValue
+				return
+			}
+			parent = cur[idx]
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+func TestFindNonDeterministicFields(t *testing.T) {
+	render := func(password, cert, checksum string, args string) []ExtractedApp {
+		return []ExtractedApp{CreateExtractedApp("db", "db", "", settingsTestManifests(t,
+			`{apiVersion: v1, kind: Secret, metadata: {name: db, namespace: default}, data: {password: `+password+`, user: admin}}`,
+			`{apiVersion: v1, kind: Secret, metadata: {name: db-tls, namespace: default}, data: {tls.crt: `+cert+`}}`,
+			`{apiVersion: apps/v1, kind: Deployment, metadata: {name: db, namespace: default},
+			  spec: {template: {metadata: {annotations: {checksum/secret: `+checksum+`}}, spec: {containers: [{name: db, args: `+args+`}]}}}}`,
+		), git.Base, ExtractedAppOptions{})}
+	}
+
+	first := render("abc", "cert-1", "111", "[--a]")
+	second := render("xyz", "cert-1", "222", "[--a, --b]")
+
+	fields := FindNonDeterministicFields(first, second)
+	assert.Equal(t, []NonDeterministicField{
+		{App: "db", APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "db", Path: "/spec/template/metadata/annotations/checksum~1secret"},
+		{App: "db", APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "db", Path: "/spec/template/spec/containers/0/args"},
+		{App: "db", APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "db", Path: "/data/password"},
+	}, fields)
+
+	assert.Empty(t, FindNonDeterministicFields(first, render("abc", "cert-1", "111", "[--a]")))
+	assert.Empty(t, FindNonDeterministicFields(first, nil), "apps that were not rendered twice are not compared")
+}
+
+func TestMaskNonDeterministicFields(t *testing.T) {
+	apps := []ExtractedApp{CreateExtractedApp("db", "db", "", settingsTestManifests(t,
+		`{apiVersion: v1, kind: Secret, metadata: {name: db, namespace: default}, data: {password: abc, user: admin}}`,
+		`{apiVersion: v1, kind: Secret, metadata: {name: other, namespace: default}, data: {password: abc}}`,
+		`{apiVersion: apps/v1, kind: Deployment, metadata: {name: db, namespace: default}, spec: {template: {spec: {containers: [{name: db}]}}}}`,
+	), git.Target, ExtractedAppOptions{})}
+
+	MaskNonDeterministicFields(apps, []NonDeterministicField{
+		{App: "db", APIVersion: "v1", Kind: "Secret", Namespace: "default", Name: "db", Path: "/data/password"},
+		{App: "db", APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "db", Path: "/spec/template/spec/containers/0"},
+		{App: "db", APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "db", Path: "/spec/missing/0"},
+	})
+
+	data := apps[0].Manifests[0].Object["data"].(map[string]any)
+	assert.Equal(t, maskedValue, data["password"])
+	assert.Equal(t, "admin", data["user"])
+	assert.Equal(t, "abc", apps[0].Manifests[1].Object["data"].(map[string]any)["password"], "other resources are not masked")
+
+	containers := apps[0].Manifests[2].Object["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"].([]any)
+	require.Len(t, containers, 1)
+	assert.Equal(t, maskedValue, containers[0])
+	assert.NotContains(t, apps[0].Manifests[2].Object["spec"], "missing", "missing fields are not added")
+}
@@ -94,6 +94,9 @@ func getResourcesFromApps(
 		return nil, nil, fmt.Errorf("failed to get list of namespaced scoped resources: %w", err)
 	}
 
+	// Global ignoreDifferences, resource exclusions and compare options from argocd-cm
+	argocdSettings := LoadArgoCDSettings(argocd)
+
 	log.Info().Msgf("🤖 Rendering Applications (timeout in %d seconds)", timeout)
 
 	// Process apps in parallel with a worker pool
@@ -156,7 +159,7 @@ func getResourcesFromApps(
 			}
 
 			// Get resources from application
-			result, k8sName, err := getResourcesFromApp(argocd, app, timeRemaining, prefix, namespacedScopedResources)
+			result, k8sName, err := getResourcesFromApp(argocd, app, timeRemaining, prefix, namespacedScopedResources, argocdSettings)
 			results <- struct {
 				app ExtractedApp
 				err error
@@ -232,6 +235,7 @@ func getResourcesFromApp(
 	timeout int,
 	prefix string,
 	namespacedScopedResources map[schema.GroupKind]bool,
+	argocdSettings *ArgoCDSettings,
 ) (ExtractedApp, string, error) {
 
 	// Store ID (kubernetes resource name) before we add a prefix and hash
@@ -285,12 +289,17 @@ func getResourcesFromApp(
 			continue
 		}
 
-		manifestsContent, err := getManifestsFromApp(argocd, app, namespacedScopedResources)
+		manifestsContent, helmHooks, err := getManifestsFromApp(argocd, app, namespacedScopedResources, argocdSettings)
 
 		// If we got manifests with no error, return the extracted app.Ignore all errors
 		if err == nil && len(manifestsContent) > 0 {
 			log.Debug().Str("loop", strconv.Itoa(loopCount)).Str("App", app.GetLongName()).Msgf("Successfully extracted %d manifests from application", len(manifestsContent))
-			extractedApp := CreateExtractedApp(uniqueIdBeforeModifications, app.Name, app.FileName, manifestsContent, app.Branch)
+			extractedApp := CreateExtractedApp(uniqueIdBeforeModifications, app.Name, app.FileName, manifestsContent, app.Branch, ExtractedAppOptions{
+				Cluster:     app.DestinationCluster(),
+				Annotations: app.Yaml.GetAnnotations(),
+				SyncOptions: app.SyncOptions(),
+				HelmHooks:   helmHooks,
+			})
 			return extractedApp, k8sName, nil
 		}
 
@@ -327,7 +336,12 @@ func getResourcesFromApp(
 		// If still got no error anywhere and already tried refreshing, return the extracted app. We assume the application was just empty.
 		if err == nil {
 			log.Warn().Str("App", app.GetLongName()).Msg("⚠️ No manifests found for application")
-			extractedApp := CreateExtractedApp(uniqueIdBeforeModifications, app.Name, app.FileName, manifestsContent, app.Branch)
+			extractedApp := CreateExtractedApp(uniqueIdBeforeModifications, app.Name, app.FileName, manifestsContent, app.Branch, ExtractedAppOptions{
+				Cluster:     app.DestinationCluster(),
+				Annotations: app.Yaml.GetAnnotations(),
+				SyncOptions: app.SyncOptions(),
+				HelmHooks:   helmHooks,
+			})
 			return extractedApp, k8sName, nil
 		}
 
@@ -361,21 +375,21 @@ func getResourcesFromApp(
 	}
 }
 
-func getManifestsFromApp(argocd *argocdPkg.ArgoCDInstallation, app argoapplication.ArgoResource, namespacedScopedResources map[schema.GroupKind]bool) ([]unstructured.Unstructured, error) {
+func getManifestsFromApp(argocd *argocdPkg.ArgoCDInstallation, app argoapplication.ArgoResource, namespacedScopedResources map[schema.GroupKind]bool, argocdSettings *ArgoCDSettings) ([]unstructured.Unstructured, []unstructured.Unstructured, error) {
 	log.Debug().Str("App", app.GetLongName()).Msg("Extracting manifests from Application")
 
 	extractionTimer := time.Now()
 	manifests, exists, err := argocd.GetManifests(app.Id)
 	if !exists {
-		return nil, fmt.Errorf("%s", string(errorApplicationNotFound))
+		return nil, nil, fmt.Errorf("%s", string(errorApplicationNotFound))
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get manifests for application %s: %w", app.GetLongName(), err)
+		return nil, nil, fmt.Errorf("failed to get manifests for application %s: %w", app.GetLongName(), err)
 	}
 
 	if len(manifests) == 0 {
 		log.Debug().Str("App", app.GetLongName()).Msgf("No manifests found for application in %s", time.Since(extractionTimer).Round(time.Second))
-		return []unstructured.Unstructured{}, nil
+		return []unstructured.Unstructured{}, nil, nil
 	}
 
 	log.Debug().Str("App", app.GetLongName()).Msgf("Extracted manifests from Application in %s", time.Since(extractionTimer).Round(time.Second))
@@ -389,9 +403,12 @@ func getManifestsFromApp(argocd *argocdPkg.ArgoCDInstallation, app argoapplicati
 		ApplyIgnoreDifferencesToManifests(manifests, rules)
 	}
 
+	// Apply the global settings from argocd-cm
+	manifests = argocdSettings.Apply(manifests, app)
+
 	err = removeArgoCDTrackingID(manifests)
 	if err != nil {
-		return nil, fmt.Errorf("failed to remove Argo CD tracking ID: %w", err)
+		return nil, nil, fmt.Errorf("failed to remove Argo CD tracking ID: %w", err)
 	}
 
 	// Normalize namespaces and deduplicate resources (same logic as Argo CD controller)
@@ -401,21 +418,14 @@ func getManifestsFromApp(argocd *argocdPkg.ArgoCDInstallation, app argoapplicati
 		destNamespace, _, _ := unstructured.NestedString(app.Yaml.Object, "spec", "destination", "namespace")
 		manifests, err = normalizeNamespaces(manifests, destNamespace, namespacedScopedResources, app.GetLongName())
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
-	// remove Helm hooks resources
-	newManifestsContent := make([]unstructured.Unstructured, 0, len(manifests))
-	for _, manifest := range manifests {
-		if HelmHookFilter(manifest) {
-			newManifestsContent = append(newManifestsContent, manifest)
-		}
-	}
-	manifests = newManifestsContent
+	// Helm hooks are kept apart from the other resources
+	manifests, helmHooks := SplitHelmHooks(manifests)
 
-	// Parse the first non-empty manifest from the string
-	return manifests, nil
+	return manifests, helmHooks, nil
 }
 
 // normalizeNamespaces uses Argo CD's DeduplicateTargetObjects to normalize namespaces on manifests.
@@ -527,6 +537,20 @@ func HelmHookFilter(obj unstructured.Unstructured) bool {
 	return !exists
 }
 
+// SplitHelmHooks splits the manifests into the resources and the Helm hooks
+func SplitHelmHooks(manifests []unstructured.Unstructured) ([]unstructured.Unstructured, []unstructured.Unstructured) {
+	resources := make([]unstructured.Unstructured, 0, len(manifests))
+	var helmHooks []unstructured.Unstructured
+	for _, manifest := range manifests {
+		if HelmHookFilter(manifest) {
+			resources = append(resources, manifest)
+		} else {
+			helmHooks = append(helmHooks, manifest)
+		}
+	}
+	return resources, helmHooks
+}
+
 func containsAny(s string, substrs []string) bool {
 	for _, substr := range substrs {
 		if s != "" && strings.Contains(s, substr) {
@@ -409,3 +409,32 @@ func TestApplicationSetGeneratedDuplicateNamesAreNormalizedBeforeExtract(t *test
 		assert.ElementsMatch(t, []string{"pr123-t-dupe-1", "pr123-t-dupe-2"}, yamlNames)
 	})
 }
+
+func TestSplitHelmHooks(t *testing.T) {
+	resource := func(name string, annotations map[string]string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata":   map[string]any{"name": name},
+		}}
+		obj.SetAnnotations(annotations)
+		return obj
+	}
+	manifests := []unstructured.Unstructured{
+		resource("worker", nil),
+		resource("migrate", map[string]string{"helm.sh/hook": "pre-upgrade"}),
+		resource("cleanup", map[string]string{"argocd.argoproj.io/hook": "PostSync"}),
+	}
+
+	resources, helmHooks := SplitHelmHooks(manifests)
+	require.Len(t, resources, 2)
+	require.Len(t, helmHooks, 1)
+	assert.Equal(t, "worker", resources[0].GetName())
+	assert.Equal(t, "cleanup", resources[1].GetName())
+	assert.Equal(t, "migrate", helmHooks[0].GetName())
+
+	apps := []ExtractedApp{CreateExtractedApp("app", "app", "app.yaml", resources, git.Target, ExtractedAppOptions{HelmHooks: helmHooks})}
+	IncludeHelmHooks(apps)
+	assert.Len(t, apps[0].Manifests, 3)
+	assert.Empty(t, apps[0].HelmHooks)
+}
@@ -15,21 +15,45 @@ import (
 
 // contains a app name, source path, and extracted manifest
 type ExtractedApp struct {
-	Id         string
-	Name       string
-	SourcePath string
-	Manifests  []unstructured.Unstructured
-	Branch     git.BranchType
+	Id          string
+	Name        string
+	SourcePath  string
+	Manifests   []unstructured.Unstructured
+	Branch      git.BranchType
+	Cluster     string                      // destination cluster of the Application that rendered the manifests
+	Annotations map[string]string           // annotations of the Application that rendered the manifests
+	SyncOptions []string                    // spec.syncPolicy.syncOptions of the Application that rendered the manifests
+	HelmHooks   []unstructured.Unstructured // Helm hooks, kept out of the manifests unless they are included in the diff
+}
+
+// ExtractedAppOptions are the details of the Application that rendered the manifests
+type ExtractedAppOptions struct {
+	Cluster     string
+	Annotations map[string]string
+	SyncOptions []string
+	HelmHooks   []unstructured.Unstructured
 }
 
 // CreateExtractedApp creates an ExtractedApp from an ArgoResource
-func CreateExtractedApp(id string, name string, sourcePath string, manifest []unstructured.Unstructured, branch git.BranchType) ExtractedApp {
+func CreateExtractedApp(id string, name string, sourcePath string, manifest []unstructured.Unstructured, branch git.BranchType, options ExtractedAppOptions) ExtractedApp {
 	return ExtractedApp{
-		Id:         id,
-		Name:       name,
-		SourcePath: sourcePath,
-		Manifests:  manifest,
-		Branch:     branch,
+		Id:          id,
+		Name:        name,
+		SourcePath:  sourcePath,
+		Manifests:   manifest,
+		Branch:      branch,
+		Cluster:     options.Cluster,
+		Annotations: options.Annotations,
+		SyncOptions: options.SyncOptions,
+		HelmHooks:   options.HelmHooks,
+	}
+}
+
+// IncludeHelmHooks moves the Helm hooks of the apps into their manifests, so they are part of the diff
+func IncludeHelmHooks(apps []ExtractedApp) {
+	for i := range apps {
+		apps[i].Manifests = append(apps[i].Manifests, apps[i].HelmHooks...)
+		apps[i].HelmHooks = nil
 	}
 }
 
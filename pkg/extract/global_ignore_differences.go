@@ -0,0 +1,114 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"sigs.k8s.io/yaml"
+)
+
+// MaskedField is a field of a global ignore rule, with the number of resources it was masked in
+type MaskedField struct {
+	Group     string
+	Kind      string
+	Name      string
+	Namespace string
+	Field     string // the JSON pointer or jq path expression
+	Resources int
+}
+
+// ParseIgnoreDifferences parses global ignore rules. The rules are a YAML (or JSON) list in the
+// format of spec.ignoreDifferences of an Application. Unlike in an Application, the kind is
+// optional, so a rule without group, kind, name and namespace applies to all resources.
+func ParseIgnoreDifferences(value string) ([]IgnoreDifferenceRule, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var rules []IgnoreDifferenceRule
+	if err := yaml.UnmarshalStrict([]byte(value), &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	for i, rule := range rules {
+		if len(rule.JSONPointers) == 0 && len(rule.JQPathExpressions) == 0 {
+			return nil, fmt.Errorf("rule %d has no jsonPointers or jqPathExpressions", i+1)
+		}
+		for _, pointer := range rule.JSONPointers {
+			if !strings.HasPrefix(pointer, "/") {
+				return nil, fmt.Errorf("rule %d: JSON pointer '%s' must start with '/'", i+1, pointer)
+			}
+		}
+		for _, expr := range rule.JQPathExpressions {
+			if _, err := gojq.Parse("path(" + expr + ")"); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid jq path expression '%s': %w", i+1, expr, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// ApplyGlobalIgnoreDifferences masks the fields of the global ignore rules in the manifests of
+// both branches. It returns the fields of the rules with the number of resources they were
+// masked in. A resource masked in both branches is counted once.
+func ApplyGlobalIgnoreDifferences(baseApps []ExtractedApp, targetApps []ExtractedApp, rules []IgnoreDifferenceRule) []MaskedField {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	type fieldKey struct {
+		rule  int
+		field string
+	}
+	maskedResources := map[fieldKey]map[string]bool{}
+	markMasked := func(key fieldKey, app *ExtractedApp, resourceIndex int) {
+		m := &app.Manifests[resourceIndex]
+		resource := strings.Join([]string{app.Name, m.GetAPIVersion(), m.GetKind(), m.GetNamespace(), m.GetName()}, "\x00")
+		if maskedResources[key] == nil {
+			maskedResources[key] = map[string]bool{}
+		}
+		maskedResources[key][resource] = true
+	}
+
+	for _, apps := range [][]ExtractedApp{baseApps, targetApps} {
+		for a := range apps {
+			app := &apps[a]
+			for i := range app.Manifests {
+				m := &app.Manifests[i]
+				group := groupFromAPIVersion(m.GetAPIVersion())
+				for r, rule := range rules {
+					if !ruleMatches(rule, group, m.GetKind(), m.GetName(), m.GetNamespace()) {
+						continue
+					}
+					for _, pointer := range rule.JSONPointers {
+						if deleteOrMaskAtJSONPointer(m.Object, pointer) {
+							markMasked(fieldKey{rule: r, field: pointer}, app, i)
+						}
+					}
+					for _, expr := range rule.JQPathExpressions {
+						if applyJQPathExpression(m.Object, expr) {
+							markMasked(fieldKey{rule: r, field: expr}, app, i)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Keep the order of the rules, and list fields that masked nothing as well
+	var masked []MaskedField
+	for r, rule := range rules {
+		for _, field := range append(append([]string{}, rule.JSONPointers...), rule.JQPathExpressions...) {
+			masked = append(masked, MaskedField{
+				Group:     rule.Group,
+				Kind:      rule.Kind,
+				Name:      rule.Name,
+				Namespace: rule.Namespace,
+				Field:     field,
+				Resources: len(maskedResources[fieldKey{rule: r, field: field}]),
+			})
+		}
+	}
+	return masked
+}
@@ -0,0 +1,89 @@
+package extract
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+)
+
+func TestParseIgnoreDifferences(t *testing.T) {
+	rules, err := ParseIgnoreDifferences(`
+- jsonPointers: [/metadata/labels/helm.sh~1chart]
+- kind: Deployment
+  group: apps
+  jqPathExpressions: ['.spec.template.metadata.annotations["checksum/config"]']
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []IgnoreDifferenceRule{
+		{JSONPointers: []string{"/metadata/labels/helm.sh~1chart"}},
+		{Group: "apps", Kind: "Deployment", JQPathExpressions: []string{`.spec.template.metadata.annotations["checksum/config"]`}},
+	}, rules)
+
+	rules, err = ParseIgnoreDifferences("")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for name, value := range map[string]string{
+		"no fields":        "[{kind: Deployment}]",
+		"relative pointer": "[{jsonPointers: [metadata/labels]}]",
+		"invalid jq":       "[{jqPathExpressions: ['.spec[']}]",
+		"unknown key":      "[{kinds: [Deployment], jsonPointers: [/spec]}]",
+		"not a list":       "jsonPointers: [/spec]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseIgnoreDifferences(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApplyGlobalIgnoreDifferences(t *testing.T) {
+	deployment := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels: {helm.sh/chart: web-1.0.0}
+spec:
+  template:
+    metadata:
+      annotations: {checksum/config: abc}`
+	service := `{apiVersion: v1, kind: Service, metadata: {name: web, namespace: default, labels: {helm.sh/chart: web-1.0.0}}}`
+	configMap := `{apiVersion: v1, kind: ConfigMap, metadata: {name: settings, namespace: default}}`
+
+	newApp := func(branch git.BranchType) ExtractedApp {
+		return CreateExtractedApp("web", "web", "", settingsTestManifests(t, deployment, service, configMap), branch, ExtractedAppOptions{})
+	}
+	baseApps := []ExtractedApp{newApp(git.Base)}
+	targetApps := []ExtractedApp{newApp(git.Target)}
+
+	rules, err := ParseIgnoreDifferences(`
+- jsonPointers: [/metadata/labels/helm.sh~1chart]
+- kind: Deployment
+  jqPathExpressions: ['.spec.template.metadata.annotations["checksum/config"]']
+- kind: Secret
+  jsonPointers: [/data]
+`)
+	require.NoError(t, err)
+
+	masked := ApplyGlobalIgnoreDifferences(baseApps, targetApps, rules)
+	assert.Equal(t, []MaskedField{
+		{Field: "/metadata/labels/helm.sh~1chart", Resources: 2},
+		{Kind: "Deployment", Field: `.spec.template.metadata.annotations["checksum/config"]`, Resources: 1},
+		{Kind: "Secret", Field: "/data", Resources: 0},
+	}, masked, "a resource masked in both branches is counted once")
+
+	for _, apps := range [][]ExtractedApp{baseApps, targetApps} {
+		assert.NotContains(t, apps[0].Manifests[0].GetLabels(), "helm.sh/chart")
+		assert.NotContains(t, apps[0].Manifests[1].GetLabels(), "helm.sh/chart")
+		annotations, _, _ := unstructured.NestedMap(apps[0].Manifests[0].Object, "spec", "template", "metadata", "annotations")
+		assert.NotContains(t, annotations, "checksum/config")
+	}
+
+	assert.Nil(t, ApplyGlobalIgnoreDifferences(baseApps, targetApps, nil))
+}
@@ -11,15 +11,15 @@ import (
 	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
 )
 
-// ignoreDifferenceRule represents a subset of Argo CD's ignoreDifferences entry that we support.
+// IgnoreDifferenceRule represents a subset of Argo CD's ignoreDifferences entry that we support.
 // We support jsonPointers and jqPathExpressions.
-type ignoreDifferenceRule struct {
-	Group             string
-	Kind              string
-	Name              string
-	Namespace         string
-	JSONPointers      []string
-	JQPathExpressions []string
+type IgnoreDifferenceRule struct {
+	Group             string   `json:"group,omitempty"`
+	Kind              string   `json:"kind,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	Namespace         string   `json:"namespace,omitempty"`
+	JSONPointers      []string `json:"jsonPointers,omitempty"`
+	JQPathExpressions []string `json:"jqPathExpressions,omitempty"`
 }
 
 const maskedValue = "<argocd-diff-preview:ignored>"
@@ -27,8 +27,8 @@ const maskedValue = "<argocd-diff-preview:ignored>"
 // ParseIgnoreDifferencesFromApp extracts ignoreDifferences rules from an Application manifest.
 // It supports both Application (spec.ignoreDifferences) and ApplicationSet templates
 // (spec.template.spec.ignoreDifferences), though in our flow Applications are already generated.
-func ParseIgnoreDifferencesFromApp(app argoapplication.ArgoResource) []ignoreDifferenceRule {
-	var rules []ignoreDifferenceRule
+func ParseIgnoreDifferencesFromApp(app argoapplication.ArgoResource) []IgnoreDifferenceRule {
+	var rules []IgnoreDifferenceRule
 
 	if app.Yaml == nil {
 		return rules
@@ -56,13 +56,13 @@ func ParseIgnoreDifferencesFromApp(app argoapplication.ArgoResource) []ignoreDif
 	return rules
 }
 
-func parseSingleIgnoreRule(item any) (ignoreDifferenceRule, bool) {
+func parseSingleIgnoreRule(item any) (IgnoreDifferenceRule, bool) {
 	m, ok := item.(map[string]any)
 	if !ok {
-		return ignoreDifferenceRule{}, false
+		return IgnoreDifferenceRule{}, false
 	}
 
-	rule := ignoreDifferenceRule{}
+	rule := IgnoreDifferenceRule{}
 	if v, ok := m["group"].(string); ok {
 		rule.Group = v
 	}
@@ -75,32 +75,35 @@ func parseSingleIgnoreRule(item any) (ignoreDifferenceRule, bool) {
 	if v, ok := m["namespace"].(string); ok {
 		rule.Namespace = v
 	}
-	if v, ok := m["jsonPointers"].([]any); ok {
-		for _, p := range v {
-			if s, ok := p.(string); ok {
-				rule.JSONPointers = append(rule.JSONPointers, s)
-			}
-		}
-	}
-	if v, ok := m["jqPathExpressions"].([]any); ok {
-		for _, p := range v {
-			if s, ok := p.(string); ok {
-				rule.JQPathExpressions = append(rule.JQPathExpressions, s)
-			}
-		}
-	}
+	rule.JSONPointers = stringList(m["jsonPointers"])
+	rule.JQPathExpressions = stringList(m["jqPathExpressions"])
 
 	// We require at least Kind and one jsonPointer or jqPathExpression to be useful
 	if rule.Kind == "" || (len(rule.JSONPointers) == 0 && len(rule.JQPathExpressions) == 0) {
-		return ignoreDifferenceRule{}, false
+		return IgnoreDifferenceRule{}, false
 	}
 	return rule, true
 }
 
+// stringList returns the strings of a YAML list, skipping other values
+func stringList(v any) []string {
+	list, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 // ApplyIgnoreDifferencesToManifests mutates the manifests in-place to remove/mask fields
 // specified by the ignoreDifferences rules. This ensures both branches produce identical
 // content at those paths and therefore do not show up in the final diff.
-func ApplyIgnoreDifferencesToManifests(manifests []unstructured.Unstructured, rules []ignoreDifferenceRule) {
+func ApplyIgnoreDifferencesToManifests(manifests []unstructured.Unstructured, rules []IgnoreDifferenceRule) {
 	if len(rules) == 0 {
 		return
 	}
@@ -129,20 +132,22 @@ func ApplyIgnoreDifferencesToManifests(manifests []unstructured.Unstructured, ru
 
 // applyJQPathExpression evaluates a jq expression and deletes/masks values at returned paths.
 // The provided expression is wrapped with jq's path(<expr>) helper to obtain token arrays.
-func applyJQPathExpression(obj map[string]any, expr string) {
+// It returns true if a value was deleted or masked.
+func applyJQPathExpression(obj map[string]any, expr string) bool {
 	if expr == "" {
-		return
+		return false
 	}
 	q, err := gojq.Parse("path(" + expr + ")")
 	if err != nil {
 		log.Debug().Err(err).Msgf("ignoreDifferences: invalid jqPathExpression: %s", expr)
-		return
+		return false
 	}
 	code, err := gojq.Compile(q)
 	if err != nil {
 		log.Debug().Err(err).Msgf("ignoreDifferences: failed to compile jqPathExpression: %s", expr)
-		return
+		return false
 	}
+	applied := false
 	iter := code.Run(obj)
 	for {
 		v, ok := iter.Next()
@@ -156,20 +161,21 @@ func applyJQPathExpression(obj map[string]any, expr string) {
 
 		// Expect a single path (array of tokens). If it's an array of arrays, handle each.
 		if arr, ok := v.([]any); ok {
-			applyTokens(obj, arr)
+			applied = applyTokens(obj, arr) || applied
 			continue
 		}
 		if arrs, ok := v.([][]any); ok {
 			for _, tokens := range arrs {
-				applyTokens(obj, tokens)
+				applied = applyTokens(obj, tokens) || applied
 			}
 		}
 	}
+	return applied
 }
 
 // applyTokens traverses obj following jq path tokens and removes the final map key
-// or masks the final array element.
-func applyTokens(obj map[string]any, tokens []any) {
+// or masks the final array element. It returns true if a value was deleted or masked.
+func applyTokens(obj map[string]any, tokens []any) bool {
 	var parent any = obj
 	for i, tok := range tokens {
 		last := i == len(tokens)-1
@@ -177,15 +183,18 @@ func applyTokens(obj map[string]any, tokens []any) {
 		case map[string]any:
 			key, ok := tok.(string)
 			if !ok {
-				return
+				return false
 			}
 			if last {
+				if _, ok := cur[key]; !ok {
+					return false
+				}
 				delete(cur, key)
-				return
+				return true
 			}
 			next, ok := cur[key]
 			if !ok {
-				return
+				return false
 			}
 			parent = next
 		case []any:
@@ -198,23 +207,24 @@ func applyTokens(obj map[string]any, tokens []any) {
 			case float64:
 				idx = int(n)
 			default:
-				return
+				return false
 			}
 			if idx < 0 || idx >= len(cur) {
-				return
+				return false
 			}
 			if last {
 				cur[idx] = maskedValue
-				return
+				return true
 			}
 			parent = cur[idx]
 		default:
-			return
+			return false
 		}
 	}
+	return false
 }
 
-func ruleMatches(r ignoreDifferenceRule, group, kind, name, namespace string) bool {
+func ruleMatches(r IgnoreDifferenceRule, group, kind, name, namespace string) bool {
 	if r.Kind != "" && !strings.EqualFold(r.Kind, kind) {
 		return false
 	}
@@ -243,13 +253,14 @@ func groupFromAPIVersion(apiVersion string) string {
 // deleteOrMaskAtJSONPointer applies a JSON Pointer (RFC 6901) to obj.
 // If the target is a map key, it deletes the key. If it's an array index,
 // it replaces the element with a masked value. Invalid pointers are ignored.
-func deleteOrMaskAtJSONPointer(obj map[string]any, pointer string) {
+// It returns true if a value was deleted or masked.
+func deleteOrMaskAtJSONPointer(obj map[string]any, pointer string) bool {
 	if pointer == "" {
-		return
+		return false
 	}
 	if pointer[0] != '/' {
 		// Per RFC 6901, pointers must start with '/'
-		return
+		return false
 	}
 
 	// Split and unescape tokens
@@ -263,13 +274,16 @@ func deleteOrMaskAtJSONPointer(obj map[string]any, pointer string) {
 		switch cur := parent.(type) {
 		case map[string]any:
 			if last {
+				if _, ok := cur[tok]; !ok {
+					return false
+				}
 				delete(cur, tok)
-				return
+				return true
 			}
 			next, ok := cur[tok]
 			if !ok {
 				// Nothing to do
-				return
+				return false
 			}
 			parent = next
 
@@ -277,20 +291,21 @@ func deleteOrMaskAtJSONPointer(obj map[string]any, pointer string) {
 			// Token must be an index
 			idx, err := strconv.Atoi(tok)
 			if err != nil || idx < 0 || idx >= len(cur) {
-				return
+				return false
 			}
 			if last {
 				cur[idx] = maskedValue
-				return
+				return true
 			}
 			parent = cur[idx]
 
 		default:
 			// Can't traverse further
 			log.Debug().Msgf("ignoreDifferences: cannot traverse token '%s' at pointer '%s'", tok, pointer)
-			return
+			return false
 		}
 	}
+	return false
 }
 
 func decodeJSONPointerToken(s string) string {
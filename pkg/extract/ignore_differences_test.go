@@ -15,7 +15,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 	tests := []struct {
 		name          string
 		appObj        map[string]any
-		expectedRules []ignoreDifferenceRule
+		expectedRules []IgnoreDifferenceRule
 		expectedCount int
 	}{
 		{
@@ -45,7 +45,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{
+			expectedRules: []IgnoreDifferenceRule{
 				{
 					Group:        "admissionregistration.k8s.io",
 					Kind:         "ValidatingWebhookConfiguration",
@@ -84,7 +84,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{
+			expectedRules: []IgnoreDifferenceRule{
 				{
 					Kind:         "Deployment",
 					JSONPointers: []string{"/metadata/annotations"},
@@ -106,7 +106,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{
+			expectedRules: []IgnoreDifferenceRule{
 				{
 					Kind:              "Deployment",
 					JQPathExpressions: []string{".spec.template.spec.containers[].image"},
@@ -129,7 +129,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{
+			expectedRules: []IgnoreDifferenceRule{
 				{
 					Kind:              "Service",
 					JSONPointers:      []string{"/metadata/labels"},
@@ -147,7 +147,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					"ignoreDifferences": []any{},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{},
+			expectedRules: []IgnoreDifferenceRule{},
 			expectedCount: 0,
 		},
 		{
@@ -157,7 +157,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 				"kind":       "Application",
 				"spec":       map[string]any{},
 			},
-			expectedRules: []ignoreDifferenceRule{},
+			expectedRules: []IgnoreDifferenceRule{},
 			expectedCount: 0,
 		},
 		{
@@ -174,7 +174,7 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{},
+			expectedRules: []IgnoreDifferenceRule{},
 			expectedCount: 0,
 		},
 		{
@@ -190,13 +190,13 @@ func TestParseIgnoreDifferencesFromApp(t *testing.T) {
 					},
 				},
 			},
-			expectedRules: []ignoreDifferenceRule{},
+			expectedRules: []IgnoreDifferenceRule{},
 			expectedCount: 0,
 		},
 		{
 			name:          "Nil yaml object",
 			appObj:        nil,
-			expectedRules: []ignoreDifferenceRule{},
+			expectedRules: []IgnoreDifferenceRule{},
 			expectedCount: 0,
 		},
 	}
@@ -236,7 +236,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 	tests := []struct {
 		name      string
 		manifests []unstructured.Unstructured
-		rules     []ignoreDifferenceRule
+		rules     []IgnoreDifferenceRule
 		validate  func(t *testing.T, manifests []unstructured.Unstructured)
 	}{
 		{
@@ -283,7 +283,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Group:        "admissionregistration.k8s.io",
 					Kind:         "ValidatingWebhookConfiguration",
@@ -358,7 +358,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Kind:         "Deployment",
 					JSONPointers: []string{"/spec/template/spec/containers/1"},
@@ -394,7 +394,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Kind:         "Secret",
 					JSONPointers: []string{"/data"},
@@ -419,7 +419,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{},
+			rules: []IgnoreDifferenceRule{},
 			validate: func(t *testing.T, manifests []unstructured.Unstructured) {
 				// Secret should remain unchanged
 				val, found, err := unstructured.NestedString(manifests[0].Object, "data", "key")
@@ -453,7 +453,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Kind:         "Service",
 					JSONPointers: []string{"/metadata/labels", "/spec/ports/0/nodePort"},
@@ -512,7 +512,7 @@ func TestApplyIgnoreDifferencesToManifests(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Group:        "", // Core group
 					Kind:         "Pod",
@@ -864,7 +864,7 @@ func deepCopyValue(v any) any {
 func TestRuleMatches(t *testing.T) {
 	tests := []struct {
 		testName  string
-		rule      ignoreDifferenceRule
+		rule      IgnoreDifferenceRule
 		group     string
 		kind      string
 		name      string
@@ -873,7 +873,7 @@ func TestRuleMatches(t *testing.T) {
 	}{
 		{
 			testName: "Exact match all fields",
-			rule: ignoreDifferenceRule{
+			rule: IgnoreDifferenceRule{
 				Group:     "apps",
 				Kind:      "Deployment",
 				Name:      "test-app",
@@ -887,7 +887,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			testName: "Kind only match",
-			rule: ignoreDifferenceRule{
+			rule: IgnoreDifferenceRule{
 				Kind: "Secret",
 			},
 			group:     "",
@@ -898,7 +898,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			testName: "Case insensitive kind match",
-			rule: ignoreDifferenceRule{
+			rule: IgnoreDifferenceRule{
 				Kind: "deployment",
 			},
 			group:     "apps",
@@ -909,7 +909,7 @@ func TestRuleMatches(t *testing.T) {
 		},
 		{
 			testName: "Group mismatch",
-			rule: ignoreDifferenceRule{
+			rule: IgnoreDifferenceRule{
 				Group: "networking.k8s.io",
 				Kind:  "Ingress",
 			},
@@ -1199,7 +1199,7 @@ func TestApplyIgnoreDifferencesWithJQPathExpressions(t *testing.T) {
 	tests := []struct {
 		name      string
 		manifests []unstructured.Unstructured
-		rules     []ignoreDifferenceRule
+		rules     []IgnoreDifferenceRule
 		validate  func(t *testing.T, manifests []unstructured.Unstructured)
 	}{
 		{
@@ -1229,7 +1229,7 @@ func TestApplyIgnoreDifferencesWithJQPathExpressions(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Kind:              "Deployment",
 					JQPathExpressions: []string{".spec.template.spec.containers[].image"},
@@ -1281,7 +1281,7 @@ func TestApplyIgnoreDifferencesWithJQPathExpressions(t *testing.T) {
 					},
 				}},
 			},
-			rules: []ignoreDifferenceRule{
+			rules: []IgnoreDifferenceRule{
 				{
 					Kind:              "Service",
 					JSONPointers:      []string{"/metadata/labels"},
@@ -0,0 +1,227 @@
+package fileparsing
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/rs/zerolog/log"
+)
+
+// IgnoreFileName is the file in the repository root listing the paths (gitignore syntax)
+// that are not searched for Applications
+const IgnoreFileName = ".argocd-diff-preview-ignore"
+
+// ExclusionRule is the rule that excluded a file from discovery
+type ExclusionRule string
+
+const (
+	ExcludedByIgnoreFile  ExclusionRule = "ignore-file"
+	ExcludedByIncludeGlob ExclusionRule = "include-files"
+	ExcludedByFileRegex   ExclusionRule = "file-regex"
+)
+
+// ExcludedFile is a file or folder that was not searched for Applications.
+// Folders end with '/'.
+type ExcludedFile struct {
+	Path   string
+	Rule   ExclusionRule
+	Reason string
+}
+
+// FileFilter decides which files in a branch folder are searched for Applications
+type FileFilter struct {
+	FileRegex      *regexp.Regexp
+	IncludeGlobs   []string // gitignore syntax. Empty means all files are included
+	IgnorePatterns []string // gitignore syntax, e.g. the lines of the IgnoreFileName file
+}
+
+// filePattern is a parsed gitignore pattern and the line it was parsed from
+type filePattern struct {
+	raw     string
+	pattern gitignore.Pattern
+}
+
+func parsePatterns(lines []string) []filePattern {
+	var patterns []filePattern
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		patterns = append(patterns, filePattern{raw: trimmed, pattern: gitignore.ParsePattern(trimmed, nil)})
+	}
+	return patterns
+}
+
+// ReadIgnoreFile reads the patterns of the IgnoreFileName file in the directory.
+// A missing file results in no patterns.
+func ReadIgnoreFile(directory string) ([]string, error) {
+	f, err := os.Open(filepath.Join(directory, IgnoreFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", IgnoreFileName, err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Warn().Err(err).Msgf("⚠️ Failed to close file '%s'", IgnoreFileName)
+		}
+	}()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
+	}
+	return patterns, nil
+}
+
+// ignoredBy returns the last ignore pattern matching the path. Like in gitignore,
+// a later negated pattern ('!path') includes the path again.
+func ignoredBy(patterns []filePattern, relPath string, isDir bool) (string, bool) {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, p := range slices.Backward(patterns) {
+		switch p.pattern.Match(parts, isDir) {
+		case gitignore.Exclude:
+			return p.raw, true
+		case gitignore.Include:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// matchesAny returns true if any of the patterns matches the file
+func matchesAny(patterns []filePattern, relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	return slices.ContainsFunc(patterns, func(p filePattern) bool {
+		return p.pattern.Match(parts, false) == gitignore.Exclude
+	})
+}
+
+// declaresApplication returns true if the file has an Application or ApplicationSet document
+func declaresApplication(path string) bool {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Debug().Err(err).Msgf("Failed to read excluded file '%s'", path)
+		return false
+	}
+	return argoKindPattern.Match(content)
+}
+
+// GetYamlFiles gets all YAML files in a directory that pass the filter, and the
+// files and folders that were excluded. Ignored folders are not walked. Files not
+// matching the include globs or file regex are only returned as excluded if they
+// have an Application or ApplicationSet, since most YAML files in a repository don't.
+func (f FileFilter) GetYamlFiles(directory string) ([]string, []ExcludedFile) {
+	log.Debug().Msgf("Fetching all files in dir: %s", directory)
+
+	ignorePatterns := parsePatterns(f.IgnorePatterns)
+	includePatterns := parsePatterns(f.IncludeGlobs)
+
+	var yamlFiles []string
+	var excluded []ExcludedFile
+	unmatched := 0 // files not matching the include globs or file regex, without Applications
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Convert path to relative path
+		relPath, err := filepath.Rel(directory, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			if pattern, ignored := ignoredBy(ignorePatterns, relPath, true); ignored {
+				excluded = append(excluded, ExcludedFile{
+					Path:   relPath + "/",
+					Rule:   ExcludedByIgnoreFile,
+					Reason: fmt.Sprintf("folder matches '%s' in %s", pattern, IgnoreFileName),
+				})
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Check if file has .yaml or .yml extension
+		ext := filepath.Ext(path)
+		if ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		if pattern, ignored := ignoredBy(ignorePatterns, relPath, false); ignored {
+			excluded = append(excluded, ExcludedFile{
+				Path:   relPath,
+				Rule:   ExcludedByIgnoreFile,
+				Reason: fmt.Sprintf("file matches '%s' in %s", pattern, IgnoreFileName),
+			})
+			return nil
+		}
+
+		if len(includePatterns) > 0 && !matchesAny(includePatterns, relPath) {
+			if !declaresApplication(path) {
+				unmatched++
+				return nil
+			}
+			excluded = append(excluded, ExcludedFile{
+				Path:   relPath,
+				Rule:   ExcludedByIncludeGlob,
+				Reason: fmt.Sprintf("file does not match any of '%s'", strings.Join(f.IncludeGlobs, "', '")),
+			})
+			return nil
+		}
+
+		// Check regex if provided
+		if f.FileRegex != nil && !f.FileRegex.MatchString(relPath) {
+			if !declaresApplication(path) {
+				unmatched++
+				return nil
+			}
+			excluded = append(excluded, ExcludedFile{
+				Path:   relPath,
+				Rule:   ExcludedByFileRegex,
+				Reason: fmt.Sprintf("file does not match file-regex '%s'", f.FileRegex.String()),
+			})
+			return nil
+		}
+
+		yamlFiles = append(yamlFiles, relPath)
+		return nil
+	})
+
+	if err != nil {
+		log.Error().Err(err).Msg("⚠️ Error reading directory")
+		return []string{}, nil
+	}
+
+	if len(excluded) > 0 || unmatched > 0 {
+		log.Debug().Msgf("Found %d yaml files in dir '%s' and excluded %d paths and %d files without Application[Sets]",
+			len(yamlFiles), directory, len(excluded), unmatched)
+	} else {
+		log.Debug().Msgf("Found %d yaml files in dir '%s'",
+			len(yamlFiles), directory)
+	}
+
+	return yamlFiles, excluded
+}
@@ -0,0 +1,141 @@
+package fileparsing
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFilter_GetYamlFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, file := range []string{
+		"apps/guestbook.yaml",
+		"apps/archived/old.yaml",
+		"apps/test/fixture.yml",
+		"apps/test/keep.yaml",
+		"charts/app/templates/application.yaml",
+		"clusters/prod/apps.yaml",
+		"README.md",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), []byte("kind: Application"), 0644))
+	}
+
+	t.Run("ignore patterns", func(t *testing.T) {
+		filter := FileFilter{IgnorePatterns: []string{"archived/", "charts/*/templates", "apps/test/*.yml", "!apps/test/fixture.yml", "/clusters/prod/apps.yaml"}}
+
+		files, excluded := filter.GetYamlFiles(tempDir)
+
+		assert.ElementsMatch(t, []string{"apps/guestbook.yaml", "apps/test/fixture.yml", "apps/test/keep.yaml"}, files)
+		assert.ElementsMatch(t, []ExcludedFile{
+			{Path: "apps/archived/", Rule: ExcludedByIgnoreFile, Reason: "folder matches 'archived/' in .argocd-diff-preview-ignore"},
+			{Path: "charts/app/templates/", Rule: ExcludedByIgnoreFile, Reason: "folder matches 'charts/*/templates' in .argocd-diff-preview-ignore"},
+			{Path: "clusters/prod/apps.yaml", Rule: ExcludedByIgnoreFile, Reason: "file matches '/clusters/prod/apps.yaml' in .argocd-diff-preview-ignore"},
+		}, excluded)
+	})
+
+	t.Run("include globs and file regex", func(t *testing.T) {
+		filter := FileFilter{
+			IncludeGlobs: []string{"apps/**/*.yaml"},
+			FileRegex:    regexp.MustCompile(`guestbook`),
+		}
+
+		files, excluded := filter.GetYamlFiles(tempDir)
+
+		assert.Equal(t, []string{"apps/guestbook.yaml"}, files)
+		assert.ElementsMatch(t, []ExcludedFile{
+			{Path: "apps/archived/old.yaml", Rule: ExcludedByFileRegex, Reason: "file does not match file-regex 'guestbook'"},
+			{Path: "apps/test/keep.yaml", Rule: ExcludedByFileRegex, Reason: "file does not match file-regex 'guestbook'"},
+			{Path: "apps/test/fixture.yml", Rule: ExcludedByIncludeGlob, Reason: "file does not match any of 'apps/**/*.yaml'"},
+			{Path: "charts/app/templates/application.yaml", Rule: ExcludedByIncludeGlob, Reason: "file does not match any of 'apps/**/*.yaml'"},
+			{Path: "clusters/prod/apps.yaml", Rule: ExcludedByIncludeGlob, Reason: "file does not match any of 'apps/**/*.yaml'"},
+		}, excluded)
+	})
+}
+
+func TestFileFilter_GetYamlFiles_FilesWithoutApplications(t *testing.T) {
+	tempDir := t.TempDir()
+	for file, content := range map[string]string{
+		"apps/guestbook.yaml":    "kind: Application",
+		"apps/appset.yaml":       "apiVersion: v1\nkind: ConfigMap\n---\nkind: ApplicationSet",
+		"charts/app/values.yaml": "replicaCount: 1",
+		"charts/app/Chart.yaml":  "apiVersion: v2\nname: app",
+		"archived/values.yaml":   "replicaCount: 1",
+		"manifests/service.yaml": "kind: Service",
+		"manifests/ingress.yaml": "kind: Ingress",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644))
+	}
+
+	// Only files with an Application[Set] are reported when they miss the include globs or
+	// file regex, while every path matching the ignore file is reported
+	filter := FileFilter{
+		IgnorePatterns: []string{"archived/"},
+		IncludeGlobs:   []string{"apps/**", "manifests/**"},
+		FileRegex:      regexp.MustCompile(`guestbook|manifests`),
+	}
+
+	files, excluded := filter.GetYamlFiles(tempDir)
+
+	assert.ElementsMatch(t, []string{"apps/guestbook.yaml", "manifests/service.yaml", "manifests/ingress.yaml"}, files)
+	assert.ElementsMatch(t, []ExcludedFile{
+		{Path: "archived/", Rule: ExcludedByIgnoreFile, Reason: "folder matches 'archived/' in .argocd-diff-preview-ignore"},
+		{Path: "apps/appset.yaml", Rule: ExcludedByFileRegex, Reason: "file does not match file-regex 'guestbook|manifests'"},
+	}, excluded)
+}
+
+func TestFileFilter_GetYamlFiles_NegatedFileInIgnoredFolder(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, file := range []string{
+		"archived/old.yaml",
+		"archived/keep-me.yaml",
+		"archived/nested/old.yaml",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(file)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), []byte("kind: Application"), 0644))
+	}
+
+	// Like in gitignore, a file can not be included again when its folder is ignored
+	t.Run("ignored folder", func(t *testing.T) {
+		filter := FileFilter{IgnorePatterns: []string{"archived/", "!archived/keep-me.yaml"}}
+
+		files, excluded := filter.GetYamlFiles(tempDir)
+
+		assert.Empty(t, files)
+		assert.Equal(t, []ExcludedFile{
+			{Path: "archived/", Rule: ExcludedByIgnoreFile, Reason: "folder matches 'archived/' in .argocd-diff-preview-ignore"},
+		}, excluded)
+	})
+
+	t.Run("ignored folder content", func(t *testing.T) {
+		filter := FileFilter{IgnorePatterns: []string{"archived/*", "!archived/keep-me.yaml"}}
+
+		files, excluded := filter.GetYamlFiles(tempDir)
+
+		assert.Equal(t, []string{"archived/keep-me.yaml"}, files)
+		assert.ElementsMatch(t, []ExcludedFile{
+			{Path: "archived/nested/", Rule: ExcludedByIgnoreFile, Reason: "folder matches 'archived/*' in .argocd-diff-preview-ignore"},
+			{Path: "archived/old.yaml", Rule: ExcludedByIgnoreFile, Reason: "file matches 'archived/*' in .argocd-diff-preview-ignore"},
+		}, excluded)
+	})
+}
+
+func TestReadIgnoreFile(t *testing.T) {
+	tempDir := t.TempDir()
+
+	patterns, err := ReadIgnoreFile(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	content := "# test fixtures\n\ntest/\n  archived/**  \n!archived/keep.yaml\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, IgnoreFileName), []byte(content), 0644))
+
+	patterns, err = ReadIgnoreFile(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test/", "archived/**", "!archived/keep.yaml"}, patterns)
+}
@@ -2,6 +2,8 @@ package fileparsing
 
 import (
 	"bufio"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -13,61 +15,22 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-// GetYamlFiles gets all YAML files in a directory
-func GetYamlFiles(directory string, fileRegex *regexp.Regexp) []string {
-	log.Debug().Msgf("Fetching all files in dir: %s", directory)
-
-	var yamlFiles []string
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			return nil
-		}
-
-		// Check if file has .yaml or .yml extension
-		ext := filepath.Ext(path)
-		if ext != ".yaml" && ext != ".yml" {
-			return nil
-		}
-
-		// Convert path to relative path
-		relPath, err := filepath.Rel(directory, path)
-		if err != nil {
-			return err
-		}
-
-		// Check regex if provided
-		if fileRegex != nil {
-			if !fileRegex.MatchString(relPath) {
-				return nil
-			}
-		}
-
-		yamlFiles = append(yamlFiles, relPath)
-		return nil
-	})
-
-	if err != nil {
-		log.Error().Err(err).Msg("⚠️ Error reading directory")
-		return []string{}
-	}
-
-	if fileRegex != nil {
-		log.Debug().Msgf("Found %d yaml files in dir '%s' matching regex: %s",
-			len(yamlFiles), directory, fileRegex.String())
-	} else {
-		log.Debug().Msgf("Found %d yaml files in dir '%s'",
-			len(yamlFiles), directory)
-	}
-
-	return yamlFiles
+// ParseProblem is a YAML document that looks like an Argo CD Application[Set] but
+// could not be parsed into a Kubernetes resource
+type ParseProblem struct {
+	FileName string
+	Branch   git.BranchType
+	Message  string
 }
 
-// ParseYaml parses YAML files into Resources
-func ParseYaml(dir string, files []string, branch git.BranchType) []Resource {
+// argoKindPattern matches the kind line of an Application or ApplicationSet
+var argoKindPattern = regexp.MustCompile(`(?m)^kind:\s*["']?Application(Set)?["']?\s*$`)
+
+// ParseYaml parses YAML files into Resources. Documents that look like an Application[Set]
+// but can't be parsed are returned as ParseProblems.
+func ParseYaml(dir string, files []string, branch git.BranchType) ([]Resource, []ParseProblem) {
 	var resources []Resource
+	var problems []ParseProblem
 
 	for _, file := range files {
 		log.Debug().Msgf("In dir '%s' found yaml file: %s", dir, file)
@@ -84,36 +47,75 @@ func ParseYaml(dir string, files []string, branch git.BranchType) []Resource {
 			}
 		}()
 
-		// Read file line by line and split on "---"
-		var currentChunk strings.Builder
-		scanner := bufio.NewScanner(f)
-
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			if line == "---" {
-				// Process the current chunk if it's not empty
-				if currentChunk.Len() > 0 {
-					if resource, ok := processYamlChunk(file, currentChunk.String(), branch); ok {
-						resources = append(resources, *resource)
-					}
-				}
-				currentChunk.Reset()
-			} else {
-				currentChunk.WriteString(line)
-				currentChunk.WriteString("\n")
-			}
+		fileResources, fileProblems := parseYamlDocuments(file, f, branch)
+		resources = append(resources, fileResources...)
+		problems = append(problems, fileProblems...)
+	}
+
+	return resources, problems
+}
+
+// ParseYamlString parses a multi-document YAML string, e.g. the output of helm template,
+// into Resources. fileName is used as the FileName of the Resources.
+func ParseYamlString(fileName string, content string, branch git.BranchType) ([]Resource, []ParseProblem) {
+	return parseYamlDocuments(fileName, strings.NewReader(content), branch)
+}
+
+// parseYamlDocuments reads YAML line by line and splits it into documents on "---"
+func parseYamlDocuments(file string, r io.Reader, branch git.BranchType) ([]Resource, []ParseProblem) {
+	var resources []Resource
+	var problems []ParseProblem
+	var currentChunk strings.Builder
+	processChunk := func(chunk string) {
+		if resource, ok := processYamlChunk(file, chunk, branch); ok {
+			resources = append(resources, *resource)
+		} else if message, isProblem := parseProblem(chunk); isProblem {
+			problems = append(problems, ParseProblem{FileName: file, Branch: branch, Message: message})
 		}
+	}
+
+	scanner := bufio.NewScanner(r)
 
-		// Process the last chunk
-		if currentChunk.Len() > 0 {
-			if resource, ok := processYamlChunk(file, currentChunk.String(), branch); ok {
-				resources = append(resources, *resource)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "---" {
+			// Process the current chunk if it's not empty
+			if currentChunk.Len() > 0 {
+				processChunk(currentChunk.String())
 			}
+			currentChunk.Reset()
+		} else {
+			currentChunk.WriteString(line)
+			currentChunk.WriteString("\n")
 		}
 	}
 
-	return resources
+	// Process the last chunk
+	if currentChunk.Len() > 0 {
+		processChunk(currentChunk.String())
+	}
+
+	return resources, problems
+}
+
+// parseProblem explains why a chunk that processYamlChunk rejected is a problem. Only
+// chunks with an Application or ApplicationSet kind are problems. Chunks containing Go
+// templates (e.g. Helm chart templates) are not valid YAML until rendered, so they are
+// not problems either.
+func parseProblem(chunk string) (string, bool) {
+	if !argoKindPattern.MatchString(chunk) || strings.Contains(chunk, "{{") {
+		return "", false
+	}
+
+	var yamlObj map[string]any
+	if err := yaml.Unmarshal([]byte(chunk), &yamlObj); err != nil {
+		return fmt.Sprintf("invalid YAML: %s", err), true
+	}
+	if apiVersion, _, _ := unstructured.NestedString(yamlObj, "apiVersion"); apiVersion == "" {
+		return "apiVersion is missing", true
+	}
+	return "", false
 }
 
 // processYamlChunk parses a YAML chunk into a Resource
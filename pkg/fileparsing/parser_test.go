@@ -49,7 +49,7 @@ metadata:
 	}
 
 	t.Run("GetYamlFiles", func(t *testing.T) {
-		files := GetYamlFiles(tempDir, nil)
+		files, _ := FileFilter{}.GetYamlFiles(tempDir)
 		assert.Len(t, files, 2)
 		assert.Contains(t, files, "test1.yaml")
 		assert.Contains(t, files, "test2.yaml")
@@ -57,13 +57,14 @@ metadata:
 
 	t.Run("ParseYaml", func(t *testing.T) {
 		files := []string{"test1.yaml", "test2.yaml"}
-		resources := ParseYaml(tempDir, files, git.BranchType(""))
+		resources, problems := ParseYaml(tempDir, files, git.BranchType(""))
+		assert.Empty(t, problems)
 		assert.Len(t, resources, 2)
 	})
 
 	t.Run("WithFileRegex", func(t *testing.T) {
 		regex := regexp.MustCompile("test1.yaml")
-		files := GetYamlFiles(tempDir, regex)
+		files, _ := FileFilter{FileRegex: regex}.GetYamlFiles(tempDir)
 		assert.Len(t, files, 1)
 		assert.Contains(t, files, "test1.yaml")
 	})
@@ -132,3 +133,42 @@ spec:
 		})
 	}
 }
+
+func TestParseYamlString_Problems(t *testing.T) {
+	content := `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: broken
+   namespace: argocd
+---
+kind: ApplicationSet
+metadata:
+  name: no-api-version
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: {{ .Values.name }}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+   namespace: default
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: valid
+`
+
+	resources, problems := ParseYamlString("apps.yaml", content, git.Target)
+
+	assert.Len(t, resources, 1)
+	if assert.Len(t, problems, 2) {
+		assert.Equal(t, "apps.yaml", problems[0].FileName)
+		assert.Equal(t, git.Target, problems[0].Branch)
+		assert.Contains(t, problems[0].Message, "invalid YAML")
+		assert.Equal(t, "apiVersion is missing", problems[1].Message)
+	}
+}
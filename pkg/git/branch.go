@@ -29,11 +29,16 @@ func (b BranchType) ShortName() string {
 	return "?"
 }
 
+// FolderName returns the folder a branch of this type is checked out to
+func (b BranchType) FolderName() string {
+	return fmt.Sprintf("%s-branch", b)
+}
+
 // NewBranch creates a new Branch instance
 func NewBranch(name string, branchType BranchType) *Branch {
 	return &Branch{
 		Name:       name,
-		folderName: fmt.Sprintf("%s-branch", branchType),
+		folderName: branchType.FolderName(),
 		branchType: branchType,
 	}
 }
@@ -0,0 +1,125 @@
+package immutable_fields
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ImmutableField is a field that can not be changed once the resource is created. Changing it
+// makes the sync fail, unless the resource is replaced (e.g. with the Replace=true sync option).
+type ImmutableField struct {
+	Group string
+	Kind  string
+	Path  string // dot-separated, e.g. spec.selector
+}
+
+// String returns the string representation of the ImmutableField
+func (f *ImmutableField) String() string {
+	return fmt.Sprintf("[Group: %s, Kind: %s, Path: %s]", f.Group, f.Kind, f.Path)
+}
+
+// BuiltIn is the catalogue of immutable fields of the built-in kinds
+var BuiltIn = []ImmutableField{
+	{Group: "apps", Kind: "Deployment", Path: "spec.selector"},
+	{Group: "apps", Kind: "ReplicaSet", Path: "spec.selector"},
+	{Group: "apps", Kind: "DaemonSet", Path: "spec.selector"},
+	{Group: "apps", Kind: "StatefulSet", Path: "spec.selector"},
+	{Group: "apps", Kind: "StatefulSet", Path: "spec.serviceName"},
+	{Group: "apps", Kind: "StatefulSet", Path: "spec.volumeClaimTemplates"},
+	{Group: "apps", Kind: "StatefulSet", Path: "spec.podManagementPolicy"},
+	{Group: "batch", Kind: "Job", Path: "spec.selector"},
+	{Group: "batch", Kind: "Job", Path: "spec.template"},
+	{Group: "batch", Kind: "Job", Path: "spec.completionMode"},
+	{Group: "", Kind: "Service", Path: "spec.clusterIP"},
+	{Group: "", Kind: "PersistentVolumeClaim", Path: "spec.storageClassName"},
+	{Group: "", Kind: "PersistentVolumeClaim", Path: "spec.accessModes"},
+	{Group: "", Kind: "PersistentVolumeClaim", Path: "spec.volumeName"},
+	{Group: "", Kind: "PersistentVolumeClaim", Path: "spec.volumeMode"},
+	{Group: "", Kind: "PersistentVolumeClaim", Path: "spec.selector"},
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding", Path: "roleRef"},
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding", Path: "roleRef"},
+	{Group: "storage.k8s.io", Kind: "StorageClass", Path: "provisioner"},
+	{Group: "storage.k8s.io", Kind: "StorageClass", Path: "parameters"},
+	{Group: "storage.k8s.io", Kind: "StorageClass", Path: "reclaimPolicy"},
+	{Group: "storage.k8s.io", Kind: "StorageClass", Path: "volumeBindingMode"},
+}
+
+// format is --immutable-fields="group:kind:path,group:kind:path"
+// * means any group or kind, and the core group is written as an empty string or 'core'
+
+// FromString creates new ImmutableFields from a string representation
+func FromString(s string) ([]ImmutableField, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var fields []ImmutableField
+
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		parts := strings.Split(field, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid immutable field format: %s (expected group:kind:path)", field)
+		}
+
+		path := strings.TrimSpace(parts[2])
+		if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+			return nil, fmt.Errorf("invalid immutable field path: %s (expected e.g. spec.selector)", field)
+		}
+
+		group := strings.TrimSpace(parts[0])
+		if group == "core" {
+			group = ""
+		}
+
+		fields = append(fields, ImmutableField{
+			Group: group,
+			Kind:  strings.TrimSpace(parts[1]),
+			Path:  path,
+		})
+	}
+
+	return fields, nil
+}
+
+// matches checks if the ImmutableField applies to the given group and kind
+func (f *ImmutableField) matches(group, kind string) bool {
+	return (f.Group == "*" || f.Group == group) && (f.Kind == "*" || f.Kind == kind)
+}
+
+// Changed returns the paths of the immutable fields that differ between two versions of a
+// resource. A field missing in both versions is unchanged. Resources of different kinds are
+// replaced anyway, so they are not compared.
+func Changed(fields []ImmutableField, base, target *unstructured.Unstructured) []string {
+	if base == nil || target == nil {
+		return nil
+	}
+	baseGVK, targetGVK := base.GroupVersionKind(), target.GroupVersionKind()
+	if baseGVK.Group != targetGVK.Group || baseGVK.Kind != targetGVK.Kind {
+		return nil
+	}
+
+	var changed []string
+	seen := map[string]bool{}
+	for _, field := range fields {
+		if !field.matches(targetGVK.Group, targetGVK.Kind) || seen[field.Path] {
+			continue
+		}
+		seen[field.Path] = true
+
+		path := strings.Split(field.Path, ".")
+		baseValue, baseFound, _ := unstructured.NestedFieldNoCopy(base.Object, path...)
+		targetValue, targetFound, _ := unstructured.NestedFieldNoCopy(target.Object, path...)
+		if baseFound != targetFound || !reflect.DeepEqual(baseValue, targetValue) {
+			changed = append(changed, field.Path)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,169 @@
+package immutable_fields
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+func TestFromString(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    []ImmutableField
+		expectError bool
+	}{
+		{
+			name:     "single field",
+			input:    "apps:StatefulSet:spec.serviceName",
+			expected: []ImmutableField{{Group: "apps", Kind: "StatefulSet", Path: "spec.serviceName"}},
+		},
+		{
+			name:  "multiple fields with whitespace",
+			input: " example.com:Widget:spec.size , *:*:spec.immutable ",
+			expected: []ImmutableField{
+				{Group: "example.com", Kind: "Widget", Path: "spec.size"},
+				{Group: "*", Kind: "*", Path: "spec.immutable"},
+			},
+		},
+		{
+			name:     "core group",
+			input:    "core:ConfigMap:data,:Secret:data",
+			expected: []ImmutableField{{Group: "", Kind: "ConfigMap", Path: "data"}, {Group: "", Kind: "Secret", Path: "data"}},
+		},
+		{
+			name:     "empty string",
+			input:    "",
+			expected: nil,
+		},
+		{
+			name:        "missing path",
+			input:       "apps:Deployment",
+			expectError: true,
+		},
+		{
+			name:        "empty path",
+			input:       "apps:Deployment:",
+			expectError: true,
+		},
+		{
+			name:        "path with trailing dot",
+			input:       "apps:Deployment:spec.",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FromString(tt.input)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+
+			if len(result) != len(tt.expected) {
+				t.Errorf("got %d fields, want %d", len(result), len(tt.expected))
+				return
+			}
+
+			for i, field := range result {
+				if field != tt.expected[i] {
+					t.Errorf("field[%d] = %+v, want %+v", i, field, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func parseManifest(t *testing.T, manifest string) *unstructured.Unstructured {
+	t.Helper()
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(manifest), &obj.Object); err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	return &obj
+}
+
+func TestChanged(t *testing.T) {
+	deployment := `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+spec:
+  replicas: 1
+  selector: {matchLabels: {app: web}}
+`
+	tests := []struct {
+		name     string
+		base     string
+		target   string
+		fields   []ImmutableField
+		expected []string
+	}{
+		{
+			name:     "changed selector",
+			base:     deployment,
+			target:   `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}, spec: {replicas: 1, selector: {matchLabels: {app: frontend}}}}`,
+			fields:   BuiltIn,
+			expected: []string{"spec.selector"},
+		},
+		{
+			name:     "mutable field changed",
+			base:     deployment,
+			target:   `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}, spec: {replicas: 3, selector: {matchLabels: {app: web}}}}`,
+			fields:   BuiltIn,
+			expected: nil,
+		},
+		{
+			name:     "field added",
+			base:     `{apiVersion: v1, kind: PersistentVolumeClaim, metadata: {name: data}, spec: {accessModes: [ReadWriteOnce]}}`,
+			target:   `{apiVersion: v1, kind: PersistentVolumeClaim, metadata: {name: data}, spec: {accessModes: [ReadWriteOnce], storageClassName: fast}}`,
+			fields:   BuiltIn,
+			expected: []string{"spec.storageClassName"},
+		},
+		{
+			name:     "same kind in another group",
+			base:     `{apiVersion: example.com/v1, kind: Deployment, metadata: {name: web}, spec: {selector: a}}`,
+			target:   `{apiVersion: example.com/v1, kind: Deployment, metadata: {name: web}, spec: {selector: b}}`,
+			fields:   BuiltIn,
+			expected: nil,
+		},
+		{
+			name:     "kind changed",
+			base:     `{apiVersion: apps/v1, kind: Deployment, metadata: {name: web}, spec: {selector: a}}`,
+			target:   `{apiVersion: apps/v1, kind: StatefulSet, metadata: {name: web}, spec: {selector: b}}`,
+			fields:   BuiltIn,
+			expected: nil,
+		},
+		{
+			name:     "extended catalogue",
+			base:     `{apiVersion: example.com/v1, kind: Widget, metadata: {name: w}, spec: {size: 1}}`,
+			target:   `{apiVersion: example.com/v1, kind: Widget, metadata: {name: w}, spec: {size: 2}}`,
+			fields:   append([]ImmutableField{{Group: "example.com", Kind: "*", Path: "spec.size"}}, BuiltIn...),
+			expected: []string{"spec.size"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := Changed(tt.fields, parseManifest(t, tt.base), parseManifest(t, tt.target))
+			if len(changed) != len(tt.expected) {
+				t.Fatalf("got %v, want %v", changed, tt.expected)
+			}
+			for i := range changed {
+				if changed[i] != tt.expected[i] {
+					t.Errorf("got %v, want %v", changed, tt.expected)
+				}
+			}
+		})
+	}
+}
@@ -17,7 +17,7 @@ import (
 )
 
 type Client struct {
-	clientSet             *dynamic.DynamicClient
+	clientSet             dynamic.Interface
 	discoveryClient       discovery.DiscoveryInterface
 	cachedDiscoveryClient *disk.CachedDiscoveryClient
 	mapper                *restmapper.DeferredDiscoveryRESTMapper
@@ -7,6 +7,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
 )
 
 // GetServerVersion returns the Kubernetes server version string (e.g. "v1.29.2").
@@ -89,3 +90,33 @@ func (c *Client) GetListOfNamespacedScopedResources() (map[schema.GroupKind]bool
 	}
 	return namespacedScopedResources, nil
 }
+
+// GetResourceScopes returns whether each resource type of the cluster is namespaced. Unlike
+// GetListOfNamespacedScopedResources it also contains the cluster-scoped resource types.
+// If some API groups fail discovery, the scopes of the other groups are still returned.
+func (c *Client) GetResourceScopes() (map[schema.GroupKind]bool, error) {
+	_, apiResourceLists, err := c.discoveryClient.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover API resources: %w", err)
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("⚠️ Some API groups could not be discovered. Their resource scopes are guessed")
+	}
+
+	scopes := make(map[schema.GroupKind]bool)
+	for _, apiResourceList := range apiResourceLists {
+		gv, err := schema.ParseGroupVersion(apiResourceList.GroupVersion)
+		if err != nil {
+			log.Warn().Err(err).Msgf("Failed to parse GroupVersion: %s", apiResourceList.GroupVersion)
+			continue
+		}
+		for _, apiResource := range apiResourceList.APIResources {
+			// Skip subresources (e.g., "pods/log", "deployments/scale")
+			if strings.Contains(apiResource.Name, "/") {
+				continue
+			}
+			scopes[schema.GroupKind{Group: gv.Group, Kind: apiResource.Kind}] = apiResource.Namespaced
+		}
+	}
+	return scopes, nil
+}
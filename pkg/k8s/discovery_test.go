@@ -0,0 +1,81 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// partialDiscovery returns the resources of the healthy API groups together with an error
+// for the groups that failed discovery
+type partialDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	err error
+}
+
+func (d *partialDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	return nil, d.Resources, d.err
+}
+
+func newDiscoveryTestClient(err error) *Client {
+	return &Client{discoveryClient: &partialDiscovery{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+				{Name: "namespaces", Kind: "Namespace"},
+				{Name: "pods/log", Kind: "Pod", Namespaced: true},
+			}},
+			{GroupVersion: "gateway.networking.k8s.io/v1", APIResources: []metav1.APIResource{
+				{Name: "gatewayclasses", Kind: "GatewayClass"},
+			}},
+		}}},
+		err: err,
+	}}
+}
+
+func TestGetResourceScopes(t *testing.T) {
+	expected := map[schema.GroupKind]bool{
+		{Kind: "ConfigMap"}: true,
+		{Kind: "Namespace"}: false,
+		{Group: "gateway.networking.k8s.io", Kind: "GatewayClass"}: false,
+	}
+
+	t.Run("all groups discovered", func(t *testing.T) {
+		scopes, err := newDiscoveryTestClient(nil).GetResourceScopes()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(scopes) != len(expected) {
+			t.Errorf("expected %d scopes, got %v", len(expected), scopes)
+		}
+		for groupKind, namespaced := range expected {
+			if got, found := scopes[groupKind]; !found || got != namespaced {
+				t.Errorf("expected %v to be namespaced=%v, got %v (found=%v)", groupKind, namespaced, got, found)
+			}
+		}
+	})
+
+	t.Run("some groups failed discovery", func(t *testing.T) {
+		failed := &discovery.ErrGroupDiscoveryFailed{Groups: map[schema.GroupVersion]error{
+			{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("service unavailable"),
+		}}
+		scopes, err := newDiscoveryTestClient(failed).GetResourceScopes()
+		if err != nil {
+			t.Fatalf("expected the partial results without an error, got: %v", err)
+		}
+		if len(scopes) != len(expected) {
+			t.Errorf("expected the scopes of the discovered groups, got %v", scopes)
+		}
+	})
+
+	t.Run("discovery failed", func(t *testing.T) {
+		if _, err := newDiscoveryTestClient(errors.New("connection refused")).GetResourceScopes(); err == nil {
+			t.Errorf("expected an error")
+		}
+	})
+}
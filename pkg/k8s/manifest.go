@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/dag-andersen/argocd-diff-preview/pkg/utils"
 	"github.com/rs/zerolog/log"
@@ -189,6 +190,38 @@ func (c *Client) CreateNamespace(namespace string) (bool, error) {
 	return true, err
 }
 
+// DeleteNamespace deletes a namespace and waits until it is gone
+func (c *Client) DeleteNamespace(namespace string, timeoutSeconds int) error {
+	namespaceRes := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	defer cancel()
+
+	err := c.clientSet.Resource(namespaceRes).Delete(ctx, namespace, metav1.DeleteOptions{})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return fmt.Errorf("failed to delete namespace: %w", err)
+	}
+
+	// Poll until the namespace is gone or timeout
+	pollInterval := 1 * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for namespace '%s' to be deleted", namespace)
+		default:
+			_, err := c.clientSet.Resource(namespaceRes).Get(ctx, namespace, metav1.GetOptions{})
+			if err != nil && strings.Contains(err.Error(), "not found") {
+				return nil
+			}
+			log.Debug().Msgf("Namespace '%s' is still terminating, waiting...", namespace)
+			time.Sleep(pollInterval)
+		}
+	}
+}
+
 func (c *Client) GetConfigMaps(namespace string, names ...string) (string, error) {
 	configMapRes := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
 
@@ -248,6 +281,21 @@ func (c *Client) GetConfigMapValue(namespace string, name string, key string) (s
 	return value, nil
 }
 
+// GetConfigMapData returns the data of a ConfigMap
+func (c *Client) GetConfigMapData(namespace string, name string) (map[string]string, error) {
+	configMapRes := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	result, err := c.clientSet.Resource(configMapRes).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ConfigMap %s: %w", name, err)
+	}
+
+	data, _, err := unstructured.NestedStringMap(result.Object, "data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data from ConfigMap %s: %w", name, err)
+	}
+	return data, nil
+}
+
 // get secret value from key. e.g. key: "password"
 func (c *Client) GetSecretValue(namespace string, name string, key string) (string, error) {
 	secretRes := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var namespaceResource = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+
+func newFakeClient(objects ...runtime.Object) (*Client, *dynamicfake.FakeDynamicClient) {
+	clientSet := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{namespaceResource: "NamespaceList"},
+		objects...,
+	)
+	return &Client{clientSet: clientSet}, clientSet
+}
+
+func makeNamespace(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]any{"name": name},
+	}}
+}
+
+func TestDeleteNamespace(t *testing.T) {
+	client, clientSet := newFakeClient(makeNamespace("argocd"))
+
+	if err := client.DeleteNamespace("argocd", 5); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := clientSet.Resource(namespaceResource).Get(context.Background(), "argocd", metav1.GetOptions{}); err == nil {
+		t.Errorf("Expected the namespace to be deleted")
+	}
+}
+
+func TestDeleteNamespace_NotFound(t *testing.T) {
+	client, _ := newFakeClient()
+
+	if err := client.DeleteNamespace("argocd", 5); err != nil {
+		t.Errorf("Expected a missing namespace not to be an error, got %v", err)
+	}
+}
+
+func TestDeleteNamespace_Timeout(t *testing.T) {
+	client, clientSet := newFakeClient(makeNamespace("argocd"))
+
+	// Keep the namespace around, like a namespace stuck in Terminating
+	clientSet.PrependReactor("delete", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+
+	err := client.DeleteNamespace("argocd", 1)
+	if err == nil || !strings.Contains(err.Error(), "timeout waiting for namespace 'argocd' to be deleted") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+}
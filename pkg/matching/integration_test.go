@@ -1,11 +1,13 @@
 package matching
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/git"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/immutable_fields"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/yaml"
 )
@@ -327,7 +329,7 @@ spec:
 		makeAppFromYAML(t, "new-app-id", "new-app-name", deploymentYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 3, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
 	if err != nil {
 		t.Fatalf("failed to generate app diffs: %v", err)
 	}
@@ -367,7 +369,7 @@ spec:
 		makeAppFromYAML(t, "eks-hotel-a-nonprod-eso-1", "eks-hotel-a-nonprod-eso", deploymentYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 3, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
 	if err != nil {
 		t.Fatalf("failed to generate app diffs: %v", err)
 	}
@@ -783,7 +785,7 @@ data:
 		makeAppFromYAML(t, "app-1", "my-app", targetConfigYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -896,7 +898,7 @@ spec:
 		makeAppFromYAML(t, "app-1", "my-app", targetStatefulSetYAML, serviceYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -972,7 +974,7 @@ spec:
 		makeAppFromYAML(t, "app-1", "my-app", targetYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1042,7 +1044,7 @@ spec:
 		makeAppFromYAML(t, "app-1", "my-app", targetYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1132,7 +1134,7 @@ data:
 		makeAppFromYAML(t, "app-1", "my-app", targetStatefulSetYAML, targetSecretYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1211,7 +1213,7 @@ spec:
 		makeAppFromYAML(t, "app-1", "my-app", targetStatefulSetYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1315,7 +1317,7 @@ spec:
 		makeAppFromYAML(t, "app-1", "my-app", targetYAML),
 	}
 
-	diffs, err := GenerateAppDiffs(baseApps, targetApps, 10, nil, nil)
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1335,3 +1337,710 @@ spec:
 		t.Fatalf("expected 1 resource in diff, got %d", len(d.Resources))
 	}
 }
+
+func TestGenerateAppDiffs_DiffSettingsAnnotations(t *testing.T) {
+	baseYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+  namespace: default
+data:
+  version: "1.0.0"
+  replicas: "1"`
+	targetYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-config
+  namespace: default
+data:
+  version: "2.0.0"
+  replicas: "1"`
+	secretYAML := `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-secret
+  namespace: default
+data:
+  key: dmFsdWU=`
+
+	withAnnotations := func(app extract.ExtractedApp, annotations map[string]string) extract.ExtractedApp {
+		app.Annotations = annotations
+		return app
+	}
+
+	t.Run("diff-ignore hides matching lines", func(t *testing.T) {
+		baseApps := []extract.ExtractedApp{makeAppFromYAML(t, "app", "app", baseYAML)}
+		targetApps := []extract.ExtractedApp{withAnnotations(makeAppFromYAML(t, "app", "app", targetYAML), map[string]string{
+			"argocd-diff-preview/diff-ignore": "version",
+		})}
+
+		diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 0 {
+			t.Fatalf("expected the change to be ignored, got %d diffs", len(diffs))
+		}
+	})
+
+	t.Run("ignore-resources skips matching resources", func(t *testing.T) {
+		baseApps := []extract.ExtractedApp{makeAppFromYAML(t, "app", "app", baseYAML)}
+		targetApps := []extract.ExtractedApp{withAnnotations(makeAppFromYAML(t, "app", "app", targetYAML, secretYAML), map[string]string{
+			"argocd-diff-preview/ignore-resources": ":Secret:*",
+		})}
+
+		diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || len(diffs[0].Resources) != 2 {
+			t.Fatalf("expected 1 diff with 2 resources, got %+v", diffs)
+		}
+		for _, r := range diffs[0].Resources {
+			if r.Kind == "Secret" && !r.IsSkipped {
+				t.Errorf("expected Secret to be skipped")
+			}
+			if r.Kind == "ConfigMap" && r.IsSkipped {
+				t.Errorf("expected ConfigMap not to be skipped")
+			}
+		}
+	})
+
+	t.Run("line-count overrides context lines", func(t *testing.T) {
+		baseApps := []extract.ExtractedApp{makeAppFromYAML(t, "app", "app", baseYAML)}
+		targetApps := []extract.ExtractedApp{withAnnotations(makeAppFromYAML(t, "app", "app", targetYAML), map[string]string{
+			"argocd-diff-preview/line-count": "0",
+		})}
+
+		diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 10})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || len(diffs[0].Resources) != 1 {
+			t.Fatalf("expected 1 diff with 1 resource, got %+v", diffs)
+		}
+		content := diffs[0].Resources[0].Content
+		if strings.Contains(content, "replicas") {
+			t.Errorf("expected no context lines, got:\n%s", content)
+		}
+	})
+
+	t.Run("hide-diff keeps the app but hides resources", func(t *testing.T) {
+		baseApps := []extract.ExtractedApp{makeAppFromYAML(t, "app", "app", baseYAML)}
+		targetApps := []extract.ExtractedApp{withAnnotations(makeAppFromYAML(t, "app", "app", targetYAML), map[string]string{
+			"argocd-diff-preview/hide-diff": "true",
+		})}
+
+		diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 {
+			t.Fatalf("expected 1 diff, got %d", len(diffs))
+		}
+		if len(diffs[0].Resources) != 0 {
+			t.Errorf("expected resources to be hidden, got %d", len(diffs[0].Resources))
+		}
+		if diffs[0].EmptyReason != EmptyReasonHiddenByAnnotation {
+			t.Errorf("expected EmptyReasonHiddenByAnnotation, got %d", diffs[0].EmptyReason)
+		}
+		if diffs[0].AddedLines != 1 || diffs[0].DeletedLines != 1 {
+			t.Errorf("expected line stats to be kept, got +%d -%d", diffs[0].AddedLines, diffs[0].DeletedLines)
+		}
+		if len(diffs[0].Changes) != 1 || diffs[0].Changes[0].Action != ResourceChanged {
+			t.Errorf("expected the resource changes to be kept, got %+v", diffs[0].Changes)
+		}
+	})
+
+	t.Run("deleted app uses base annotations", func(t *testing.T) {
+		baseApps := []extract.ExtractedApp{withAnnotations(makeAppFromYAML(t, "app", "app", baseYAML), map[string]string{
+			"argocd-diff-preview/hide-diff": "true",
+		})}
+
+		diffs, err := GenerateAppDiffs(baseApps, nil, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || diffs[0].EmptyReason != EmptyReasonHiddenByAnnotation {
+			t.Fatalf("expected deleted app diff to be hidden, got %+v", diffs)
+		}
+	})
+}
+
+// TestGenerateAppDiffs_ResourceMovedBetweenApps tests that a resource deleted from one app
+// and added to another is shown as a move instead of a deletion and an addition
+func TestGenerateAppDiffs_ResourceMovedBetweenApps(t *testing.T) {
+	webYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1`
+	movedWebYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 2`
+	serviceYAML := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default`
+	apiYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: default
+spec:
+  replicas: 1`
+
+	baseApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "frontend", "frontend", webYAML, serviceYAML),
+		makeAppFromYAML(t, "backend", "backend", apiYAML),
+	}
+	targetApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "frontend", "frontend", serviceYAML),
+		makeAppFromYAML(t, "backend", "backend", apiYAML, movedWebYAML),
+	}
+
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("failed to generate app diffs: %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("expected 2 diffs, got %d", len(diffs))
+	}
+
+	byName := map[string]AppDiff{}
+	for _, d := range diffs {
+		byName[d.PrettyName()] = d
+	}
+
+	frontend := byName["frontend"]
+	if len(frontend.Resources) != 1 || frontend.Resources[0].MovedTo != "backend" {
+		t.Fatalf("expected the Deployment to be moved to backend, got %+v", frontend.Resources)
+	}
+	if frontend.Resources[0].Content != "" || frontend.DeletedLines != 0 {
+		t.Errorf("expected no diff in the app the resource was moved from, got:\n%s", frontend.Resources[0].Content)
+	}
+	if got := frontend.Resources[0].Header(); got != "Deployment: default/web (moved to backend)" {
+		t.Errorf("unexpected header: %q", got)
+	}
+
+	backend := byName["backend"]
+	if len(backend.Resources) != 1 || backend.Resources[0].MovedFrom != "frontend" {
+		t.Fatalf("expected the Deployment to be moved from frontend, got %+v", backend.Resources)
+	}
+	if backend.AddedLines != 1 || backend.DeletedLines != 1 {
+		t.Errorf("expected only the residual diff, got +%d -%d", backend.AddedLines, backend.DeletedLines)
+	}
+	if !strings.Contains(backend.Resources[0].Content, "+  replicas: 2") {
+		t.Errorf("expected the residual diff to show the replica change, got:\n%s", backend.Resources[0].Content)
+	}
+}
+
+// TestGenerateAppDiffs_UnchangedMoveToNewApp tests that an unchanged resource moved to a new
+// app is still shown in the new app
+func TestGenerateAppDiffs_UnchangedMoveToNewApp(t *testing.T) {
+	configYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared-config
+  namespace: default
+data:
+  key: value`
+	otherYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+  namespace: default
+data:
+  key: other`
+
+	baseApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "old", "old", configYAML, otherYAML),
+	}
+	targetApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "old", "old", otherYAML),
+		makeAppFromYAML(t, "config", "config", configYAML),
+	}
+
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("failed to generate app diffs: %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("expected 2 diffs, got %d", len(diffs))
+	}
+	for _, d := range diffs {
+		if len(d.Resources) != 1 {
+			t.Fatalf("expected 1 resource in %s, got %d", d.PrettyName(), len(d.Resources))
+		}
+		r := d.Resources[0]
+		switch d.PrettyName() {
+		case "config":
+			if d.Action != ActionAdded || r.MovedFrom != "old" || r.Content != "" {
+				t.Errorf("expected an unchanged move from old, got %+v", r)
+			}
+		case "old":
+			if r.MovedTo != "config" {
+				t.Errorf("expected a move to config, got %+v", r)
+			}
+		}
+	}
+}
+
+// TestGenerateAppDiffs_MoveToAnotherCluster tests that a resource removed from an app on one
+// cluster and added to an app on another cluster is not shown as a move
+func TestGenerateAppDiffs_MoveToAnotherCluster(t *testing.T) {
+	configYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared-config
+  namespace: default
+data:
+  key: value`
+	otherYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: other
+  namespace: default
+data:
+  key: other`
+
+	baseOld := makeAppFromYAML(t, "old", "old", configYAML, otherYAML)
+	baseOld.Cluster = "https://kubernetes.default.svc"
+	targetOld := makeAppFromYAML(t, "old", "old", otherYAML)
+	targetOld.Cluster = "https://kubernetes.default.svc"
+	remote := makeAppFromYAML(t, "remote", "remote", configYAML)
+	remote.Cluster = "https://remote-cluster"
+
+	diffs, err := GenerateAppDiffs([]extract.ExtractedApp{baseOld}, []extract.ExtractedApp{targetOld, remote}, DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("failed to generate app diffs: %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("expected 2 diffs, got %d", len(diffs))
+	}
+	for _, d := range diffs {
+		for _, r := range d.Resources {
+			if r.MovedFrom != "" || r.MovedTo != "" {
+				t.Errorf("expected no move between clusters in %s, got %+v", d.PrettyName(), r)
+			}
+		}
+	}
+}
+
+// TestGenerateAppDiffs_SimilarResourceMovedBetweenApps tests that a resource renamed along
+// with a move to another app is matched by content similarity and shown as a replacing move
+func TestGenerateAppDiffs_SimilarResourceMovedBetweenApps(t *testing.T) {
+	webYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels:
+    app: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.27`
+	renamedWebYAML := strings.Replace(webYAML, "name: web\n  namespace", "name: frontend-web\n  namespace", 1)
+	serviceYAML := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default`
+	apiYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: default
+spec:
+  replicas: 1`
+
+	baseApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "frontend", "frontend", webYAML, serviceYAML),
+		makeAppFromYAML(t, "backend", "backend", apiYAML),
+	}
+	targetApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "frontend", "frontend", serviceYAML),
+		makeAppFromYAML(t, "backend", "backend", apiYAML, renamedWebYAML),
+	}
+
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("failed to generate app diffs: %v", err)
+	}
+
+	byName := map[string]AppDiff{}
+	for _, d := range diffs {
+		byName[d.PrettyName()] = d
+	}
+
+	frontend := byName["frontend"]
+	if len(frontend.Resources) != 1 || frontend.Resources[0].MovedTo != "backend" {
+		t.Fatalf("expected the Deployment to be moved to backend, got %+v", frontend.Resources)
+	}
+
+	backend := byName["backend"]
+	if len(backend.Resources) != 1 || backend.Resources[0].MovedFrom != "frontend" {
+		t.Fatalf("expected the Deployment to be moved from frontend, got %+v", backend.Resources)
+	}
+	r := backend.Resources[0]
+	if got := r.Header(); got != "Deployment: default/web → default/frontend-web (moved from frontend)" {
+		t.Errorf("unexpected header: %q", got)
+	}
+	if r.Action != ResourceReplaced || r.ReplaceReason != "renamed from default/web" {
+		t.Errorf("expected the renamed move to be a replacement, got %v (%s)", r.Action, r.ReplaceReason)
+	}
+	if backend.AddedLines != 1 || backend.DeletedLines != 1 {
+		t.Errorf("expected only the rename in the diff, got +%d -%d", backend.AddedLines, backend.DeletedLines)
+	}
+}
+
+func TestGenerateAppDiffs_ResourceChanges(t *testing.T) {
+	deploymentYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25`
+	scaledDeploymentYAML := strings.Replace(deploymentYAML, "replicas: 1", "replicas: 3", 1)
+	serviceYAML := `apiVersion: v1
+kind: Service
+metadata:
+  name: frontend
+  namespace: default
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+    targetPort: 8080
+    protocol: TCP`
+	renamedServiceYAML := strings.Replace(serviceYAML, "name: frontend", "name: web", 1)
+	secretYAML := `apiVersion: v1
+kind: Secret
+metadata:
+  name: credentials
+  namespace: default`
+	configMapYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  mode: production`
+
+	baseApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "web", "web", deploymentYAML, serviceYAML, secretYAML),
+	}
+	targetApps := []extract.ExtractedApp{
+		makeAppFromYAML(t, "web", "web", scaledDeploymentYAML, renamedServiceYAML, configMapYAML),
+	}
+
+	diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+	if err != nil {
+		t.Fatalf("failed to generate app diffs: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+
+	actions := map[string]ResourceChange{}
+	for _, change := range diffs[0].Changes {
+		actions[change.Kind+"/"+change.Name] = change
+	}
+	expected := map[string]ResourceAction{
+		"Deployment/web":     ResourceChanged,
+		"Service/web":        ResourceReplaced,
+		"Secret/credentials": ResourceDeleted,
+		"ConfigMap/settings": ResourceAdded,
+	}
+	if len(actions) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(actions), diffs[0].Changes)
+	}
+	for key, action := range expected {
+		if actions[key].Action != action {
+			t.Errorf("expected %s to %s, got %s", key, action, actions[key].Action)
+		}
+	}
+	if reason := actions["Service/web"].ReplaceReason; reason != "renamed from default/frontend" {
+		t.Errorf("unexpected replace reason: %q", reason)
+	}
+}
+
+func TestGenerateAppDiffs_ImmutableFieldChanged(t *testing.T) {
+	baseYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web`
+	targetYAML := strings.Replace(baseYAML, "app: web", "app: frontend", 1)
+
+	baseApps := []extract.ExtractedApp{makeAppFromYAML(t, "web", "web", baseYAML)}
+	targetApps := []extract.ExtractedApp{makeAppFromYAML(t, "web", "web", targetYAML)}
+
+	t.Run("flagged with the catalogue", func(t *testing.T) {
+		diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3, ImmutableFields: immutable_fields.BuiltIn})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || len(diffs[0].Resources) != 1 {
+			t.Fatalf("expected 1 diff with 1 resource, got %+v", diffs)
+		}
+		resource := diffs[0].Resources[0]
+		if resource.Action != ResourceReplaced || resource.ReplaceReason != "immutable field changed: spec.selector" {
+			t.Errorf("expected the resource to be replaced, got %s (%q)", resource.Action, resource.ReplaceReason)
+		}
+		if header := resource.Header(); header != "Deployment: default/web ⚠️ immutable field changed (spec.selector)" {
+			t.Errorf("unexpected header: %s", header)
+		}
+		if !diffs[0].ImmutableFieldChanged() {
+			t.Errorf("expected the app to have a changed immutable field")
+		}
+	})
+
+	t.Run("not flagged without the catalogue", func(t *testing.T) {
+		diffs, err := GenerateAppDiffs(baseApps, targetApps, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || len(diffs[0].Resources) != 1 {
+			t.Fatalf("expected 1 diff with 1 resource, got %+v", diffs)
+		}
+		if diffs[0].Resources[0].Action != ResourceChanged || diffs[0].ImmutableFieldChanged() {
+			t.Errorf("expected a plain change, got %s", diffs[0].Resources[0].Action)
+		}
+	})
+}
+
+func TestGenerateAppDiffs_SyncBehaviour(t *testing.T) {
+	configYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+  annotations:
+    argocd.argoproj.io/sync-options: Prune=false
+data:
+  mode: production`
+	extraneousYAML := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: generated
+  namespace: default
+  annotations:
+    argocd.argoproj.io/compare-options: IgnoreExtraneous
+data:
+  mode: production`
+
+	base := makeAppFromYAML(t, "web", "web", configYAML, extraneousYAML)
+	target := makeAppFromYAML(t, "web", "web",
+		strings.Replace(configYAML, "production", "staging", 1),
+		strings.Replace(extraneousYAML, "production", "staging", 1))
+	target.SyncOptions = []string{"ServerSideApply=true"}
+
+	t.Run("badges from annotations and app sync options", func(t *testing.T) {
+		diffs, err := GenerateAppDiffs([]extract.ExtractedApp{base}, []extract.ExtractedApp{target}, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || len(diffs[0].Resources) != 2 {
+			t.Fatalf("expected 1 diff with 2 resources, got %+v", diffs)
+		}
+		byName := map[string]ResourceDiff{}
+		for _, r := range diffs[0].Resources {
+			byName[r.Name] = r
+		}
+		settings := byName["settings"].SyncBehaviour
+		if !settings.NoPrune || !settings.ServerSideApply {
+			t.Errorf("expected Prune=false and server-side apply, got %+v", settings)
+		}
+		generated := byName["generated"]
+		if generated.IsSkipped || !generated.SyncBehaviour.IgnoreExtraneous {
+			t.Errorf("expected an IgnoreExtraneous resource that is not skipped, got %+v", generated)
+		}
+	})
+
+	t.Run("IgnoreExtraneous resources excluded", func(t *testing.T) {
+		diffs, err := GenerateAppDiffs([]extract.ExtractedApp{base}, []extract.ExtractedApp{target}, DiffOptions{ContextLines: 3, ExcludeIgnoreExtraneous: true})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || len(diffs[0].Resources) != 2 {
+			t.Fatalf("expected 1 diff with 2 resources, got %+v", diffs)
+		}
+		for _, r := range diffs[0].Resources {
+			if r.IsSkipped != (r.Name == "generated") {
+				t.Errorf("expected only the IgnoreExtraneous resource to be skipped, got %s skipped=%t", r.Name, r.IsSkipped)
+			}
+		}
+	})
+}
+
+func TestGenerateAppDiffs_SyncSteps(t *testing.T) {
+	migrateYAML := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: default
+  annotations:
+    argocd.argoproj.io/hook: PreSync
+spec:
+  image: migrate:1`
+	crdYAML := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+  annotations:
+    argocd.argoproj.io/sync-wave: "-1"
+spec:
+  group: example.com`
+	deploymentYAML := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1`
+	serviceYAML := `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  type: ClusterIP`
+	smokeTestYAML := `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: smoke-test
+  namespace: default
+  annotations:
+    helm.sh/hook: post-upgrade
+    helm.sh/hook-weight: "2"`
+
+	base := makeAppFromYAML(t, "web", "web", migrateYAML, crdYAML, deploymentYAML, serviceYAML)
+	target := makeAppFromYAML(t, "web", "web",
+		strings.Replace(migrateYAML, "migrate:1", "migrate:2", 1),
+		strings.Replace(crdYAML, `"-1"`, `"1"`, 1),
+		strings.Replace(deploymentYAML, "replicas: 1", "replicas: 2", 1),
+		serviceYAML)
+	target.HelmHooks = []unstructured.Unstructured{parseYAML(t, smokeTestYAML)}
+
+	diffs, err := GenerateAppDiffs([]extract.ExtractedApp{base}, []extract.ExtractedApp{target}, DiffOptions{ContextLines: 3, SyncSteps: true})
+	if err != nil {
+		t.Fatalf("failed to generate app diffs: %v", err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+
+	expected := []SyncStep{
+		{Phase: "PreSync", Wave: 0, Resources: []SyncStepResource{
+			{Kind: "Job", Namespace: "default", Name: "migrate", Hook: true, Change: SyncChanged},
+		}},
+		{Phase: "Sync", Wave: 0, Resources: []SyncStepResource{
+			{Kind: "Deployment", Namespace: "default", Name: "web", Change: SyncChanged},
+			{Kind: "Service", Namespace: "default", Name: "web", Change: SyncUnchanged},
+		}},
+		{Phase: "Sync", Wave: 1, Resources: []SyncStepResource{
+			{Kind: "CustomResourceDefinition", Name: "widgets.example.com", Change: SyncMoved, MovedFrom: "Sync wave -1"},
+		}},
+		{Phase: "PostSync", Wave: 2, Resources: []SyncStepResource{
+			{Kind: "Job", Namespace: "default", Name: "smoke-test", Hook: true, Change: SyncAdded},
+		}},
+	}
+	if !reflect.DeepEqual(diffs[0].SyncSteps, expected) {
+		t.Errorf("unexpected sync steps:\n got: %+v\nwant: %+v", diffs[0].SyncSteps, expected)
+	}
+
+	t.Run("single wave", func(t *testing.T) {
+		base := makeAppFromYAML(t, "web", "web", deploymentYAML, serviceYAML)
+		target := makeAppFromYAML(t, "web", "web", strings.Replace(deploymentYAML, "replicas: 1", "replicas: 2", 1), serviceYAML)
+
+		diffs, err := GenerateAppDiffs([]extract.ExtractedApp{base}, []extract.ExtractedApp{target}, DiffOptions{ContextLines: 3, SyncSteps: true})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || diffs[0].SyncSteps != nil {
+			t.Errorf("expected no sync steps for an app without hooks and sync waves, got %+v", diffs)
+		}
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		diffs, err := GenerateAppDiffs([]extract.ExtractedApp{base}, []extract.ExtractedApp{target}, DiffOptions{ContextLines: 3})
+		if err != nil {
+			t.Fatalf("failed to generate app diffs: %v", err)
+		}
+		if len(diffs) != 1 || diffs[0].SyncSteps != nil {
+			t.Errorf("expected no sync steps when they are not requested, got %+v", diffs)
+		}
+	})
+
+	t.Run("hook in several phases", func(t *testing.T) {
+		hook := func(phases string) string {
+			return strings.Replace(migrateYAML, "hook: PreSync", "hook: "+phases, 1)
+		}
+		tests := []struct {
+			name     string
+			base     string
+			target   string
+			expected []SyncStep
+		}{
+			{
+				name:   "moved from the phase it left",
+				base:   "PreSync,PostSync",
+				target: "PreSync,SyncFail",
+				expected: []SyncStep{
+					{Phase: "PreSync", Wave: 0, Resources: []SyncStepResource{{Kind: "Job", Namespace: "default", Name: "migrate", Hook: true, Change: SyncChanged}}},
+					{Phase: "SyncFail", Wave: 0, Resources: []SyncStepResource{{Kind: "Job", Namespace: "default", Name: "migrate", Hook: true, Change: SyncMoved, MovedFrom: "PostSync wave 0"}}},
+				},
+			},
+			{
+				name:   "removed from a phase",
+				base:   "PreSync,PostSync",
+				target: "PostSync",
+				expected: []SyncStep{
+					{Phase: "PreSync", Wave: 0, Resources: []SyncStepResource{{Kind: "Job", Namespace: "default", Name: "migrate", Hook: true, Change: SyncRemoved}}},
+					{Phase: "PostSync", Wave: 0, Resources: []SyncStepResource{{Kind: "Job", Namespace: "default", Name: "migrate", Hook: true, Change: SyncChanged}}},
+				},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				base := makeAppFromYAML(t, "web", "web", hook(tt.base))
+				target := makeAppFromYAML(t, "web", "web", hook(tt.target))
+
+				diffs, err := GenerateAppDiffs([]extract.ExtractedApp{base}, []extract.ExtractedApp{target}, DiffOptions{ContextLines: 3, SyncSteps: true})
+				if err != nil {
+					t.Fatalf("failed to generate app diffs: %v", err)
+				}
+				if len(diffs) != 1 || !reflect.DeepEqual(diffs[0].SyncSteps, tt.expected) {
+					t.Errorf("unexpected sync steps:\n got: %+v\nwant: %+v", diffs, tt.expected)
+				}
+			})
+		}
+	})
+}
@@ -1454,6 +1454,32 @@ func TestResourceMatchesIgnoreRules_BothNil(t *testing.T) {
 	}
 }
 
+func TestResourceMatchesIgnoreRules_NamespaceAndOnlyResources(t *testing.T) {
+	dashboard := makeResource("v1", "ConfigMap", "monitoring", "nodes-dashboard", nil)
+	deploy := makeResource("apps/v1", "Deployment", "default", "my-deploy", nil)
+	secret := makeResource("v1", "Secret", "default", "my-secret", nil)
+
+	rules, err := resource_filter.FromString(":ConfigMap:monitoring/*-dashboard")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	onlyRules, err := resource_filter.IncludeFromString("apps:*:*,:ConfigMap:*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules = append(rules, onlyRules...)
+
+	if !resourceMatchesIgnoreRules(&ResourcePair{Base: &dashboard, Target: &dashboard}, rules) {
+		t.Error("expected the dashboard ConfigMap in the monitoring namespace to be ignored")
+	}
+	if resourceMatchesIgnoreRules(&ResourcePair{Target: &deploy}, rules) {
+		t.Error("expected the included Deployment not to be ignored")
+	}
+	if !resourceMatchesIgnoreRules(&ResourcePair{Base: &secret}, rules) {
+		t.Error("expected the Secret to be ignored, since it matches no only rule")
+	}
+}
+
 // Tests for buildResourceDiffs with ignore rules
 
 func TestBuildResourceDiffs_SkippedResource(t *testing.T) {
@@ -1470,7 +1496,7 @@ func TestBuildResourceDiffs_SkippedResource(t *testing.T) {
 		{Group: "apps", Kind: "Deployment", Name: "my-deploy"},
 	}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1517,7 +1543,7 @@ func TestBuildResourceDiffs_MixSkippedAndNormal(t *testing.T) {
 		{Group: "apps", Kind: "Deployment", Name: "*"},
 	}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1570,7 +1596,7 @@ func TestBuildResourceDiffs_NoIgnoreRules(t *testing.T) {
 
 	resources := []ResourcePair{{Base: &base, Target: &target}}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, nil)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1604,7 +1630,7 @@ func TestBuildResourceDiffs_AllSkipped(t *testing.T) {
 		{Group: "*", Kind: "*", Name: "*"},
 	}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1636,7 +1662,7 @@ func TestBuildResourceDiffs_SkippedAddedResource(t *testing.T) {
 		{Group: "*", Kind: "Secret", Name: "*"},
 	}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1666,7 +1692,7 @@ func TestBuildResourceDiffs_SkippedDeletedResource(t *testing.T) {
 		{Group: "*", Kind: "Secret", Name: "*"},
 	}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1694,7 +1720,7 @@ func TestBuildResourceDiffs_SkippedResourceHeader(t *testing.T) {
 		{Group: "*", Kind: "CustomResourceDefinition", Name: "*"},
 	}
 
-	result, _, _, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, _, _, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -1733,7 +1759,7 @@ func TestBuildResourceDiffs_CrossKindPairSkippedByBaseIgnoreRule(t *testing.T) {
 		{Group: "", Kind: "Secret", Name: "*"},
 	}
 
-	result, added, deleted, err := buildResourceDiffs(resources, 3, nil, rules)
+	result, added, deleted, err := buildResourceDiffs(resources, resourceDiffOptions{contextLines: 3, ignoreResourceRules: rules})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
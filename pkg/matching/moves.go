@@ -0,0 +1,147 @@
+package matching
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+)
+
+// resourceLocation points at a resource pair in the changed resources of an app pair
+type resourceLocation struct {
+	pair  int
+	index int
+}
+
+// scoredMove is a candidate move of a deleted resource to an added resource, scored by content similarity
+type scoredMove struct {
+	from  resourceLocation
+	to    resourceLocation
+	score float64
+}
+
+// detectMoves finds resources deleted from one application and added to another, and
+// turns them into moves. Only resources on the same destination cluster can move. A
+// resource is moved when the same kind, namespace and name is deleted from one app pair
+// and added to another. The remaining deleted and added resources of the same kind are
+// then matched by content similarity, which catches resources renamed along with the move.
+// The deleted side gets MovedTo and the added side gets the base resource and MovedFrom,
+// so its diff only shows the changes made along with the move.
+//
+// changed holds the changed resources of each pair in pairs and is updated in place.
+func detectMoves(pairs []Pair, changed [][]ResourcePair) {
+	deleted := make(map[string][]resourceLocation)
+	for i := range pairs {
+		for j, rp := range changed[i] {
+			if rp.Base == nil || rp.Target != nil {
+				continue
+			}
+			key := moveKey(pairs[i].Base, rp.Base)
+			deleted[key] = append(deleted[key], resourceLocation{pair: i, index: j})
+		}
+	}
+	if len(deleted) == 0 {
+		return
+	}
+
+	var added []resourceLocation
+	for i := range pairs {
+		for j, rp := range changed[i] {
+			if rp.Base != nil || rp.Target == nil {
+				continue
+			}
+			key := moveKey(pairs[i].Target, rp.Target)
+			candidates := deleted[key]
+			moved := false
+			for c, from := range candidates {
+				// Resources moving within the same app pair are already matched
+				if from.pair == i {
+					continue
+				}
+				move(pairs, changed, from, resourceLocation{pair: i, index: j})
+				deleted[key] = append(candidates[:c:c], candidates[c+1:]...)
+				moved = true
+				break
+			}
+			if !moved {
+				added = append(added, resourceLocation{pair: i, index: j})
+			}
+		}
+	}
+
+	for _, m := range similarMoves(pairs, changed, deleted, added) {
+		move(pairs, changed, m.from, m.to)
+	}
+}
+
+// similarMoves matches the remaining deleted and added resources of the same kind on the same
+// cluster by content similarity. Each resource is used at most once, best scores first.
+func similarMoves(pairs []Pair, changed [][]ResourcePair, deleted map[string][]resourceLocation, added []resourceLocation) []scoredMove {
+	var candidates []scoredMove
+	for _, from := range sortedLocations(deleted) {
+		base := changed[from.pair][from.index].Base
+		for _, to := range added {
+			target := changed[to.pair][to.index].Target
+			if from.pair == to.pair ||
+				pairs[from.pair].Base.Cluster != pairs[to.pair].Target.Cluster ||
+				base.GroupVersionKind().GroupKind() != target.GroupVersionKind().GroupKind() {
+				continue
+			}
+			score := contentSimilarity(base, target)
+			if score > similarityThresholdSameKind {
+				candidates = append(candidates, scoredMove{from: from, to: to, score: score})
+			}
+		}
+	}
+
+	// Candidates are collected in a deterministic order, so a stable sort keeps ties deterministic
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	usedFrom := make(map[resourceLocation]bool)
+	usedTo := make(map[resourceLocation]bool)
+	var result []scoredMove
+	for _, c := range candidates {
+		if usedFrom[c.from] || usedTo[c.to] {
+			continue
+		}
+		usedFrom[c.from] = true
+		usedTo[c.to] = true
+		result = append(result, c)
+	}
+	return result
+}
+
+// move marks the deleted resource at from as moved to the added resource at to
+func move(pairs []Pair, changed [][]ResourcePair, from, to resourceLocation) {
+	source := &changed[from.pair][from.index]
+	source.MovedTo = pairs[to.pair].Target.Name
+	changed[to.pair][to.index] = ResourcePair{
+		Base:      source.Base,
+		Target:    changed[to.pair][to.index].Target,
+		MovedFrom: pairs[from.pair].Base.Name,
+	}
+}
+
+// moveKey returns the key of a resource for move detection: the destination cluster of its
+// app and its kind, namespace and name
+func moveKey(app *extract.ExtractedApp, r *unstructured.Unstructured) string {
+	return app.Cluster + "\x00" + fullResourceKey(r)
+}
+
+// sortedLocations returns the locations in the map ordered by pair and index
+func sortedLocations(m map[string][]resourceLocation) []resourceLocation {
+	var result []resourceLocation
+	for _, locations := range m {
+		result = append(result, locations...)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].pair != result[j].pair {
+			return result[i].pair < result[j].pair
+		}
+		return result[i].index < result[j].index
+	})
+	return result
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/immutable_fields"
 	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
 	"github.com/go-git/go-git/v5/utils/diff"
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -24,7 +27,56 @@ type ResourceDiff struct {
 	Content      string // diff text (with +/-/space prefixes)
 	AddedLines   int
 	DeletedLines int
-	IsSkipped    bool // true if resource matched an ignore rule
+	IsSkipped    bool   // true if resource matched an ignore rule
+	MovedFrom    string // name of the app the resource was moved from, if any
+	MovedTo      string // name of the app the resource was moved to, if any
+	Action       ResourceAction
+	// ReplaceReason explains why the resource is deleted and created instead of updated
+	// (only set if Action is ResourceReplaced)
+	ReplaceReason string
+	// ImmutableFields are the changed fields that can not be updated in place
+	ImmutableFields []string
+	// SyncBehaviour is how Argo CD syncs the resource, read from the target (or deleted base) resource
+	SyncBehaviour argoapplication.SyncBehaviour
+}
+
+// ResourceAction is what syncing the target branch does to a resource
+type ResourceAction int
+
+const (
+	ResourceChanged  ResourceAction = iota
+	ResourceAdded                   // the resource is created
+	ResourceDeleted                 // the resource is deleted
+	ResourceReplaced                // the resource is deleted and created, e.g. because its kind changed
+	ResourceMoved                   // the resource moved to another app
+)
+
+func (a ResourceAction) String() string {
+	switch a {
+	case ResourceChanged:
+		return "change"
+	case ResourceAdded:
+		return "add"
+	case ResourceDeleted:
+		return "destroy"
+	case ResourceReplaced:
+		return "replace"
+	case ResourceMoved:
+		return "move"
+	default:
+		return "unknown"
+	}
+}
+
+// ResourceChange is a changed resource of an app. Unlike the resource diffs, the changes
+// are kept when the diff of the app is hidden.
+type ResourceChange struct {
+	Kind            string
+	Namespace       string
+	Name            string
+	Action          ResourceAction
+	ReplaceReason   string
+	ImmutableFields []string
 }
 
 // Header returns a display header for the resource.
@@ -33,6 +85,7 @@ type ResourceDiff struct {
 //   - Name change:      "Kind: namespace/OldName → namespace/NewName"
 //   - Namespace change: "Kind: oldNs/Name → newNs/Name"
 //   - Cluster-scoped:   "Kind: Name" (no namespace prefix)
+//   - Moved:            "Kind: namespace/Name (moved from app)" or "(moved to app)"
 func (r *ResourceDiff) Header() string {
 	// Kind part
 	kindStr := r.Kind
@@ -60,12 +113,28 @@ func (r *ResourceDiff) Header() string {
 		oldName = r.Name
 	}
 
+	var header string
 	if nsChanged || nameChanged {
-		return fmt.Sprintf("%s: %s → %s", kindStr, qualifiedName(oldNs, oldName), qualifiedName(r.Namespace, r.Name))
+		header = fmt.Sprintf("%s: %s → %s", kindStr, qualifiedName(oldNs, oldName), qualifiedName(r.Namespace, r.Name))
+	} else {
+		header = fmt.Sprintf("%s: %s", kindStr, qualifiedName(r.Namespace, r.Name))
+	}
+
+	switch {
+	case r.MovedFrom != "":
+		header += fmt.Sprintf(" (moved from %s)", r.MovedFrom)
+	case r.MovedTo != "":
+		header += fmt.Sprintf(" (moved to %s)", r.MovedTo)
 	}
-	return fmt.Sprintf("%s: %s", kindStr, qualifiedName(r.Namespace, r.Name))
+	if len(r.ImmutableFields) > 0 {
+		header += fmt.Sprintf(" %s (%s)", ImmutableFieldMarker, strings.Join(r.ImmutableFields, ", "))
+	}
+	return header
 }
 
+// ImmutableFieldMarker marks resources and apps where a field that can not be updated in place changed
+const ImmutableFieldMarker = "⚠️ immutable field changed"
+
 // AppDiff represents the diff output for a single application pair
 type AppDiff struct {
 	OldName       string // Name in base branch (empty if added)
@@ -77,16 +146,19 @@ type AppDiff struct {
 	AddedLines    int
 	DeletedLines  int
 	EmptyReason   EmptyReason // Why Resources is empty (only meaningful when len(Resources) == 0)
+	Changes       []ResourceChange
+	SyncSteps     []SyncStep // the sync phases and waves of the app, if requested and it has hooks or more than one wave
 }
 
 // EmptyReason describes why an application section has no resource diffs
 type EmptyReason int
 
 const (
-	EmptyReasonNone        EmptyReason = iota // not empty - has resources
-	EmptyReasonNoResources                    // application genuinely rendered no resources
-	EmptyReasonHiddenDiff                     // diff hidden by --hide-deleted-app-diff
-	EmptyReasonNameOnlyChange                 // application name changed, but rendered resources did not
+	EmptyReasonNone               EmptyReason = iota // not empty - has resources
+	EmptyReasonNoResources                           // application genuinely rendered no resources
+	EmptyReasonHiddenDiff                            // diff hidden by --hide-deleted-app-diff
+	EmptyReasonNameOnlyChange                        // application name changed, but rendered resources did not
+	EmptyReasonHiddenByAnnotation                    // diff hidden by the argocd-diff-preview/hide-diff annotation
 )
 
 // DiffAction represents the type of change
@@ -142,6 +214,16 @@ func (d *AppDiff) PrettyPath() string {
 	}
 }
 
+// ImmutableFieldChanged returns true if a field that can not be updated in place changed in any resource
+func (d *AppDiff) ImmutableFieldChanged() bool {
+	for _, change := range d.Changes {
+		if len(change.ImmutableFields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // ChangeStats returns a formatted string showing +/- line counts
 func (d *AppDiff) ChangeStats() string {
 	switch {
@@ -161,32 +243,66 @@ func (d *AppDiff) HasContent() bool {
 	return len(d.Resources) > 0
 }
 
+// DiffOptions configures how the diffs between the base and target apps are generated
+type DiffOptions struct {
+	ContextLines            uint
+	IgnorePattern           *string // lines matching the pattern are left out of the diff
+	IgnoreResourceRules     []resource_filter.IgnoreResourceRule
+	ImmutableFields         []immutable_fields.ImmutableField // resources with a changed immutable field are replaced
+	ExcludeIgnoreExtraneous bool                              // skip resources with the IgnoreExtraneous compare option
+	SyncSteps               bool                              // order the resources of each app in sync phases and waves
+}
+
+// resourceDiffOptions are the options used to diff the resources of a single app pair
+type resourceDiffOptions struct {
+	contextLines            uint
+	ignorePattern           *regexp.Regexp
+	ignoreResourceRules     []resource_filter.IgnoreResourceRule
+	immutableFields         []immutable_fields.ImmutableField
+	appSyncOptions          []string
+	excludeIgnoreExtraneous bool
+	syncSteps               bool
+}
+
 // GenerateAppDiffs uses similarity matching to generate diffs between base and target apps.
 // This replaces the ID-based matching with content-based matching.
-func GenerateAppDiffs(
-	baseApps, targetApps []extract.ExtractedApp,
-	contextLines uint,
-	ignorePattern *string,
-	ignoreResourceRules []resource_filter.IgnoreResourceRule,
-) ([]AppDiff, error) {
+func GenerateAppDiffs(baseApps, targetApps []extract.ExtractedApp, options DiffOptions) ([]AppDiff, error) {
 	// Compile the ignore pattern regex once up front
 	var compiledIgnorePattern *regexp.Regexp
-	if ignorePattern != nil && *ignorePattern != "" {
+	if options.IgnorePattern != nil && *options.IgnorePattern != "" {
 		var err error
-		compiledIgnorePattern, err = regexp.Compile(*ignorePattern)
+		compiledIgnorePattern, err = regexp.Compile(*options.IgnorePattern)
 		if err != nil {
 			// If regex compilation fails, fall back to a literal string match
-			compiledIgnorePattern = regexp.MustCompile(regexp.QuoteMeta(*ignorePattern))
+			compiledIgnorePattern = regexp.MustCompile(regexp.QuoteMeta(*options.IgnorePattern))
 		}
 	}
 
 	// Match apps by content similarity
 	pairs := MatchApps(baseApps, targetApps)
 
+	// Resources deleted from one app and added to another are shown as moves
+	changed := make([][]ResourcePair, len(pairs))
+	for i := range pairs {
+		changed[i] = pairs[i].ChangedResources()
+	}
+	detectMoves(pairs, changed)
+
 	var diffs []AppDiff
 
-	for _, pair := range pairs {
-		appDiff, err := generateAppDiff(pair, contextLines, compiledIgnorePattern, ignoreResourceRules)
+	for i, pair := range pairs {
+		settings := pair.diffSettings()
+		appContextLines, appIgnorePattern, appIgnoreResourceRules := applyDiffSettings(settings, options.ContextLines, compiledIgnorePattern, options.IgnoreResourceRules)
+
+		appDiff, err := generateAppDiff(pair, changed[i], resourceDiffOptions{
+			contextLines:            appContextLines,
+			ignorePattern:           appIgnorePattern,
+			ignoreResourceRules:     appIgnoreResourceRules,
+			immutableFields:         options.ImmutableFields,
+			appSyncOptions:          pair.syncOptions(),
+			excludeIgnoreExtraneous: options.ExcludeIgnoreExtraneous,
+			syncSteps:               options.SyncSteps,
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate diff for app pair: %w", err)
 		}
@@ -196,6 +312,12 @@ func GenerateAppDiffs(
 			continue
 		}
 
+		// Hide the resource diffs but keep the app (and its line stats) in the output
+		if settings.HideDiff && len(appDiff.Resources) > 0 {
+			appDiff.Resources = nil
+			appDiff.EmptyReason = EmptyReasonHiddenByAnnotation
+		}
+
 		diffs = append(diffs, appDiff)
 	}
 
@@ -214,8 +336,60 @@ func GenerateAppDiffs(
 	return diffs, nil
 }
 
-// generateAppDiff generates the diff for a single app pair
-func generateAppDiff(pair Pair, contextLines uint, ignorePattern *regexp.Regexp, ignoreResourceRules []resource_filter.IgnoreResourceRule) (AppDiff, error) {
+// syncOptions returns the sync options of the Application of the pair. The target app wins,
+// since it is the one Argo CD syncs. Deleted apps use the base app.
+func (p *Pair) syncOptions() []string {
+	if p.Target != nil {
+		return p.Target.SyncOptions
+	}
+	if p.Base != nil {
+		return p.Base.SyncOptions
+	}
+	return nil
+}
+
+// diffSettings returns the per-application diff settings of the pair.
+// The target app wins, since its annotations describe the new state. Deleted apps use the base app.
+func (p *Pair) diffSettings() argoapplication.DiffSettings {
+	app := p.Target
+	if app == nil {
+		app = p.Base
+	}
+	if app == nil {
+		return argoapplication.DiffSettings{}
+	}
+	return argoapplication.ParseDiffSettings(app.Name, app.Annotations)
+}
+
+// applyDiffSettings merges per-application diff settings with the global options.
+// The line count is overridden, while ignore patterns and ignore rules are added on top of the global ones.
+func applyDiffSettings(
+	settings argoapplication.DiffSettings,
+	contextLines uint,
+	ignorePattern *regexp.Regexp,
+	ignoreResourceRules []resource_filter.IgnoreResourceRule,
+) (uint, *regexp.Regexp, []resource_filter.IgnoreResourceRule) {
+	if settings.LineCount != nil {
+		contextLines = *settings.LineCount
+	}
+
+	if settings.DiffIgnore != nil {
+		if ignorePattern == nil {
+			ignorePattern = settings.DiffIgnore
+		} else {
+			ignorePattern = regexp.MustCompile(fmt.Sprintf("(?:%s)|(?:%s)", ignorePattern.String(), settings.DiffIgnore.String()))
+		}
+	}
+
+	if len(settings.IgnoreResourceRules) > 0 {
+		ignoreResourceRules = append(slices.Clone(ignoreResourceRules), settings.IgnoreResourceRules...)
+	}
+
+	return contextLines, ignorePattern, ignoreResourceRules
+}
+
+// generateAppDiff generates the diff for a single app pair from its changed resources
+func generateAppDiff(pair Pair, changedResources []ResourcePair, options resourceDiffOptions) (AppDiff, error) {
 	diff := AppDiff{}
 
 	// Set names and paths
@@ -240,9 +414,6 @@ func generateAppDiff(pair Pair, contextLines uint, ignorePattern *regexp.Regexp,
 		return diff, nil // Both nil - shouldn't happen
 	}
 
-	// Get changed resources
-	changedResources := pair.ChangedResources()
-
 	// If no changed resources and it was a modification, it's unchanged.
 	// Added/deleted apps with zero resources should still be reported.
 	if len(changedResources) == 0 {
@@ -259,7 +430,7 @@ func generateAppDiff(pair Pair, contextLines uint, ignorePattern *regexp.Regexp,
 	}
 
 	// Build per-resource diffs
-	resources, added, deleted, err := buildResourceDiffs(changedResources, contextLines, ignorePattern, ignoreResourceRules)
+	resources, added, deleted, err := buildResourceDiffs(changedResources, options)
 	if err != nil {
 		return diff, err
 	}
@@ -273,17 +444,64 @@ func generateAppDiff(pair Pair, contextLines uint, ignorePattern *regexp.Regexp,
 	diff.Resources = resources
 	diff.AddedLines = added
 	diff.DeletedLines = deleted
+	diff.Changes = resourceChanges(resources)
+	if options.syncSteps {
+		diff.SyncSteps = buildSyncSteps(pair, options.ignoreResourceRules)
+	}
 
 	return diff, nil
 }
 
+// resourceChanges lists the resources that change when the app is synced. Skipped
+// resources are left out, and moved resources are only listed in the app they moved to.
+func resourceChanges(resources []ResourceDiff) []ResourceChange {
+	var changes []ResourceChange
+	for _, r := range resources {
+		if r.IsSkipped || r.MovedTo != "" {
+			continue
+		}
+		changes = append(changes, ResourceChange{
+			Kind:            r.Kind,
+			Namespace:       r.Namespace,
+			Name:            r.Name,
+			Action:          r.Action,
+			ReplaceReason:   r.ReplaceReason,
+			ImmutableFields: r.ImmutableFields,
+		})
+	}
+	return changes
+}
+
+// resourceAction returns what syncing does to a resource pair, and why it is replaced. A
+// resource moved from another app is replaced if its kind, namespace or name changed too.
+func resourceAction(rp *ResourcePair) (ResourceAction, string) {
+	switch {
+	case rp.MovedTo != "":
+		return ResourceMoved, ""
+	case rp.Base == nil:
+		return ResourceAdded, ""
+	case rp.Target == nil:
+		return ResourceDeleted, ""
+	case rp.Base.GetKind() != rp.Target.GetKind():
+		return ResourceReplaced, fmt.Sprintf("kind changed from %s to %s", rp.Base.GetKind(), rp.Target.GetKind())
+	case rp.Base.GetNamespace() != rp.Target.GetNamespace() || rp.Base.GetName() != rp.Target.GetName():
+		return ResourceReplaced, fmt.Sprintf("renamed from %s", qualifiedResourceName(rp.Base.GetNamespace(), rp.Base.GetName()))
+	case rp.MovedFrom != "":
+		return ResourceMoved, ""
+	default:
+		return ResourceChanged, ""
+	}
+}
+
+func qualifiedResourceName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
 // buildResourceDiffs generates per-resource diffs for all changed resources in an app
-func buildResourceDiffs(
-	resources []ResourcePair,
-	contextLines uint,
-	ignorePattern *regexp.Regexp,
-	ignoreResourceRules []resource_filter.IgnoreResourceRule,
-) ([]ResourceDiff, int, int, error) {
+func buildResourceDiffs(resources []ResourcePair, options resourceDiffOptions) ([]ResourceDiff, int, int, error) {
 	var result []ResourceDiff
 	totalAdded := 0
 	totalDeleted := 0
@@ -318,6 +536,18 @@ func buildResourceDiffs(
 
 	for _, rp := range sortedResources {
 		ref := getResourceRef(&rp)
+		action, replaceReason := resourceAction(&rp)
+		changedImmutableFields := immutable_fields.Changed(options.immutableFields, rp.Base, rp.Target)
+		if len(changedImmutableFields) > 0 && action == ResourceChanged {
+			action = ResourceReplaced
+			replaceReason = fmt.Sprintf("immutable field changed: %s", strings.Join(changedImmutableFields, ", "))
+		}
+
+		resource := rp.Target
+		if resource == nil {
+			resource = rp.Base
+		}
+		syncBehaviour := argoapplication.ParseSyncBehaviour(resource, options.appSyncOptions)
 
 		var oldKind, oldName, oldNamespace string
 		if rp.Base != nil && rp.Target != nil {
@@ -326,38 +556,62 @@ func buildResourceDiffs(
 			oldNamespace = rp.Base.GetNamespace()
 		}
 
-		// Check if this resource matches any ignore rules.
+		// Check if this resource matches any ignore rules, or is excluded because Argo CD ignores it when extraneous.
 		// If so, emit a skipped resource entry instead of the full diff.
-		if len(ignoreResourceRules) > 0 && resourceMatchesIgnoreRules(&rp, ignoreResourceRules) {
+		if (len(options.ignoreResourceRules) > 0 && resourceMatchesIgnoreRules(&rp, options.ignoreResourceRules)) ||
+			(options.excludeIgnoreExtraneous && syncBehaviour.IgnoreExtraneous) {
+			result = append(result, ResourceDiff{
+				Kind:          ref.kind,
+				OldKind:       oldKind,
+				Name:          ref.name,
+				OldName:       oldName,
+				Namespace:     ref.namespace,
+				OldNamespace:  oldNamespace,
+				IsSkipped:     true,
+				MovedFrom:     rp.MovedFrom,
+				MovedTo:       rp.MovedTo,
+				Action:        action,
+				SyncBehaviour: syncBehaviour,
+			})
+			continue
+		}
+
+		// The changes of a moved resource are shown in the app it was moved to
+		if rp.MovedTo != "" {
 			result = append(result, ResourceDiff{
-				Kind:         ref.kind,
-				OldKind:      oldKind,
-				Name:         ref.name,
-				OldName:      oldName,
-				Namespace:    ref.namespace,
-				OldNamespace: oldNamespace,
-				IsSkipped:    true,
+				Kind:          ref.kind,
+				Name:          ref.name,
+				Namespace:     ref.namespace,
+				MovedTo:       rp.MovedTo,
+				Action:        action,
+				SyncBehaviour: syncBehaviour,
 			})
 			continue
 		}
 
 		// Generate diff for this resource pair
-		diffResult, err := generateResourceDiff(rp, contextLines, ignorePattern)
+		diffResult, err := generateResourceDiff(rp, options.contextLines, options.ignorePattern)
 		if err != nil {
 			return nil, 0, 0, err
 		}
 
-		if diffResult.Content != "" {
+		// Moved resources are shown even if they are unchanged
+		if diffResult.Content != "" || rp.MovedFrom != "" {
 			result = append(result, ResourceDiff{
-				Kind:         ref.kind,
-				OldKind:      oldKind,
-				Name:         ref.name,
-				OldName:      oldName,
-				Namespace:    ref.namespace,
-				OldNamespace: oldNamespace,
-				Content:      diffResult.Content,
-				AddedLines:   diffResult.AddedLines,
-				DeletedLines: diffResult.DeletedLines,
+				Kind:            ref.kind,
+				OldKind:         oldKind,
+				Name:            ref.name,
+				OldName:         oldName,
+				Namespace:       ref.namespace,
+				OldNamespace:    oldNamespace,
+				Content:         diffResult.Content,
+				AddedLines:      diffResult.AddedLines,
+				DeletedLines:    diffResult.DeletedLines,
+				MovedFrom:       rp.MovedFrom,
+				Action:          action,
+				ReplaceReason:   replaceReason,
+				ImmutableFields: changedImmutableFields,
+				SyncBehaviour:   syncBehaviour,
 			})
 			totalAdded += diffResult.AddedLines
 			totalDeleted += diffResult.DeletedLines
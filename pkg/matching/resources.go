@@ -26,6 +26,11 @@ var (
 type ResourcePair struct {
 	Base   *unstructured.Unstructured // nil if resource was added
 	Target *unstructured.Unstructured // nil if resource was deleted
+
+	// MovedFrom is the name of the app the resource was moved from (set in the target app)
+	MovedFrom string
+	// MovedTo is the name of the app the resource was moved to (set in the base app, with a nil Target)
+	MovedTo string
 }
 
 // ChangedResources returns only the resources that differ between base and target
@@ -0,0 +1,199 @@
+package matching
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/dag-andersen/argocd-diff-preview/pkg/argoapplication"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/extract"
+	"github.com/dag-andersen/argocd-diff-preview/pkg/resource_filter"
+)
+
+// SyncChange is how a resource in a sync step changes between the branches
+type SyncChange string
+
+const (
+	SyncUnchanged SyncChange = "unchanged"
+	SyncAdded     SyncChange = "added"
+	SyncRemoved   SyncChange = "removed"
+	SyncChanged   SyncChange = "changed"
+	SyncMoved     SyncChange = "moved" // the resource is applied in another step than before
+)
+
+// phaseOrder is the order Argo CD runs the sync phases in
+var phaseOrder = []string{
+	argoapplication.PhasePreSync,
+	argoapplication.PhaseSync,
+	argoapplication.PhasePostSync,
+	argoapplication.PhaseSyncFail,
+}
+
+// SyncStep is a sync phase and wave, with the resources Argo CD applies in it
+type SyncStep struct {
+	Phase     string
+	Wave      int
+	Resources []SyncStepResource
+}
+
+// SyncStepResource is a resource applied in a sync step
+type SyncStepResource struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Hook      bool
+	Change    SyncChange
+	MovedFrom string // the step the resource was applied in before (only set if Change is SyncMoved)
+}
+
+// String returns the step like 'PreSync wave -1'
+func (s SyncStep) String() string {
+	return fmt.Sprintf("%s wave %d", s.Phase, s.Wave)
+}
+
+type syncStepKey struct {
+	phase string
+	wave  int
+}
+
+type syncResourceKey struct {
+	group     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// syncPlacement is a resource in one of the steps it is applied in
+type syncPlacement struct {
+	step     syncStepKey
+	resource *unstructured.Unstructured
+	hook     bool
+}
+
+// buildSyncSteps orders the resources and hooks of both branches of the app in the sync
+// phases and waves Argo CD applies them in, and marks what changed in each step. It returns
+// nil if all resources are applied in a single wave of the Sync phase, since the order is
+// then not interesting.
+func buildSyncSteps(pair Pair, ignoreResourceRules []resource_filter.IgnoreResourceRule) []SyncStep {
+	syncOptions := pair.syncOptions()
+	base := syncPlacements(pair.Base, syncOptions, ignoreResourceRules)
+	target := syncPlacements(pair.Target, syncOptions, ignoreResourceRules)
+
+	steps := map[syncStepKey]*SyncStep{}
+	addResource := func(step syncStepKey, key syncResourceKey, hook bool, change SyncChange, movedFrom string) {
+		if steps[step] == nil {
+			steps[step] = &SyncStep{Phase: step.phase, Wave: step.wave}
+		}
+		steps[step].Resources = append(steps[step].Resources, SyncStepResource{
+			Kind:      key.kind,
+			Namespace: key.namespace,
+			Name:      key.name,
+			Hook:      hook,
+			Change:    change,
+			MovedFrom: movedFrom,
+		})
+	}
+
+	for key, placements := range target {
+		basePlacements := base[key]
+
+		// The steps the resource is no longer applied in. A new step of the resource is a
+		// move from one of them, and the ones left over are removed.
+		var left []syncPlacement
+		for _, p := range basePlacements {
+			if !slices.ContainsFunc(placements, func(t syncPlacement) bool { return t.step == p.step }) {
+				left = append(left, p)
+			}
+		}
+
+		for _, placement := range placements {
+			i := slices.IndexFunc(basePlacements, func(p syncPlacement) bool { return p.step == placement.step })
+			switch {
+			case i >= 0 && reflect.DeepEqual(basePlacements[i].resource.Object, placement.resource.Object):
+				addResource(placement.step, key, placement.hook, SyncUnchanged, "")
+			case i >= 0:
+				addResource(placement.step, key, placement.hook, SyncChanged, "")
+			case len(left) > 0:
+				from := SyncStep{Phase: left[0].step.phase, Wave: left[0].step.wave}
+				left = left[1:]
+				addResource(placement.step, key, placement.hook, SyncMoved, from.String())
+			default:
+				addResource(placement.step, key, placement.hook, SyncAdded, "")
+			}
+		}
+		for _, placement := range left {
+			addResource(placement.step, key, placement.hook, SyncRemoved, "")
+		}
+	}
+	for key, placements := range base {
+		if _, exists := target[key]; exists {
+			continue
+		}
+		for _, placement := range placements {
+			addResource(placement.step, key, placement.hook, SyncRemoved, "")
+		}
+	}
+
+	if len(steps) == 0 {
+		return nil
+	}
+	if _, onlySync := steps[syncStepKey{phase: argoapplication.PhaseSync}]; onlySync && len(steps) == 1 {
+		return nil
+	}
+
+	result := make([]SyncStep, 0, len(steps))
+	for _, step := range steps {
+		sort.Slice(step.Resources, func(i, j int) bool {
+			a, b := step.Resources[i], step.Resources[j]
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+		result = append(result, *step)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Phase != result[j].Phase {
+			return slices.Index(phaseOrder, result[i].Phase) < slices.Index(phaseOrder, result[j].Phase)
+		}
+		return result[i].Wave < result[j].Wave
+	})
+	return result
+}
+
+// syncPlacements returns the steps each resource and Helm hook of the app is applied in
+func syncPlacements(app *extract.ExtractedApp, syncOptions []string, ignoreResourceRules []resource_filter.IgnoreResourceRule) map[syncResourceKey][]syncPlacement {
+	placements := map[syncResourceKey][]syncPlacement{}
+	if app == nil {
+		return placements
+	}
+
+	manifests := append(slices.Clone(app.Manifests), app.HelmHooks...)
+	for i := range manifests {
+		resource := &manifests[i]
+		if resource_filter.MatchesAnyIgnoreRule(resource, ignoreResourceRules) {
+			continue
+		}
+		behaviour := argoapplication.ParseSyncBehaviour(resource, syncOptions)
+		key := syncResourceKey{
+			group:     resource.GroupVersionKind().Group,
+			kind:      resource.GetKind(),
+			namespace: resource.GetNamespace(),
+			name:      resource.GetName(),
+		}
+		for _, phase := range behaviour.Phases() {
+			placements[key] = append(placements[key], syncPlacement{
+				step:     syncStepKey{phase: phase, wave: behaviour.Wave()},
+				resource: resource,
+				hook:     behaviour.IsHook(),
+			})
+		}
+	}
+	return placements
+}
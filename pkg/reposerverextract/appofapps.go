@@ -181,6 +181,9 @@ func RenderApplicationsFromBothBranchesWithAppOfApps(
 		kustomizeBuildOptions = ""
 	}
 
+	// Global ignoreDifferences, resource exclusions and compare options from argocd-cm
+	argocdSettings := extract.LoadArgoCDSettings(argocd)
+
 	// Collect all unique repository URLs referenced by the Applications so that
 	// FetchRepoCreds can enrich them with credentials from repo-creds templates.
 	appRepoURLs := collectRepoURLs(baseApps, targetApps)
@@ -299,7 +302,7 @@ func RenderApplicationsFromBothBranchesWithAppOfApps(
 
 				// Enqueue children that haven't been seen yet and pass the selection filter.
 				// Child apps are filtered by Selector, FilesChanged (via watch-pattern annotations),
-				// IgnoreInvalidWatchPattern, and WatchIfNoWatchPatternFound — exactly as top-level apps are.
+				// IgnoreInvalidWatchPattern, WatchIfNoWatchPatternFound, InferDependencies and the spec fields — exactly as top-level apps are.
 				// FilesChanged works correctly here: the PR diff is the same regardless of whether an
 				// app was discovered from a file or from a parent's rendered output; the watch pattern
 				// on the child app is what determines whether it is affected.
@@ -313,6 +316,15 @@ func RenderApplicationsFromBothBranchesWithAppOfApps(
 						FilesChanged:               appSelectionOptions.FilesChanged,
 						IgnoreInvalidWatchPattern:  appSelectionOptions.IgnoreInvalidWatchPattern,
 						WatchIfNoWatchPatternFound: appSelectionOptions.WatchIfNoWatchPatternFound,
+						InferDependencies:          appSelectionOptions.InferDependencies,
+						RepoSelector:               appSelectionOptions.RepoSelector,
+						DestinationNames:           appSelectionOptions.DestinationNames,
+						DestinationServers:         appSelectionOptions.DestinationServers,
+						DestinationNamespaces:      appSelectionOptions.DestinationNamespaces,
+						Projects:                   appSelectionOptions.Projects,
+						SourceRepoURLs:             appSelectionOptions.SourceRepoURLs,
+						SourceCharts:               appSelectionOptions.SourceCharts,
+						SourcePaths:                appSelectionOptions.SourcePaths,
 						// FileRegex intentionally omitted: child apps have no real file path
 					}
 					selection := argoapplication.ApplicationSelection(r.childApps, childSelectionOptions)
@@ -362,7 +374,7 @@ func RenderApplicationsFromBothBranchesWithAppOfApps(
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(remainingTime())*time.Second)
 			defer cancel()
 
-			manifests, childApps, err := renderAppWithChildDiscovery(ctx, repoClient, argocd, item.app, branchFolderByType, branchByType, namespacedScopedResources, creds, &repoSelector, argocd.Namespace, tempFolder, item.depth, kubeVersion, apiVersions, kustomizeBuildOptions, redirectRevisions)
+			manifests, helmHooks, childApps, err := renderAppWithChildDiscovery(ctx, repoClient, argocd, item.app, branchFolderByType, branchByType, namespacedScopedResources, creds, &repoSelector, argocd.Namespace, tempFolder, item.depth, kubeVersion, apiVersions, kustomizeBuildOptions, argocdSettings, redirectRevisions)
 			if err != nil {
 				results <- renderResult{err: fmt.Errorf("failed to render app %s: %w", item.app.GetLongName(), err)}
 				return
@@ -370,7 +382,12 @@ func RenderApplicationsFromBothBranchesWithAppOfApps(
 
 			renderedApps.Add(1)
 			results <- renderResult{
-				extracted: extract.CreateExtractedApp(item.app.Id, item.app.Name, item.app.FileName, manifests, item.app.Branch),
+				extracted: extract.CreateExtractedApp(item.app.Id, item.app.Name, item.app.FileName, manifests, item.app.Branch, extract.ExtractedAppOptions{
+					Cluster:     item.app.DestinationCluster(),
+					Annotations: item.app.Yaml.GetAnnotations(),
+					SyncOptions: item.app.SyncOptions(),
+					HelmHooks:   helmHooks,
+				}),
 				childApps: childApps,
 				depth:     item.depth,
 			}
@@ -453,11 +470,12 @@ func renderAppWithChildDiscovery(
 	kubeVersion string,
 	apiVersions []string,
 	kustomizeBuildOptions string,
+	argocdSettings *extract.ArgoCDSettings,
 	redirectRevisions []string,
-) ([]unstructured.Unstructured, []argoapplication.ArgoResource, error) {
-	allManifests, err := renderApp(ctx, repoClient, app, branchFolderByType, namespacedScopedResources, creds, repoSelector, kubeVersion, apiVersions, kustomizeBuildOptions, helmChartPuller{})
+) ([]unstructured.Unstructured, []unstructured.Unstructured, []argoapplication.ArgoResource, error) {
+	allManifests, helmHooks, err := renderApp(ctx, repoClient, app, branchFolderByType, namespacedScopedResources, creds, repoSelector, kubeVersion, apiVersions, kustomizeBuildOptions, argocdSettings, false, helmChartPuller{})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	// Discover child Application/ApplicationSet resources in the rendered manifests.
@@ -551,6 +569,7 @@ func renderAppWithChildDiscovery(
 						Msg("⚠️ Could not patch ApplicationSet-generated Application; skipping")
 					continue
 				}
+				child.SetOriginalFromAppSet(*patchedAppSet)
 				childApps = append(childApps, *child)
 				log.Debug().
 					Str("parentApp", app.Name).
@@ -567,5 +586,5 @@ func renderAppWithChildDiscovery(
 			Msgf("🔍 Discovered %d child Application(s) in rendered manifests", len(childApps))
 	}
 
-	return allManifests, childApps, nil
+	return allManifests, helmHooks, childApps, nil
 }
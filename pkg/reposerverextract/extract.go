@@ -64,6 +64,9 @@ func (p *resourceInfoProvider) IsNamespaced(gk schema.GroupKind) (bool, error) {
 // locally; those sources are rendered via the remote GenerateManifest RPC so
 // the repo server fetches them itself.
 //
+// noCache makes the repo server render remote sources again instead of returning
+// its cached manifests, which is needed to compare two renders of the same sources.
+//
 // The return type is identical to extract.RenderApplicationsFromBothBranches
 // so that callers can swap implementations with minimal changes.
 func RenderApplicationsFromBothBranches(
@@ -75,6 +78,7 @@ func RenderApplicationsFromBothBranches(
 	baseApps []argoapplication.ArgoResource,
 	targetApps []argoapplication.ArgoResource,
 	repoSelector repository.Selector,
+	noCache bool,
 ) ([]extract.ExtractedApp, []extract.ExtractedApp, time.Duration, error) {
 	startTime := time.Now()
 
@@ -115,6 +119,9 @@ func RenderApplicationsFromBothBranches(
 		log.Info().Msgf("🔧 Using kustomize build options from argocd-cm: %s", kustomizeBuildOptions)
 	}
 
+	// Global ignoreDifferences, resource exclusions and compare options from argocd-cm
+	argocdSettings := extract.LoadArgoCDSettings(argocd)
+
 	// Collect all unique repository URLs referenced by the Applications so that
 	// FetchRepoCreds can enrich them with credentials from repo-creds templates.
 	appRepoURLs := collectRepoURLs(baseApps, targetApps)
@@ -194,14 +201,19 @@ func RenderApplicationsFromBothBranches(
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(remainingTime())*time.Second)
 			defer cancel()
 
-			manifests, err := renderApp(ctx, repoClient, app, branchFolderByType, namespacedScopedResources, creds, &repoSelector, kubeVersion, apiVersions, kustomizeBuildOptions, helmChartPuller{})
+			manifests, helmHooks, err := renderApp(ctx, repoClient, app, branchFolderByType, namespacedScopedResources, creds, &repoSelector, kubeVersion, apiVersions, kustomizeBuildOptions, argocdSettings, noCache, helmChartPuller{})
 			if err != nil {
 				results <- result{err: fmt.Errorf("failed to render app %s: %w", app.GetLongName(), err)}
 				return
 			}
 
 			renderedApps.Add(1)
-			results <- result{app: extract.CreateExtractedApp(app.Id, app.Name, app.FileName, manifests, app.Branch)}
+			results <- result{app: extract.CreateExtractedApp(app.Id, app.Name, app.FileName, manifests, app.Branch, extract.ExtractedAppOptions{
+				Cluster:     app.DestinationCluster(),
+				Annotations: app.Yaml.GetAnnotations(),
+				SyncOptions: app.SyncOptions(),
+				HelmHooks:   helmHooks,
+			})}
 		}(app)
 	}
 
@@ -272,16 +284,18 @@ func renderApp(
 	kubeVersion string,
 	apiVersions []string,
 	kustomizeBuildOptions string,
+	argocdSettings *extract.ArgoCDSettings,
+	noCache bool,
 	puller chartPuller,
-) ([]unstructured.Unstructured, error) {
+) ([]unstructured.Unstructured, []unstructured.Unstructured, error) {
 	branchFolder, ok := branchFolderByType[app.Branch]
 	if !ok {
-		return nil, fmt.Errorf("unknown branch type: %s", app.Branch)
+		return nil, nil, fmt.Errorf("unknown branch type: %s", app.Branch)
 	}
 
 	contentSources, refSources, hasMultipleSources, err := splitSources(app)
 	if err != nil {
-		return nil, fmt.Errorf("failed to split sources: %w", err)
+		return nil, nil, fmt.Errorf("failed to split sources: %w", err)
 	}
 
 	var allManifestStrings []string
@@ -299,11 +313,12 @@ func renderApp(
 				kubeVersion:           kubeVersion,
 				apiVersions:           apiVersions,
 				kustomizeBuildOptions: kustomizeBuildOptions,
+				noCache:               noCache,
 				puller:                puller,
 			},
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to build manifest request for content source %d: %w", i, err)
+			return nil, nil, fmt.Errorf("failed to build manifest request for content source %d: %w", i, err)
 		}
 
 		// streamDir == "" signals that the primary source is a remote chart (e.g. an
@@ -331,7 +346,7 @@ func renderApp(
 			cleanup()
 		}
 		if err != nil {
-			return nil, fmt.Errorf("repo server returned error for content source %d: %w", i, err)
+			return nil, nil, fmt.Errorf("repo server returned error for content source %d: %w", i, err)
 		}
 
 		allManifestStrings = append(allManifestStrings, manifestStrings...)
@@ -339,7 +354,7 @@ func renderApp(
 
 	if len(allManifestStrings) == 0 {
 		log.Warn().Str("App", app.GetLongName()).Msg("⚠️ Repo server returned no manifests")
-		return []unstructured.Unstructured{}, nil
+		return []unstructured.Unstructured{}, nil, nil
 	}
 
 	// Parse JSON manifest strings into unstructured objects.
@@ -347,7 +362,7 @@ func renderApp(
 	for i, raw := range allManifestStrings {
 		var obj map[string]any
 		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal manifest %d for %s: %w", i, app.GetLongName(), err)
+			return nil, nil, fmt.Errorf("failed to unmarshal manifest %d for %s: %w", i, app.GetLongName(), err)
 		}
 		if len(obj) == 0 {
 			continue
@@ -375,25 +390,23 @@ func renderApp(
 	// Apply ignoreDifferences rules using the shared implementation in pkg/extract.
 	extract.ApplyIgnoreDifferences(manifests, app)
 
+	// Apply the global settings from argocd-cm
+	manifests = argocdSettings.Apply(manifests, app)
+
 	if err := removeArgoCDTrackingID(manifests); err != nil {
-		return nil, fmt.Errorf("failed to remove Argo CD tracking ID: %w", err)
+		return nil, nil, fmt.Errorf("failed to remove Argo CD tracking ID: %w", err)
 	}
 
 	destNamespace, _, _ := unstructured.NestedString(app.Yaml.Object, "spec", "destination", "namespace")
 	manifests, err = normalizeNamespaces(manifests, destNamespace, namespacedScopedResources, app.GetLongName())
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Filter out Helm hook resources (reuse the exported helper from extract).
-	filtered := make([]unstructured.Unstructured, 0, len(manifests))
-	for _, m := range manifests {
-		if extract.HelmHookFilter(m) {
-			filtered = append(filtered, m)
-		}
-	}
+	// Keep Helm hook resources apart (reuse the exported helper from extract).
+	filtered, helmHooks := extract.SplitHelmHooks(manifests)
 
-	return filtered, nil
+	return filtered, helmHooks, nil
 }
 
 // collectRepoURLs extracts all unique repository URLs referenced by the given
@@ -542,6 +555,8 @@ type manifestRequestRenderContext struct {
 	// from argocd-cm. Argo CD's API server passes it to the repo server on
 	// every request; the repo server never reads the ConfigMap itself.
 	kustomizeBuildOptions string
+	// noCache makes the repo server bypass its manifest cache for remote sources
+	noCache bool
 	// puller fetches remote Helm charts so they can be streamed to the repo
 	// server together with same-repo $ref value files. When nil, remote charts
 	// with ref sources fall back to the unary GenerateManifest RPC.
@@ -587,6 +602,7 @@ func buildManifestRequestForSource(
 			// errors when checking dependency repositories.
 			ProjectName:        "default",
 			ProjectSourceRepos: []string{"*"},
+			NoCache:            renderContext.noCache,
 		}
 		if renderContext.kustomizeBuildOptions != "" {
 			request.KustomizeOptions = &v1alpha1.KustomizeOptions{
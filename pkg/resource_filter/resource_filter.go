@@ -2,25 +2,42 @@ package resource_filter
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // IgnoreResourceRule represents a rule to skip a difference in the diff
 type IgnoreResourceRule struct {
-	Group string
-	Kind  string
-	Name  string
+	Group     string
+	Kind      string
+	Namespace string          // empty means any namespace
+	Name      string          // glob, or a regex if prefixed with '~'
+	Labels    labels.Selector // nil means any labels
+	Include   bool            // from --only-resources: resources matching no include rule are skipped
+
+	nameRegex *regexp.Regexp
 }
 
 // String returns the string representation of the IgnoreResourceRule
 func (s *IgnoreResourceRule) String() string {
-	return fmt.Sprintf("[Group: %s, Kind: %s, Name: %s]", s.Group, s.Kind, s.Name)
+	fields := []string{fmt.Sprintf("Group: %s", s.Group), fmt.Sprintf("Kind: %s", s.Kind)}
+	if s.Namespace != "" {
+		fields = append(fields, fmt.Sprintf("Namespace: %s", s.Namespace))
+	}
+	fields = append(fields, fmt.Sprintf("Name: %s", s.Name))
+	if s.Labels != nil {
+		fields = append(fields, fmt.Sprintf("Labels: %s", s.Labels.String()))
+	}
+	return "[" + strings.Join(fields, ", ") + "]"
 }
 
-// format is --ignore-resources="group:kind:name,group:kind:name"
-// * means any value
+// format is --ignore-resources="group:kind:name,group:kind:namespace/name@selector"
+// * means any value, group, kind, namespace and name are globs, a name prefixed with ~
+// is a regex, and the optional selector is a Kubernetes label selector
 
 // FromString creates a new IgnoreResourceRule from a string representation
 func FromString(s string) ([]IgnoreResourceRule, error) {
@@ -28,47 +45,146 @@ func FromString(s string) ([]IgnoreResourceRule, error) {
 		return nil, nil
 	}
 
-	rules := strings.Split(s, ",")
-
 	var ignoreResourceRules []IgnoreResourceRule
 
-	for _, rule := range rules {
-		rule = strings.TrimSpace(rule)
-		if rule == "" {
-			continue
+	for _, rule := range splitRules(s) {
+		// Label selectors can not contain '@', so the last '@' separates the name from the selector
+		resource, selector, hasSelector := rule, "", false
+		if i := strings.LastIndex(rule, "@"); i >= 0 {
+			resource, selector, hasSelector = rule[:i], rule[i+1:], true
 		}
 
-		parts := strings.Split(rule, ":")
+		parts := strings.Split(resource, ":")
 		if len(parts) != 3 {
-			return nil, fmt.Errorf("invalid ignore resource rule format: %s (expected group:kind:name)", rule)
+			return nil, fmt.Errorf("invalid ignore resource rule format: %s (expected group:kind:name or group:kind:namespace/name@selector)", rule)
 		}
 
-		ignoreResourceRules = append(ignoreResourceRules, IgnoreResourceRule{
+		ignoreResourceRule := IgnoreResourceRule{
 			Group: strings.TrimSpace(parts[0]),
 			Kind:  strings.TrimSpace(parts[1]),
 			Name:  strings.TrimSpace(parts[2]),
-		})
+		}
+		// Namespaces can not contain '/', so the first '/' separates the namespace from the name
+		if namespace, name, found := strings.Cut(ignoreResourceRule.Name, "/"); found {
+			ignoreResourceRule.Namespace, ignoreResourceRule.Name = strings.TrimSpace(namespace), strings.TrimSpace(name)
+		}
+
+		for _, pattern := range []string{ignoreResourceRule.Group, ignoreResourceRule.Kind, ignoreResourceRule.Namespace} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s' in ignore resource rule: %s", pattern, rule)
+			}
+		}
+		if expr, ok := strings.CutPrefix(ignoreResourceRule.Name, "~"); ok {
+			nameRegex, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid name regex '%s' in ignore resource rule: %w", expr, err)
+			}
+			ignoreResourceRule.nameRegex = nameRegex
+		} else if _, err := path.Match(ignoreResourceRule.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s' in ignore resource rule: %s", ignoreResourceRule.Name, rule)
+		}
+
+		if hasSelector {
+			labelSelector, err := labels.Parse(strings.TrimSpace(selector))
+			if err != nil {
+				return nil, fmt.Errorf("invalid label selector in ignore resource rule: %w", err)
+			}
+			ignoreResourceRule.Labels = labelSelector
+		}
+
+		ignoreResourceRules = append(ignoreResourceRules, ignoreResourceRule)
 	}
 
 	return ignoreResourceRules, nil
 }
 
+// IncludeFromString creates the rules of --only-resources, in the same format as FromString
+func IncludeFromString(s string) ([]IgnoreResourceRule, error) {
+	rules, err := FromString(s)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		rules[i].Include = true
+	}
+	return rules, nil
+}
+
+// splitRules splits comma-separated rules. Label selectors use commas as well, so a part
+// following a rule with a selector is added to the selector unless it is a rule itself.
+func splitRules(s string) []string {
+	var rules []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if last := len(rules) - 1; last >= 0 && !strings.Contains(part, ":") && strings.Contains(rules[last], "@") {
+			rules[last] += "," + part
+			continue
+		}
+		rules = append(rules, part)
+	}
+	return rules
+}
+
 // matches checks if the IgnoreResourceRule matches the given group, kind, and name
 // A "*" in any field matches any value
 func (s *IgnoreResourceRule) matches(group, kind, name string) bool {
-	return (s.Group == "*" || s.Group == group) &&
-		(s.Kind == "*" || s.Kind == kind) &&
-		(s.Name == "*" || s.Name == name)
+	return matchesGlob(s.Group, group) &&
+		matchesGlob(s.Kind, kind) &&
+		s.matchesName(name)
+}
+
+// matchesManifest checks if the IgnoreResourceRule matches the manifest, including its
+// namespace and labels
+func (s *IgnoreResourceRule) matchesManifest(manifest *unstructured.Unstructured) bool {
+	if !s.matches(groupFromAPIVersion(manifest.GetAPIVersion()), manifest.GetKind(), manifest.GetName()) {
+		return false
+	}
+	if s.Namespace != "" && !matchesGlob(s.Namespace, manifest.GetNamespace()) {
+		return false
+	}
+	return s.Labels == nil || s.Labels.Matches(labels.Set(manifest.GetLabels()))
 }
 
+func (s *IgnoreResourceRule) matchesName(name string) bool {
+	if expr, ok := strings.CutPrefix(s.Name, "~"); ok {
+		if s.nameRegex == nil {
+			// Rules not created by FromString
+			matched, err := regexp.MatchString("^(?:"+expr+")$", name)
+			return err == nil && matched
+		}
+		return s.nameRegex.MatchString(name)
+	}
+	return matchesGlob(s.Name, name)
+}
+
+func matchesGlob(pattern, value string) bool {
+	if pattern == "*" || pattern == value {
+		return true
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// MatchesAnyIgnoreRule returns true if the manifest should be skipped: it matches an ignore
+// rule, or there are include rules and it matches none of them
 func MatchesAnyIgnoreRule(manifest *unstructured.Unstructured, ignoreResourceRules []IgnoreResourceRule) bool {
-	group := groupFromAPIVersion(manifest.GetAPIVersion())
+	hasIncludeRules, included := false, false
 	for _, rule := range ignoreResourceRules {
-		if rule.matches(group, manifest.GetKind(), manifest.GetName()) {
+		if rule.Include {
+			hasIncludeRules = true
+			if !included && rule.matchesManifest(manifest) {
+				included = true
+			}
+			continue
+		}
+		if rule.matchesManifest(manifest) {
 			return true
 		}
 	}
-	return false
+	return hasIncludeRules && !included
 }
 
 func groupFromAPIVersion(apiVersion string) string {
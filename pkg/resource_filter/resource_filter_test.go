@@ -468,3 +468,135 @@ func TestMatchesAnySkipRule(t *testing.T) {
 		})
 	}
 }
+
+func TestFromString_NamespaceAndLabels(t *testing.T) {
+	rules, err := FromString(":ConfigMap:monitoring/*-dashboard, *:*:*@app.kubernetes.io/component=test,tier in (web, api), apps:Deployment:~web-[0-9]+")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("got %d rules, want 3: %+v", len(rules), rules)
+	}
+
+	if rules[0].Namespace != "monitoring" || rules[0].Name != "*-dashboard" || rules[0].Labels != nil {
+		t.Errorf("unexpected first rule: %s", rules[0].String())
+	}
+	if rules[1].Labels == nil || rules[1].Labels.String() != "app.kubernetes.io/component=test,tier in (api,web)" {
+		t.Errorf("unexpected second rule: %s", rules[1].String())
+	}
+	if rules[2].Name != "~web-[0-9]+" || rules[2].nameRegex == nil {
+		t.Errorf("unexpected third rule: %s", rules[2].String())
+	}
+
+	for _, invalid := range []string{
+		"apps:Deployment:~web-[",
+		"apps:Deployment:[web",
+		"apps:Deployment:web@app in",
+		"apps:Deployment:web:app=a",
+	} {
+		if _, err := FromString(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestMatchesAnyIgnoreRule_NamespaceGlobRegexAndLabels(t *testing.T) {
+	manifest := func(kind, namespace, name string, labels map[string]any) *unstructured.Unstructured {
+		metadata := map[string]any{"name": name, "namespace": namespace}
+		if labels != nil {
+			metadata["labels"] = labels
+		}
+		return &unstructured.Unstructured{Object: map[string]any{"apiVersion": "v1", "kind": kind, "metadata": metadata}}
+	}
+
+	tests := []struct {
+		name     string
+		rules    string
+		manifest *unstructured.Unstructured
+		expected bool
+	}{
+		{
+			name:     "glob name in namespace",
+			rules:    ":ConfigMap:monitoring/*-dashboard",
+			manifest: manifest("ConfigMap", "monitoring", "nodes-dashboard", nil),
+			expected: true,
+		},
+		{
+			name:     "glob name in other namespace",
+			rules:    ":ConfigMap:monitoring/*-dashboard",
+			manifest: manifest("ConfigMap", "default", "nodes-dashboard", nil),
+			expected: false,
+		},
+		{
+			name:     "glob kind",
+			rules:    ":*Map:*",
+			manifest: manifest("ConfigMap", "default", "settings", nil),
+			expected: true,
+		},
+		{
+			name:     "regex name must match fully",
+			rules:    ":ConfigMap:~dash",
+			manifest: manifest("ConfigMap", "default", "nodes-dashboard", nil),
+			expected: false,
+		},
+		{
+			name:     "regex name",
+			rules:    ":ConfigMap:~.*-dash(board)?",
+			manifest: manifest("ConfigMap", "default", "nodes-dashboard", nil),
+			expected: true,
+		},
+		{
+			name:     "label selector",
+			rules:    "*:*:*@app.kubernetes.io/component=test",
+			manifest: manifest("Pod", "default", "smoke", map[string]any{"app.kubernetes.io/component": "test"}),
+			expected: true,
+		},
+		{
+			name:     "label selector without labels",
+			rules:    "*:*:*@app.kubernetes.io/component=test",
+			manifest: manifest("Pod", "default", "web", nil),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := FromString(tt.rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result := MatchesAnyIgnoreRule(tt.manifest, rules); result != tt.expected {
+				t.Errorf("MatchesAnyIgnoreRule() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchesAnyIgnoreRule_Include(t *testing.T) {
+	include, err := IncludeFromString("apps:*:*,:Service:*")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ignore, err := FromString("apps:Deployment:debug")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rules := append(ignore, include...)
+
+	manifest := func(apiVersion, kind, name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{"apiVersion": apiVersion, "kind": kind, "metadata": map[string]any{"name": name}}}
+	}
+
+	if MatchesAnyIgnoreRule(manifest("apps/v1", "Deployment", "web"), rules) {
+		t.Errorf("expected an included Deployment to be kept")
+	}
+	if MatchesAnyIgnoreRule(manifest("v1", "Service", "web"), rules) {
+		t.Errorf("expected an included Service to be kept")
+	}
+	if !MatchesAnyIgnoreRule(manifest("v1", "ConfigMap", "web"), rules) {
+		t.Errorf("expected a resource matching no include rule to be skipped")
+	}
+	if !MatchesAnyIgnoreRule(manifest("apps/v1", "Deployment", "debug"), rules) {
+		t.Errorf("expected ignore rules to take precedence over include rules")
+	}
+}